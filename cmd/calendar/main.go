@@ -5,16 +5,21 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/bobrovka/calendar/internal"
+	"github.com/bobrovka/calendar/internal/auth"
 	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/egress"
+	"github.com/bobrovka/calendar/internal/gateway"
 	"github.com/bobrovka/calendar/internal/scheduler"
 	"github.com/bobrovka/calendar/internal/scheduler/producer"
 	"github.com/bobrovka/calendar/internal/service"
 	pg "github.com/bobrovka/calendar/internal/storage/storage-pg"
+	"github.com/bobrovka/calendar/internal/web"
 	"github.com/bobrovka/calendar/pkg/calendar/api"
 	"github.com/go-errors/errors"
 	"github.com/heetch/confita"
@@ -54,13 +59,40 @@ func main() {
 	storage, err := pg.NewStoragePg(cfg.PgUser, cfg.PgPassword, cfg.PgHost, cfg.PgPort, cfg.PgName)
 	failOnError(err, "cannot create storage")
 
-	app, err := app.NewCalendar(storage, sugaredLogger)
+	// changes made by other instances reach watchers through postgres LISTEN/NOTIFY
+	changeFeed := pg.NewChangeFeed(storage, sugaredLogger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = changeFeed.Listen(ctx)
+	}()
+
+	// webhooks may only target internal hosts listed in the config
+	egressPolicy, err := egress.NewPolicy(cfg.WebhookAllowedHosts)
+	failOnError(err, "bad webhook allowed hosts")
+
+	app, err := app.NewCalendar(storage, sugaredLogger, app.WithChangeBus(changeFeed), app.WithEgressPolicy(egressPolicy))
 	failOnError(err, "cannot create app instance")
 
 	eventService := service.NewEventService(app, sugaredLogger)
 
+	authenticator, err := getAuthenticator(cfg)
+	failOnError(err, "cannot set up authentication")
+
+	var webHandler http.Handler = web.NewHandler(app, sugaredLogger)
+	var serverOptions []grpc.ServerOption
+	if authenticator != nil {
+		serverOptions = append(serverOptions,
+			grpc.UnaryInterceptor(auth.UnaryServerInterceptor(authenticator)),
+			grpc.StreamInterceptor(auth.StreamServerInterceptor(authenticator)),
+		)
+		webHandler = auth.Middleware(authenticator, webHandler)
+	} else {
+		sugaredLogger.Warn("authentication is disabled, callers may act as any user")
+	}
+
 	// Create grpc server
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(serverOptions...)
 	reflection.Register(grpcServer)
 
 	api.RegisterEventsServer(grpcServer, eventService)
@@ -74,8 +106,28 @@ func main() {
 		exitChannel <- grpcServer.Serve(lis)
 	}()
 
+	// REST gateway calls the grpc server like any other client
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	failOnError(err, "cannot connect gateway to grpc server")
+	defer conn.Close()
+
+	webMux := http.NewServeMux()
+	webMux.Handle(gateway.Prefix, gateway.NewHandler(api.NewEventsClient(conn), sugaredLogger))
+	webMux.Handle("/", webHandler)
+
+	// start calendar feed and REST server
+	webServer := &http.Server{
+		Addr:    cfg.WebListen,
+		Handler: webMux,
+	}
+	if cfg.WebListen != "" {
+		go func() {
+			exitChannel <- webServer.ListenAndServe()
+		}()
+	}
+
 	go func() {
-		termChan := make(chan os.Signal)
+		termChan := make(chan os.Signal, 1)
 		signal.Notify(termChan, syscall.SIGINT, syscall.SIGTERM)
 
 		<-termChan
@@ -91,6 +143,13 @@ func main() {
 	), "event.exchange", "direct", "event.queue", "event.notification")
 	sched := scheduler.NewScheduler(producer, storage, sugaredLogger)
 
+	go func() {
+		err := producer.KeepConnection()
+		if err != nil {
+			sugaredLogger.Warnw("connection ends", "err", err)
+		}
+		exitChannel <- err
+	}()
 	go func() {
 		exitChannel <- sched.Run()
 	}()
@@ -98,10 +157,17 @@ func main() {
 	err = <-exitChannel
 	log.Println("stopped with err: ", err)
 
+	// watch streams never end on their own
+	changeFeed.Close()
 	grpcServer.GracefulStop()
-	err = sched.Stop()
+	err = webServer.Shutdown(context.Background())
 	if err != nil {
-		log.Println("cannot gracefully stop scheduler, err: ", err)
+		log.Println("cannot gracefully stop web server, err: ", err)
+	}
+	sched.Stop()
+	err = producer.GracefulStop()
+	if err != nil {
+		log.Println("cannot gracefully stop producer, err: ", err)
 	}
 }
 
@@ -111,6 +177,26 @@ func failOnError(err error, msg string) {
 	}
 }
 
+// getAuthenticator returns nil when neither JWT key nor token file is configured
+func getAuthenticator(cfg *internal.Config) (auth.Authenticator, error) {
+	var chain auth.Chain
+	if cfg.AuthJWTKey != "" {
+		chain = append(chain, auth.NewJWT([]byte(cfg.AuthJWTKey)))
+	}
+	if cfg.AuthTokenFile != "" {
+		tokens, err := auth.LoadTokenFile(cfg.AuthTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, tokens)
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
 func getConfig() *internal.Config {
 	if configPath == "" {
 		log.Fatal("no config file")
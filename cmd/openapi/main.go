@@ -0,0 +1,34 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/bobrovka/calendar/internal/gateway"
+	flag "github.com/spf13/pflag"
+)
+
+var outputPath string
+
+func init() {
+	flag.StringVarP(&outputPath, "output", "o", "", "path to write OpenAPI document to, stdout if empty")
+}
+
+func main() {
+	flag.Parse()
+
+	data, err := gateway.OpenAPI()
+	if err != nil {
+		log.Fatalf("cannot build OpenAPI document: %s", err)
+	}
+
+	if outputPath == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = ioutil.WriteFile(outputPath, data, 0644)
+	}
+	if err != nil {
+		log.Fatalf("cannot write OpenAPI document: %s", err)
+	}
+}
@@ -2,29 +2,38 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
+	"time"
 
 	"github.com/bobrovka/calendar/internal"
 	"github.com/bobrovka/calendar/internal/consumer"
+	"github.com/bobrovka/calendar/internal/egress"
 	"github.com/bobrovka/calendar/internal/models"
+	"github.com/bobrovka/calendar/internal/notifier"
+	"github.com/bobrovka/calendar/internal/queue"
+	"github.com/bobrovka/calendar/internal/sender"
+	pg "github.com/bobrovka/calendar/internal/storage/storage-pg"
 	"github.com/heetch/confita"
 	"github.com/heetch/confita/backend/file"
 	flag "github.com/spf13/pflag"
-	"github.com/streadway/amqp"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
-var configPath string
+var (
+	configPath string
+	dlqList    int
+	dlqReplay  int
+)
 
 func init() {
 	flag.StringVarP(&configPath, "config", "c", "", "path to config file")
+	flag.IntVar(&dlqList, "dlq-list", 0, "print up to N dead letters and exit")
+	flag.IntVar(&dlqReplay, "dlq-replay", 0, "move up to N dead letters back to the queue and exit")
 }
 
 func main() {
@@ -49,47 +58,74 @@ func main() {
 		cfg.RabbitPort,
 	), "event.exchange", "direct", "event.queue", "event.notification")
 
+	if dlqList > 0 || dlqReplay > 0 {
+		deadLetters(mqConsumer)
+		return
+	}
+
+	// profiles tell which channels a user wants reminders in
+	storage, err := pg.NewStoragePg(cfg.PgUser, cfg.PgPassword, cfg.PgHost, cfg.PgPort, cfg.PgName)
+	failOnError(err, "cannot create storage")
+
+	templates, err := notifier.LoadTemplates(cfg.NotificationTemplates)
+	failOnError(err, "cannot load notification templates")
+
+	file, err := notifier.NewFile(cfg.NotificationFile, templates)
+	failOnError(err, "cannot open notification file")
+	defer file.Close()
+
+	// the address is checked again on dial, a host may resolve to an internal address after the profile was saved
+	egressPolicy, err := egress.NewPolicy(cfg.WebhookAllowedHosts)
+	failOnError(err, "bad webhook allowed hosts")
+
+	notifiers := map[models.Channel]notifier.Notifier{
+		models.ChannelFile:    file,
+		models.ChannelWebhook: notifier.NewWebhook(10*time.Second, templates, egressPolicy),
+	}
+	if cfg.SMTPAddr != "" {
+		mailer, err := notifier.NewSMTP(cfg.SMTPAddr, cfg.SMTPFrom, cfg.SMTPUser, cfg.SMTPPassword, templates)
+		failOnError(err, "cannot set up email")
+		notifiers[models.ChannelEmail] = mailer
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
-	wg := &sync.WaitGroup{}
+	done := make(chan struct{})
 
 	go func() {
-		err := mqConsumer.Handle(ctx, wg, func(msgs <-chan amqp.Delivery) {
-			for {
-				select {
-				case msg, ok := <-msgs:
-					// если закроется канал, завершить обработчик
-					if !ok {
-						return
-					}
-					var e models.Event
-					err := json.Unmarshal(msg.Body, &e)
-					if err != nil {
-						logger.Warn(fmt.Sprintf("got invalid message %s", msg.Body))
-						msg.Reject(false)
-					} else {
-						logger.Info(fmt.Sprintf("Notification to %s\n%s at %v", e.User, e.Title, e.StartAt))
-						msg.Ack(false)
-					}
-				case <-ctx.Done():
-					// если завершается программа, завершить обработчик
-					return
-				}
-			}
-		}, 3)
+		err := sender.NewSender(mqConsumer, storage, notifier.NewRouter(notifiers), cfg.SenderMaxAttempts, logger).Run(ctx, 3)
 		failOnError(err, "handling error")
+		close(done)
 	}()
 
-	termChan := make(chan os.Signal)
+	termChan := make(chan os.Signal, 1)
 	signal.Notify(termChan, syscall.SIGINT, syscall.SIGTERM)
 
 	<-termChan
 
 	cancel()
-	wg.Wait()
+	<-done
 
 	fmt.Println("Sender stopped")
 }
 
+// deadLetters покажет или вернет в очередь недоставленные напоминания
+func deadLetters(dlq queue.DeadLetterQueue) {
+	ctx := context.Background()
+	if dlqList > 0 {
+		letters, err := dlq.DeadLetters(ctx, dlqList)
+		failOnError(err, "cannot read dead letters")
+		for _, l := range letters {
+			fmt.Printf("attempts: %d, reason: %s\n%s\n\n", l.Attempts, l.Reason, l.Body)
+		}
+		fmt.Printf("%d dead letters shown\n", len(letters))
+	}
+	if dlqReplay > 0 {
+		replayed, err := dlq.Replay(ctx, dlqReplay)
+		failOnError(err, "cannot replay dead letters")
+		fmt.Printf("%d dead letters replayed\n", replayed)
+	}
+}
+
 func failOnError(err error, msg string) {
 	if err != nil {
 		log.Fatalf("%s: %s", msg, err)
@@ -102,8 +138,10 @@ func getConfig() *internal.Config {
 	}
 
 	cfg := &internal.Config{
-		HTTPListen: "127.0.0.1:50051",
-		LogLevel:   "debug",
+		HTTPListen:        "127.0.0.1:50051",
+		LogLevel:          "debug",
+		SenderMaxAttempts: 5,
+		NotificationFile:  "notifications.jsonl",
 	}
 
 	loader := confita.NewLoader(
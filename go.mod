@@ -1,6 +1,6 @@
 module github.com/bobrovka/calendar
 
-go 1.12
+go 1.19
 
 require (
 	github.com/cenkalti/backoff v2.1.1+incompatible
@@ -9,6 +9,7 @@ require (
 	github.com/golang/protobuf v1.3.5
 	github.com/google/uuid v1.1.1
 	github.com/heetch/confita v0.8.0
+	github.com/jackc/pgconn v1.4.0
 	github.com/jackc/pgx/v4 v4.5.0
 	github.com/jmoiron/sqlx v1.2.0
 	github.com/spf13/pflag v1.0.5
@@ -16,8 +17,28 @@ require (
 	github.com/stretchr/testify v1.5.1
 	go.uber.org/zap v1.12.0
 	golang.org/x/net v0.0.0-20200301022130-244492dfa37a
+	google.golang.org/genproto v0.0.0-20200313141609-30c55424f95d
+	google.golang.org/grpc v1.28.0
+)
+
+require (
+	github.com/BurntSushi/toml v0.3.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/jackc/chunkreader/v2 v2.0.1 // indirect
+	github.com/jackc/pgio v1.0.0 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgproto3/v2 v2.0.1 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20200307190119-3430c5407db8 // indirect
+	github.com/jackc/pgtype v1.2.0 // indirect
+	github.com/pkg/errors v0.8.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.2.0 // indirect
+	go.uber.org/atomic v1.5.0 // indirect
+	go.uber.org/multierr v1.3.0 // indirect
+	golang.org/x/crypto v0.0.0-20190911031432-227b76d455e7 // indirect
 	golang.org/x/sys v0.0.0-20200302150141-5c8b2ff67527 // indirect
+	golang.org/x/text v0.3.2 // indirect
 	golang.org/x/tools v0.0.0-20191101200257-8dbcdeb83d3f // indirect
-	google.golang.org/genproto v0.0.0-20200313141609-30c55424f95d // indirect
-	google.golang.org/grpc v1.28.0
+	golang.org/x/xerrors v0.0.0-20190717185122-a985d3407aa7 // indirect
+	gopkg.in/yaml.v2 v2.2.2 // indirect
 )
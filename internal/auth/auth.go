@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrNoToken в запросе нет bearer-токена
+	ErrNoToken = errors.New("no bearer token")
+
+	// ErrInvalidToken токен не прошел проверку или истек
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Authenticator проверит токен и вернет пользователя, которому он выдан
+type Authenticator interface {
+	Authenticate(token string) (string, error)
+}
+
+// Chain принимает токен, который принял хотя бы один из Authenticator
+type Chain []Authenticator
+
+// Authenticate ...
+func (c Chain) Authenticate(token string) (string, error) {
+	for _, a := range c {
+		if user, err := a.Authenticate(token); err == nil {
+			return user, nil
+		}
+	}
+	return "", ErrInvalidToken
+}
+
+// bearerToken достанет токен из заголовка Authorization: Bearer <token>
+func bearerToken(header string) (string, bool) {
+	const scheme = "bearer "
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(scheme):]), true
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestJWT_Authenticate(t *testing.T) {
+	now := time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC)
+	issuer := NewJWT([]byte("secret"))
+
+	type testCase struct {
+		token   func() string
+		expUser string
+		expErr  error
+	}
+
+	issue := func(j *JWT, user string, expiresAt time.Time) func() string {
+		return func() string {
+			token, err := j.Issue(user, expiresAt)
+			assert.NoError(t, err)
+			return token
+		}
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["valid"] = testCase{
+		token:   issue(issuer, "Kira", now.Add(time.Hour)),
+		expUser: "Kira",
+	}
+	testCases["no expiry"] = testCase{
+		token:   issue(issuer, "Kira", time.Time{}),
+		expUser: "Kira",
+	}
+	testCases["expired"] = testCase{
+		token:  issue(issuer, "Kira", now.Add(-time.Hour)),
+		expErr: ErrInvalidToken,
+	}
+	testCases["other key"] = testCase{
+		token:  issue(NewJWT([]byte("guess")), "Kira", now.Add(time.Hour)),
+		expErr: ErrInvalidToken,
+	}
+	testCases["no subject"] = testCase{
+		token:  issue(issuer, "", now.Add(time.Hour)),
+		expErr: ErrInvalidToken,
+	}
+	testCases["alg none"] = testCase{
+		// {"alg":"none"}.{"sub":"Kira"}.
+		token:  func() string { return "eyJhbGciOiJub25lIn0.eyJzdWIiOiJLaXJhIn0." },
+		expErr: ErrInvalidToken,
+	}
+	testCases["not a jwt"] = testCase{
+		token:  func() string { return "Kira" },
+		expErr: ErrInvalidToken,
+	}
+
+	verifier := NewJWT([]byte("secret"))
+	verifier.now = func() time.Time { return now }
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			user, err := verifier.Authenticate(tc.token())
+			assert.Equal(t, tc.expErr, err)
+			assert.Equal(t, tc.expUser, user)
+		})
+	}
+}
+
+func TestLoadTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("# ci and people\nKira kira-token\n\nLena  lena-token\n"), 0600))
+
+	tokens, err := LoadTokenFile(path)
+	assert.NoError(t, err)
+
+	user, err := tokens.Authenticate("lena-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "Lena", user)
+
+	_, err = tokens.Authenticate("Lena")
+	assert.Equal(t, ErrInvalidToken, err)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("Kira\n"), 0600))
+	_, err = LoadTokenFile(path)
+	assert.Error(t, err)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	chain := Chain{&TokenFile{}, NewJWT([]byte("secret"))}
+	token, err := NewJWT([]byte("secret")).Issue("Kira", time.Time{})
+	assert.NoError(t, err)
+
+	interceptor := UnaryServerInterceptor(chain)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		caller, _ := app.CallerFromContext(ctx)
+		return caller, nil
+	}
+	call := func(md metadata.MD) (interface{}, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		return interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/Events/ListEvents"}, handler)
+	}
+
+	caller, err := call(metadata.Pairs("authorization", "Bearer "+token))
+	assert.NoError(t, err)
+	assert.Equal(t, "Kira", caller)
+
+	_, err = call(metadata.MD{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = call(metadata.Pairs("authorization", "Bearer "+token+"x"))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestMiddleware(t *testing.T) {
+	token, err := NewJWT([]byte("secret")).Issue("Kira", time.Time{})
+	assert.NoError(t, err)
+
+	handler := Middleware(NewJWT([]byte("secret")), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := app.CallerFromContext(r.Context())
+		_, _ = w.Write([]byte(caller))
+	}))
+
+	type testCase struct {
+		setAuth func(r *http.Request)
+		expCode int
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["bearer"] = testCase{
+		setAuth: func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) },
+		expCode: http.StatusOK,
+	}
+	testCases["basic with token as password"] = testCase{
+		setAuth: func(r *http.Request) { r.SetBasicAuth("Kira", token) },
+		expCode: http.StatusOK,
+	}
+	testCases["basic with someone else's name"] = testCase{
+		setAuth: func(r *http.Request) { r.SetBasicAuth("Lena", token) },
+		expCode: http.StatusUnauthorized,
+	}
+	testCases["no credentials"] = testCase{
+		setAuth: func(r *http.Request) {},
+		expCode: http.StatusUnauthorized,
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/calendars/Kira.ics", nil)
+			tc.setAuth(request)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.expCode, recorder.Code)
+			if tc.expCode == http.StatusOK {
+				assert.Equal(t, "Kira", recorder.Body.String())
+			} else {
+				assert.NotEmpty(t, recorder.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	jwt := NewJWT([]byte("secret"))
+	token, err := jwt.Issue("Kira", time.Time{})
+	assert.NoError(t, err)
+
+	interceptor := StreamServerInterceptor(jwt)
+	var caller string
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		caller, _ = app.CallerFromContext(ss.Context())
+		return nil
+	}
+	call := func(md metadata.MD) error {
+		ss := &testStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+		return interceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/Events/WatchEvents"}, handler)
+	}
+
+	assert.NoError(t, call(metadata.Pairs("authorization", "Bearer "+token)))
+	assert.Equal(t, "Kira", caller)
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(call(metadata.MD{})))
+}
+
+type testStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor пропустит только вызовы с действительным токеном в метаданных
+// authorization: Bearer <token> и положит пользователя в контекст вызова
+func UnaryServerInterceptor(a Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		user, err := authenticate(ctx, a)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(app.WithCaller(ctx, user), req)
+	}
+}
+
+// StreamServerInterceptor то же, что UnaryServerInterceptor, для потоковых вызовов
+func StreamServerInterceptor(a Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		user, err := authenticate(ss.Context(), a)
+		if err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(srv, &callerStream{ServerStream: ss, ctx: app.WithCaller(ss.Context(), user)})
+	}
+}
+
+// callerStream поток вызова с пользователем в контексте
+type callerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context вернет контекст вызова с пользователем
+func (s *callerStream) Context() context.Context {
+	return s.ctx
+}
+
+func authenticate(ctx context.Context, a Authenticator) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", ErrNoToken
+	}
+
+	token, ok := bearerToken(values[0])
+	if !ok {
+		return "", ErrNoToken
+	}
+	return a.Authenticate(token)
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"net/http"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+)
+
+// Middleware пропустит к next только запросы с действительным токеном. Кроме Bearer принимается
+// Basic с токеном вместо пароля: календарные клиенты по подписке и CalDAV умеют только его
+func Middleware(a Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := authenticateHTTP(r, a)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="calendar", charset="UTF-8"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(app.WithCaller(r.Context(), user)))
+	})
+}
+
+func authenticateHTTP(r *http.Request, a Authenticator) (string, error) {
+	if name, password, ok := r.BasicAuth(); ok {
+		user, err := a.Authenticate(password)
+		if err != nil {
+			return "", err
+		}
+		// имя, если клиент его передал, должно совпадать с владельцем токена
+		if name != "" && name != user {
+			return "", ErrInvalidToken
+		}
+		return user, nil
+	}
+
+	token, ok := bearerToken(r.Header.Get("Authorization"))
+	if !ok {
+		return "", ErrNoToken
+	}
+	return a.Authenticate(token)
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// leeway допуск на расхождение часов при проверке exp и nbf
+const leeway = time.Minute
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+}
+
+// JWT проверяет JWT, подписанные HS256 общим ключом. Пользователь берется из claim sub
+type JWT struct {
+	key []byte
+	now func() time.Time
+}
+
+// NewJWT создает проверку токенов, подписанных key
+func NewJWT(key []byte) *JWT {
+	return &JWT{
+		key: key,
+		now: time.Now,
+	}
+}
+
+// Authenticate ...
+func (j *JWT) Authenticate(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", ErrInvalidToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return "", ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, j.sign(parts[0]+"."+parts[1])) {
+		return "", ErrInvalidToken
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil || claims.Subject == "" {
+		return "", ErrInvalidToken
+	}
+	now := j.now()
+	if claims.ExpiresAt != 0 && now.After(time.Unix(claims.ExpiresAt, 0).Add(leeway)) {
+		return "", ErrInvalidToken
+	}
+	if claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return "", ErrInvalidToken
+	}
+
+	return claims.Subject, nil
+}
+
+// Issue выпустит токен пользователю user, действующий до expiresAt; нулевое время - бессрочный
+func (j *JWT) Issue(user string, expiresAt time.Time) (string, error) {
+	claims := jwtClaims{Subject: user}
+	if !expiresAt.IsZero() {
+		claims.ExpiresAt = expiresAt.Unix()
+	}
+
+	header, err := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(j.sign(unsigned)), nil
+}
+
+func (j *JWT) sign(unsigned string) []byte {
+	mac := hmac.New(sha256.New, j.key)
+	_, _ = mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
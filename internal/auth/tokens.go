@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TokenFile статические токены из файла. Каждая строка - "<user> <token>",
+// пустые строки и строки с # пропускаются
+type TokenFile struct {
+	// токены хранятся хешами, чтобы время поиска не зависело от совпадения префикса
+	users map[[sha256.Size]byte]string
+}
+
+// LoadTokenFile прочитает токены из файла path
+func LoadTokenFile(path string) (*TokenFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tokens := &TokenFile{users: make(map[[sha256.Size]byte]string)}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: want \"<user> <token>\"", path, line)
+		}
+		tokens.users[sha256.Sum256([]byte(fields[1]))] = fields[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Authenticate ...
+func (f *TokenFile) Authenticate(token string) (string, error) {
+	user, ok := f.users[sha256.Sum256([]byte(token))]
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	return user, nil
+}
@@ -0,0 +1,501 @@
+package caldav
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/ical"
+	"github.com/bobrovka/calendar/internal/models"
+	"go.uber.org/zap"
+)
+
+// Prefix путь, под которым обработчик отдает календари: /dav/<user>/ - календарь пользователя,
+// /dav/<user>/<name>.ics - одно событие
+const Prefix = "/dav/"
+
+// maxBodySize наибольший размер тела запроса
+const maxBodySize = 1 << 20
+
+// resourceStamp DTSTAMP событий. Время изменения не хранится, а постоянный DTSTAMP
+// меняет тело ресурса и его ETag только вместе с самим событием
+var resourceStamp = time.Unix(0, 0)
+
+// Handler CalDAV-сервер (RFC 4791) поверх приложения календаря: PROPFIND, REPORT, GET, PUT и DELETE
+// событий. Запись идет через app.App, поэтому проверка свободного времени и напоминания работают
+// так же, как для gRPC. Перенесенные экземпляры серий отдаются в ресурсе серии, а при записи
+// игнорируются: их меняют через API
+type Handler struct {
+	app    app.App
+	logger *zap.SugaredLogger
+}
+
+// NewHandler создает CalDAV-обработчик
+func NewHandler(app app.App, logger *zap.SugaredLogger) *Handler {
+	return &Handler{
+		app:    app,
+		logger: logger,
+	}
+}
+
+// resource событие календаря: серия или разовое событие вместе с перенесенными экземплярами
+type resource struct {
+	name   string
+	events []*models.Event
+	body   []byte
+	etag   string
+}
+
+// master основное событие ресурса
+func (r *resource) master() *models.Event {
+	return r.events[0]
+}
+
+// encode соберет тело ресурса в iCalendar и его ETag
+func (r *resource) encode(loc *time.Location) error {
+	var body bytes.Buffer
+	err := ical.Encode(&body, &ical.Calendar{
+		Location: loc,
+		Stamp:    resourceStamp,
+		Events:   r.events,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.body = body.Bytes()
+	r.etag = `"` + hash(r.body) + `"`
+	return nil
+}
+
+// ServeHTTP ...
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, name, ok := splitPath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	switch r.Method {
+	case http.MethodOptions:
+		w.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+		w.WriteHeader(http.StatusOK)
+	case "PROPFIND":
+		h.propfind(w, r, user, name)
+	case "REPORT":
+		h.report(w, r, user)
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r, user, name)
+	case http.MethodPut:
+		h.put(w, r, user, name)
+	case http.MethodDelete:
+		h.delete(w, r, user, name)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// splitPath разберет путь на пользователя и имя ресурса без .ics; имя пусто для календаря
+func splitPath(p string) (string, string, bool) {
+	parts := strings.Split(strings.TrimPrefix(path.Clean(p), path.Clean(Prefix)+"/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "" && parts[0] != ".":
+		return parts[0], "", true
+	case len(parts) == 2 && parts[0] != "" && strings.HasSuffix(parts[1], ".ics") && len(parts[1]) > len(".ics"):
+		return parts[0], strings.TrimSuffix(parts[1], ".ics"), true
+	}
+	return "", "", false
+}
+
+func collectionHref(user string) string {
+	return Prefix + user + "/"
+}
+
+func resourceHref(user, name string) string {
+	return Prefix + user + "/" + name + ".ics"
+}
+
+func (h *Handler) propfind(w http.ResponseWriter, r *http.Request, user, name string) {
+	props, err := readPropfind(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var responses []response
+	if name != "" {
+		res, err := h.resource(r, user, name)
+		if err != nil {
+			h.fail(w, "propfind", err)
+			return
+		}
+		if res == nil {
+			http.NotFound(w, r)
+			return
+		}
+		responses = append(responses, resourceResponse(user, res, props))
+	} else {
+		resources, err := h.resources(r, user)
+		if err != nil {
+			h.fail(w, "propfind", err)
+			return
+		}
+		responses = append(responses, collectionResponse(user, resources, props))
+		if r.Header.Get("Depth") != "0" {
+			for _, res := range resources {
+				responses = append(responses, resourceResponse(user, res, props))
+			}
+		}
+	}
+
+	writeMultistatus(w, responses)
+}
+
+func (h *Handler) report(w http.ResponseWriter, r *http.Request, user string) {
+	report, err := readReport(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resources, err := h.resources(r, user)
+	if err != nil {
+		h.fail(w, "report", err)
+		return
+	}
+
+	var responses []response
+	switch report.XMLName {
+	case calendarQueryName:
+		from, to, err := report.timeRange()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, res := range resources {
+			if inRange(res, from, to) {
+				responses = append(responses, resourceResponse(user, res, report.Prop))
+			}
+		}
+	case calendarMultigetName:
+		for _, href := range report.Hrefs {
+			_, name, ok := splitPath(href)
+			res := find(resources, name)
+			if !ok || name == "" || res == nil {
+				responses = append(responses, response{Href: href, Status: statusLine(http.StatusNotFound)})
+				continue
+			}
+			responses = append(responses, resourceResponse(user, res, report.Prop))
+		}
+	default:
+		http.Error(w, "unsupported report", http.StatusForbidden)
+		return
+	}
+
+	writeMultistatus(w, responses)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, user, name string) {
+	if name == "" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	res, err := h.resource(r, user, name)
+	if err != nil {
+		h.fail(w, "get", err)
+		return
+	}
+	if res == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	w.Header().Set("ETag", res.etag)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(res.body)
+	}
+}
+
+func (h *Handler) put(w http.ResponseWriter, r *http.Request, user, name string) {
+	if name == "" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	profile, err := h.app.GetProfile(r.Context(), user)
+	if err != nil {
+		h.fail(w, "put", err)
+		return
+	}
+
+	imported, err := ical.Decode(io.LimitReader(r.Body, maxBodySize), profile.Location)
+	if err != nil {
+		writePrecondition(w, "valid-calendar-data", err)
+		return
+	}
+
+	var event *models.Event
+	for _, item := range imported {
+		switch {
+		case errors.Is(item.Err, ical.ErrRecurrenceOverride):
+			// перенесенные экземпляры меняются через API
+		case item.Err != nil:
+			writePrecondition(w, "valid-calendar-data", item.Err)
+			return
+		case event != nil:
+			writePrecondition(w, "valid-calendar-object-resource", errors.New("one resource must contain one event"))
+			return
+		default:
+			event = item.Event
+		}
+	}
+	if event == nil {
+		writePrecondition(w, "valid-calendar-object-resource", errors.New("no VEVENT in resource"))
+		return
+	}
+
+	existing, err := h.resource(r, user, name)
+	if err == nil && existing == nil && event.ExternalUID != name {
+		existing, err = h.resource(r, user, event.ExternalUID)
+	}
+	if err != nil {
+		h.fail(w, "put", err)
+		return
+	}
+	if !checkPreconditions(w, r, existing) {
+		return
+	}
+	// UID импортированного ресурса не меняется, иначе рядом с ним появилось бы второе событие
+	if existing != nil && existing.master().ExternalUID != "" && existing.master().ExternalUID != event.ExternalUID {
+		writePrecondition(w, "no-uid-conflict", fmt.Errorf("resource %s has UID %s", name, existing.master().ExternalUID))
+		return
+	}
+
+	// событие, созданное не через импорт, меняется по своему UUID
+	if existing != nil && existing.master().ExternalUID == "" {
+		event.User = user
+		event.ExternalUID = ""
+		err = h.app.ChangeEvent(r.Context(), existing.master().UUID, event, app.ScopeSeries, time.Time{})
+		if errors.Is(err, app.ErrTimeBusy) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		var invalid *app.ValidationError
+		if errors.As(err, &invalid) {
+			writePrecondition(w, "valid-calendar-object-resource", err)
+			return
+		}
+		if err != nil {
+			h.fail(w, "put", err)
+			return
+		}
+		h.logger.Infow("Success CalDAV put", "user", user, "UUID", existing.master().UUID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	results, err := h.app.ImportEvents(r.Context(), user, []*models.Event{event})
+	if err != nil {
+		h.fail(w, "put", err)
+		return
+	}
+
+	result := results[0]
+	switch result.Status {
+	case app.ImportCreated:
+		h.logger.Infow("Success CalDAV put", "user", user, "UUID", result.UUID)
+		w.Header().Set("Location", resourceHref(user, event.ExternalUID))
+		w.WriteHeader(http.StatusCreated)
+	case app.ImportUpdated:
+		h.logger.Infow("Success CalDAV put", "user", user, "UUID", result.UUID)
+		w.WriteHeader(http.StatusNoContent)
+	case app.ImportConflict:
+		http.Error(w, result.Err.Error(), http.StatusConflict)
+	default:
+		writePrecondition(w, "valid-calendar-data", result.Err)
+	}
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request, user, name string) {
+	if name == "" {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	res, err := h.resource(r, user, name)
+	if err != nil {
+		h.fail(w, "delete", err)
+		return
+	}
+	if res == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if !checkPreconditions(w, r, res) {
+		return
+	}
+
+	err = h.app.RemoveEvent(r.Context(), res.master().UUID, app.ScopeSeries, time.Time{})
+	if err != nil {
+		h.fail(w, "delete", err)
+		return
+	}
+
+	h.logger.Infow("Success CalDAV delete", "user", user, "UUID", res.master().UUID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resource вернет ресурс пользователя по имени или nil, если его нет. Ресурс ищется по UID
+// или UUID, поэтому запросы к одному ресурсу не читают весь календарь, как запросы к коллекции
+func (h *Handler) resource(r *http.Request, user, name string) (*resource, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	profile, err := h.app.GetProfile(r.Context(), user)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := h.app.FindEvent(r.Context(), user, name)
+	if errors.Is(err, app.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &resource{name: name, events: events}
+	if err := res.encode(profile.Location); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// resources вернет события пользователя, сгруппированные в ресурсы по имени
+func (h *Handler) resources(r *http.Request, user string) ([]*resource, error) {
+	profile, err := h.app.GetProfile(r.Context(), user)
+	if err != nil {
+		return nil, err
+	}
+
+	// ресурсы не ограничены окном выгрузки, иначе PUT старого события создал бы его заново
+	events, err := h.app.ListAllEvents(r.Context(), user)
+	if err != nil {
+		return nil, err
+	}
+
+	byUUID := make(map[string]*resource)
+	var resources []*resource
+	for _, event := range events {
+		if event.SeriesUUID != "" {
+			continue
+		}
+		name := event.UUID
+		if event.ExternalUID != "" {
+			name = event.ExternalUID
+		}
+		res := &resource{name: name, events: []*models.Event{event}}
+		byUUID[event.UUID] = res
+		resources = append(resources, res)
+	}
+	for _, event := range events {
+		if res, ok := byUUID[event.SeriesUUID]; ok {
+			res.events = append(res.events, event)
+		}
+	}
+
+	for _, res := range resources {
+		if err := res.encode(profile.Location); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].name < resources[j].name
+	})
+	return resources, nil
+}
+
+func (h *Handler) fail(w http.ResponseWriter, method string, err error) {
+	h.logger.Errorw("error CalDAV "+method, "methodName", method, "err", err)
+	if errors.Is(err, app.ErrForbidden) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func find(resources []*resource, name string) *resource {
+	for _, res := range resources {
+		if res.name == name {
+			return res
+		}
+	}
+	return nil
+}
+
+// inRange проверит, что у ресурса есть экземпляр, пересекающийся с [from, to). Нулевые границы не ограничивают
+func inRange(res *resource, from, to time.Time) bool {
+	if from.IsZero() && to.IsZero() {
+		return true
+	}
+	if to.IsZero() {
+		to = from.Add(100 * 365 * 24 * time.Hour)
+	}
+
+	for _, event := range res.events {
+		occurrences, err := event.Occurrences(from, to)
+		if err == nil && len(occurrences) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPreconditions проверит If-Match и If-None-Match. Если условие не выполнено, ответит 412 и вернет false
+func checkPreconditions(w http.ResponseWriter, r *http.Request, existing *resource) bool {
+	ifMatch := r.Header.Get("If-Match")
+	ifNoneMatch := r.Header.Get("If-None-Match")
+
+	failed := false
+	switch {
+	case ifNoneMatch == "*" && existing != nil:
+		failed = true
+	case ifMatch == "*" && existing == nil:
+		failed = true
+	case ifMatch != "" && ifMatch != "*" && (existing == nil || ifMatch != existing.etag):
+		failed = true
+	}
+
+	if failed {
+		http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+	}
+	return !failed
+}
+
+func hash(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func statusLine(code int) string {
+	return fmt.Sprintf("HTTP/1.1 %d %s", code, http.StatusText(code))
+}
+
+func readBody(body io.Reader) ([]byte, error) {
+	return ioutil.ReadAll(io.LimitReader(body, maxBodySize))
+}
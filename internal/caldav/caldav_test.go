@@ -0,0 +1,260 @@
+package caldav
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// client выполняет запросы к тестовому серверу
+type client struct {
+	t      *testing.T
+	server *httptest.Server
+}
+
+func (c *client) do(method, path, body string, headers map[string]string) (*http.Response, string) {
+	request, err := http.NewRequest(method, c.server.URL+path, strings.NewReader(body))
+	assert.NoError(c.t, err)
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(c.t, err)
+	defer response.Body.Close()
+
+	data, err := ioutil.ReadAll(response.Body)
+	assert.NoError(c.t, err)
+	return response, string(data)
+}
+
+func vevent(uid, start, end, summary string) string {
+	return strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:" + uid,
+		"DTSTART:" + start,
+		"DTEND:" + end,
+		"SUMMARY:" + summary,
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+}
+
+func newClient(t *testing.T) (*client, app.App) {
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(NewHandler(calendar, zap.NewNop().Sugar()))
+	t.Cleanup(server.Close)
+	return &client{t: t, server: server}, calendar
+}
+
+func TestHandler_PutGetDelete(t *testing.T) {
+	c, _ := newClient(t)
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	at := func(hour int) string {
+		return tomorrow.Add(time.Duration(hour) * time.Hour).Format(timeRangeLayout)
+	}
+
+	response, _ := c.do(http.MethodPut, "/dav/Kira/review@phone.ics", vevent("review@phone", at(10), at(11), "review"),
+		map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	// тот же ресурс второй раз не создать
+	response, _ = c.do(http.MethodPut, "/dav/Kira/review@phone.ics", vevent("review@phone", at(10), at(11), "review"),
+		map[string]string{"If-None-Match": "*"})
+	assert.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+
+	response, _ = c.do(http.MethodPut, "/dav/Kira/clash@phone.ics", vevent("clash@phone", at(10), at(12), "clash"), nil)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+
+	response, body := c.do(http.MethodGet, "/dav/Kira/review@phone.ics", "", nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Contains(t, body, "UID:review@phone")
+	assert.Contains(t, body, "SUMMARY:review")
+	etag := response.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	response, _ = c.do(http.MethodPut, "/dav/Kira/review@phone.ics", vevent("review@phone", at(13), at(14), "moved review"),
+		map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	// ETag поменялся вместе с событием
+	response, _ = c.do(http.MethodDelete, "/dav/Kira/review@phone.ics", "", map[string]string{"If-Match": etag})
+	assert.Equal(t, http.StatusPreconditionFailed, response.StatusCode)
+
+	response, body = c.do(http.MethodGet, "/dav/Kira/review@phone.ics", "", nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Contains(t, body, "SUMMARY:moved review")
+
+	// другой UID по адресу ресурса не создает второе событие
+	response, body = c.do(http.MethodPut, "/dav/Kira/review@phone.ics", vevent("other@phone", at(15), at(16), "other"),
+		map[string]string{"If-Match": response.Header.Get("ETag")})
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+	assert.Contains(t, body, "<no-uid-conflict")
+	response, _ = c.do(http.MethodGet, "/dav/Kira/other@phone.ics", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response, _ = c.do(http.MethodDelete, "/dav/Kira/review@phone.ics", "", nil)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	response, _ = c.do(http.MethodGet, "/dav/Kira/review@phone.ics", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response, _ = c.do(http.MethodPut, "/dav/Kira/broken.ics", "not a calendar", nil)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestHandler_PropfindAndReport(t *testing.T) {
+	c, calendar := newClient(t)
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	uuid, err := calendar.CreateNewEvent(context.Background(), &models.Event{
+		Title:    "standup",
+		StartAt:  tomorrow.Add(10 * time.Hour),
+		Duration: 15 * time.Minute,
+		User:     "Kira",
+		RRule:    "FREQ=WEEKLY",
+	})
+	assert.NoError(t, err)
+	_, err = calendar.CreateNewEvent(context.Background(), &models.Event{
+		Title:    "retro",
+		StartAt:  tomorrow.AddDate(0, 0, 3).Add(15 * time.Hour),
+		Duration: time.Hour,
+		User:     "Kira",
+	})
+	assert.NoError(t, err)
+
+	response, body := c.do("PROPFIND", "/dav/Kira/", `<?xml version="1.0"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:resourcetype/><d:getetag/><c:supported-calendar-component-set/><d:quota-used-bytes/></d:prop>
+</d:propfind>`, map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusMultiStatus, response.StatusCode)
+	assert.Equal(t, 3, strings.Count(body, "<response>"))
+	assert.Contains(t, body, `<calendar xmlns="urn:ietf:params:xml:ns:caldav"/>`)
+	assert.Contains(t, body, "<href>/dav/Kira/"+uuid+".ics</href>")
+	assert.Contains(t, body, "HTTP/1.1 404 Not Found")
+
+	response, body = c.do("PROPFIND", "/dav/Kira/", "", map[string]string{"Depth": "0"})
+	assert.Equal(t, http.StatusMultiStatus, response.StatusCode)
+	assert.Equal(t, 1, strings.Count(body, "<response>"))
+
+	// во второй день попадает только серия
+	response, body = c.do("REPORT", "/dav/Kira/", `<?xml version="1.0"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/><c:calendar-data/></d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="`+tomorrow.AddDate(0, 0, 7).Format(timeRangeLayout)+`" end="`+tomorrow.AddDate(0, 0, 8).Format(timeRangeLayout)+`"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`, map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusMultiStatus, response.StatusCode)
+	assert.Equal(t, 1, strings.Count(body, "<response>"))
+	assert.Contains(t, body, "SUMMARY:standup")
+	assert.Contains(t, body, "RRULE:FREQ=WEEKLY")
+
+	response, body = c.do("REPORT", "/dav/Kira/", `<?xml version="1.0"?>
+<c:calendar-multiget xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><c:calendar-data/></d:prop>
+  <d:href>/dav/Kira/`+uuid+`.ics</d:href>
+  <d:href>/dav/Kira/missing.ics</d:href>
+</c:calendar-multiget>`, nil)
+	assert.Equal(t, http.StatusMultiStatus, response.StatusCode)
+	assert.Contains(t, body, "SUMMARY:standup")
+	assert.Contains(t, body, "HTTP/1.1 404 Not Found")
+
+	// событие, созданное через API, меняется по его UUID без дубликата
+	response, _ = c.do(http.MethodPut, "/dav/Kira/"+uuid+".ics", vevent(uuid,
+		tomorrow.Add(11*time.Hour).Format(timeRangeLayout), tomorrow.Add(12*time.Hour).Format(timeRangeLayout), "longer standup"), nil)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	events, err := calendar.ListDayEvents(context.Background(), "Kira", tomorrow)
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "longer standup", events[0].Title)
+	assert.Empty(t, events[0].RRule)
+}
+
+func TestHandler_OldResource(t *testing.T) {
+	c, calendar := newClient(t)
+	// старше окна выгрузки iCalendar
+	old := time.Now().UTC().Truncate(24*time.Hour).AddDate(-3, 0, 0)
+	at := func(hour int) string {
+		return old.Add(time.Duration(hour) * time.Hour).Format(timeRangeLayout)
+	}
+
+	response, _ := c.do(http.MethodPut, "/dav/Kira/offsite@phone.ics", vevent("offsite@phone", at(10), at(11), "offsite"), nil)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	response, body := c.do("PROPFIND", "/dav/Kira/", "", map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusMultiStatus, response.StatusCode)
+	assert.Contains(t, body, "<href>/dav/Kira/offsite@phone.ics</href>")
+
+	// изменение, а не второе событие
+	response, _ = c.do(http.MethodPut, "/dav/Kira/offsite@phone.ics", vevent("offsite@phone", at(12), at(13), "moved offsite"), nil)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	events, err := calendar.ListDayEvents(context.Background(), "Kira", old)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "moved offsite", events[0].Title)
+	}
+
+	response, _ = c.do(http.MethodDelete, "/dav/Kira/offsite@phone.ics", "", nil)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+}
+
+func TestHandler_SeriesResource(t *testing.T) {
+	c, calendar := newClient(t)
+	tomorrow := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	ctx := context.Background()
+	uuid, err := calendar.CreateNewEvent(ctx, &models.Event{
+		Title:    "standup",
+		StartAt:  tomorrow.Add(10 * time.Hour),
+		Duration: 15 * time.Minute,
+		User:     "Kira",
+		RRule:    "FREQ=DAILY",
+	})
+	assert.NoError(t, err)
+	moved := &models.Event{Title: "late standup", StartAt: tomorrow.AddDate(0, 0, 1).Add(12 * time.Hour), Duration: 15 * time.Minute, User: "Kira"}
+	assert.NoError(t, calendar.ChangeEvent(ctx, uuid, moved, app.ScopeOccurrence, tomorrow.AddDate(0, 0, 1).Add(10*time.Hour)))
+
+	// ресурс серии отдается вместе с перенесенным экземпляром и с тем же ETag, что в коллекции
+	response, body := c.do(http.MethodGet, "/dav/Kira/"+uuid+".ics", "", nil)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Contains(t, body, "SUMMARY:late standup")
+	assert.Contains(t, body, "RECURRENCE-ID")
+	etag := strings.Trim(response.Header.Get("ETag"), `"`)
+
+	response, body = c.do("PROPFIND", "/dav/Kira/", "", map[string]string{"Depth": "1"})
+	assert.Equal(t, http.StatusMultiStatus, response.StatusCode)
+	assert.Equal(t, 2, strings.Count(body, "<response>"))
+	assert.Contains(t, body, etag)
+
+	// перенесенный экземпляр отдельным ресурсом не считается
+	events, err := calendar.ListDayEvents(ctx, "Kira", tomorrow.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.NotEqual(t, uuid, events[0].UUID)
+		response, _ = c.do(http.MethodGet, "/dav/Kira/"+events[0].UUID+".ics", "", nil)
+		assert.Equal(t, http.StatusNotFound, response.StatusCode)
+	}
+	response, _ = c.do(http.MethodGet, "/dav/Lena/"+uuid+".ics", "", nil)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
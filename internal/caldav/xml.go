@@ -0,0 +1,247 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/ical"
+)
+
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+
+	timeRangeLayout = "20060102T150405Z"
+)
+
+var (
+	calendarQueryName    = xml.Name{Space: nsCalDAV, Local: "calendar-query"}
+	calendarMultigetName = xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}
+
+	resourceTypeName  = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	displayNameName   = xml.Name{Space: nsDAV, Local: "displayname"}
+	etagName          = xml.Name{Space: nsDAV, Local: "getetag"}
+	contentTypeName   = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	principalName     = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	homeSetName       = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	componentSetName  = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	calendarDataName  = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	ctagName          = xml.Name{Space: nsCS, Local: "getctag"}
+	defaultCollection = []xml.Name{resourceTypeName, displayNameName, principalName, homeSetName, componentSetName, ctagName}
+	defaultResource   = []xml.Name{resourceTypeName, etagName, contentTypeName}
+)
+
+type multistatus struct {
+	XMLName   xml.Name   `xml:"DAV: multistatus"`
+	Responses []response `xml:"response"`
+}
+
+type response struct {
+	Href      string     `xml:"href"`
+	Propstats []propstat `xml:"propstat,omitempty"`
+	Status    string     `xml:"status,omitempty"`
+}
+
+type propstat struct {
+	Prop   props  `xml:"prop"`
+	Status string `xml:"status"`
+}
+
+type props struct {
+	Values []value `xml:",any"`
+}
+
+// value свойство с готовым содержимым в XML
+type value struct {
+	XMLName xml.Name
+	Inner   string `xml:",innerxml"`
+}
+
+// propNames имена запрошенных свойств
+type propNames []xml.Name
+
+// UnmarshalXML соберет имена дочерних элементов
+func (p *propNames) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			*p = append(*p, t.Name)
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+type propfindRequest struct {
+	XMLName xml.Name  `xml:"DAV: propfind"`
+	Prop    propNames `xml:"DAV: prop"`
+}
+
+type reportRequest struct {
+	XMLName xml.Name
+	Prop    propNames `xml:"DAV: prop"`
+	Filter  *filter   `xml:"urn:ietf:params:xml:ns:caldav filter"`
+	Hrefs   []string  `xml:"DAV: href"`
+}
+
+type filter struct {
+	Comp compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type compFilter struct {
+	Name      string       `xml:"name,attr"`
+	TimeRange *timeRange   `xml:"urn:ietf:params:xml:ns:caldav time-range"`
+	Comps     []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+}
+
+type timeRange struct {
+	Start string `xml:"start,attr"`
+	End   string `xml:"end,attr"`
+}
+
+// readPropfind вернет запрошенные свойства, nil для allprop и пустого тела
+func readPropfind(body io.Reader) (propNames, error) {
+	data, err := readBody(body)
+	if err != nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil, err
+	}
+
+	var request propfindRequest
+	if err := xml.Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+	return request.Prop, nil
+}
+
+func readReport(body io.Reader) (*reportRequest, error) {
+	data, err := readBody(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var request reportRequest
+	if err := xml.Unmarshal(data, &request); err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// timeRange вернет границы time-range фильтра VEVENT, нулевые, если их нет
+func (r *reportRequest) timeRange() (time.Time, time.Time, error) {
+	if r.Filter == nil {
+		return time.Time{}, time.Time{}, nil
+	}
+
+	for _, comp := range r.Filter.Comp.Comps {
+		if comp.Name != "VEVENT" || comp.TimeRange == nil {
+			continue
+		}
+
+		var from, to time.Time
+		var err error
+		if comp.TimeRange.Start != "" {
+			if from, err = time.Parse(timeRangeLayout, comp.TimeRange.Start); err != nil {
+				return time.Time{}, time.Time{}, err
+			}
+		}
+		if comp.TimeRange.End != "" {
+			if to, err = time.Parse(timeRangeLayout, comp.TimeRange.End); err != nil {
+				return time.Time{}, time.Time{}, err
+			}
+		}
+		if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+			return time.Time{}, time.Time{}, errors.New("time-range end must be after start")
+		}
+		return from, to, nil
+	}
+	return time.Time{}, time.Time{}, nil
+}
+
+func collectionResponse(user string, resources []*resource, requested propNames) response {
+	ctag := make([]byte, 0, len(resources)*42)
+	for _, res := range resources {
+		ctag = append(ctag, res.etag...)
+	}
+
+	known := map[xml.Name]string{
+		resourceTypeName: `<collection xmlns="DAV:"/><calendar xmlns="urn:ietf:params:xml:ns:caldav"/>`,
+		displayNameName:  escape(user),
+		principalName:    `<href xmlns="DAV:">` + escape(collectionHref(user)) + `</href>`,
+		homeSetName:      `<href xmlns="DAV:">` + escape(collectionHref(user)) + `</href>`,
+		componentSetName: `<comp xmlns="urn:ietf:params:xml:ns:caldav" name="VEVENT"/>`,
+		ctagName:         escape(hash(ctag)),
+	}
+	if requested == nil {
+		requested = defaultCollection
+	}
+	return propResponse(collectionHref(user), known, requested)
+}
+
+func resourceResponse(user string, res *resource, requested propNames) response {
+	known := map[xml.Name]string{
+		resourceTypeName: "",
+		etagName:         escape(res.etag),
+		contentTypeName:  escape(ical.ContentType + "; component=vevent"),
+		calendarDataName: escape(string(res.body)),
+	}
+	if requested == nil {
+		requested = defaultResource
+	}
+	return propResponse(resourceHref(user, res.name), known, requested)
+}
+
+// propResponse разложит запрошенные свойства на известные и отсутствующие
+func propResponse(href string, known map[xml.Name]string, requested propNames) response {
+	var found, missing props
+	for _, name := range requested {
+		inner, ok := known[name]
+		if !ok {
+			missing.Values = append(missing.Values, value{XMLName: name})
+			continue
+		}
+		found.Values = append(found.Values, value{XMLName: name, Inner: inner})
+	}
+
+	result := response{Href: href}
+	if len(found.Values) > 0 {
+		result.Propstats = append(result.Propstats, propstat{Prop: found, Status: statusLine(http.StatusOK)})
+	}
+	if len(missing.Values) > 0 {
+		result.Propstats = append(result.Propstats, propstat{Prop: missing, Status: statusLine(http.StatusNotFound)})
+	}
+	return result
+}
+
+func writeMultistatus(w http.ResponseWriter, responses []response) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(multistatus{Responses: responses})
+}
+
+// writePrecondition ответит 403 с нарушенным предусловием CalDAV, например valid-calendar-data
+func writePrecondition(w http.ResponseWriter, condition string, err error) {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = io.WriteString(w, xml.Header+`<error xmlns="DAV:"><`+condition+` xmlns="urn:ietf:params:xml:ns:caldav"/>`+
+		`<responsedescription>`+escape(err.Error())+`</responsedescription></error>`)
+}
+
+func escape(text string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
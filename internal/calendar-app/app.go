@@ -2,8 +2,11 @@ package app
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"time"
 
+	"github.com/bobrovka/calendar/internal/egress"
 	"github.com/bobrovka/calendar/internal/models"
 	"go.uber.org/zap"
 )
@@ -13,110 +16,955 @@ type App interface {
 	ListDayEvents(ctx context.Context, user string, date time.Time) ([]*models.Event, error)
 	ListWeekEvents(ctx context.Context, user string, date time.Time) ([]*models.Event, error)
 	ListMonthEvents(ctx context.Context, user string, date time.Time) ([]*models.Event, error)
+	ListEventsRange(ctx context.Context, query *RangeQuery) (*Page, error)
+	WatchEvents(ctx context.Context, user string, from, to time.Time, send func(*EventUpdate) error) error
 	CreateNewEvent(ctx context.Context, newEvent *models.Event) (string, error)
-	RemoveEvent(ctx context.Context, uuid string) error
-	ChangeEvent(ctx context.Context, uuid string, newEvent *models.Event) error
+	RemoveEvent(ctx context.Context, uuid string, scope Scope, occurrence time.Time) error
+	ChangeEvent(ctx context.Context, uuid string, newEvent *models.Event, scope Scope, occurrence time.Time) error
+	GetProfile(ctx context.Context, user string) (*models.User, error)
+	UpdateProfile(ctx context.Context, profile *models.User) error
+	FreeBusy(ctx context.Context, users []string, from, to time.Time) (map[string][]models.Interval, error)
+	FindSlots(ctx context.Context, query *SlotQuery) ([]models.Interval, error)
+	ExportEvents(ctx context.Context, user string) ([]*models.Event, error)
+	ListAllEvents(ctx context.Context, user string) ([]*models.Event, error)
+	FindEvent(ctx context.Context, user, name string) ([]*models.Event, error)
+	ImportEvents(ctx context.Context, user string, events []*models.Event) ([]*ImportResult, error)
 }
 
+// ImportStatus итог импорта одного события
+type ImportStatus int
+
+const (
+	// ImportCreated событие создано
+	ImportCreated ImportStatus = iota
+	// ImportUpdated обновлено ранее импортированное событие с тем же UID
+	ImportUpdated
+	// ImportConflict время занято, событие пропущено
+	ImportConflict
+	// ImportInvalid событие не удалось прочитать
+	ImportInvalid
+)
+
+// ImportResult итог импорта события
+type ImportResult struct {
+	UUID   string
+	Status ImportStatus
+	Err    error
+}
+
+// SlotQuery параметры поиска общего свободного времени
+type SlotQuery struct {
+	Users    []string
+	Duration time.Duration
+	From     time.Time
+	To       time.Time
+	// WorkDayStart и WorkDayEnd рабочие часы как смещение от местной полуночи каждого участника.
+	// Нулевой WorkDayEnd снимает ограничение
+	WorkDayStart time.Duration
+	WorkDayEnd   time.Duration
+	// Limit сколько промежутков вернуть, по умолчанию defaultSlotLimit
+	Limit int
+}
+
+// Scope определяет, какую часть повторяющегося события затрагивает изменение
+type Scope int
+
+const (
+	// ScopeSeries вся серия целиком
+	ScopeSeries Scope = iota
+	// ScopeOccurrence только один экземпляр серии
+	ScopeOccurrence
+	// ScopeFollowing экземпляр и все последующие
+	ScopeFollowing
+)
+
+const (
+	// slotStep шаг, с которым перебираются начала свободных промежутков
+	slotStep = 15 * time.Minute
+	// defaultSlotLimit сколько свободных промежутков вернуть, если не задано
+	defaultSlotLimit = 5
+)
+
+// maxConflicts сколько пересечений вернуть в BusyError: серия против серии может дать их сотни
+const maxConflicts = 10
+
+// conflictHorizon на сколько вперед проверяются пересечения бесконечных серий
+const conflictHorizon = 2 * 365 * 24 * time.Hour
+
+// exportPast за сколько времени назад выгружаются разовые события
+const exportPast = 365 * 24 * time.Hour
+
+// allEventsFrom и allEventsTo окно, в которое попадают все события пользователя.
+// С запасом от границ time.Time, так как хранилище расширяет окно для событий на целый день
+var (
+	allEventsFrom = time.Date(1, time.February, 1, 0, 0, 0, 0, time.UTC)
+	allEventsTo   = time.Date(9999, time.December, 1, 0, 0, 0, 0, time.UTC)
+)
+
 // Calendar сущность, описывающая бизнес-логику сервиса
 type Calendar struct {
 	storage EventStorage
+	changes ChangeBus
 	logger  *zap.SugaredLogger
+	egress  *egress.Policy
+}
+
+// Option необязательная настройка приложения
+type Option func(*Calendar)
+
+// WithChangeBus заменит шину изменений внутри процесса, например на общую для нескольких экземпляров сервиса
+func WithChangeBus(bus ChangeBus) Option {
+	return func(a *Calendar) {
+		a.changes = bus
+	}
+}
+
+// WithEgressPolicy разрешит webhook на внутренние адреса из политики. По умолчанию они запрещены
+func WithEgressPolicy(policy *egress.Policy) Option {
+	return func(a *Calendar) {
+		a.egress = policy
+	}
 }
 
 // NewCalendar создает новый инстанс приложения
-func NewCalendar(storage EventStorage, logger *zap.SugaredLogger) (App, error) {
-	return &Calendar{
+func NewCalendar(storage EventStorage, logger *zap.SugaredLogger, options ...Option) (App, error) {
+	policy, err := egress.NewPolicy(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Calendar{
 		storage: storage,
+		changes: NewBus(),
 		logger:  logger,
-	}, nil
+		egress:  policy,
+	}
+	for _, option := range options {
+		option(a)
+	}
+	return a, nil
 }
 
-// ListDayEvents вернет список событий на день
+// ListDayEvents вернет список событий на локальный день пользователя, в который попадает date
 func (a *Calendar) ListDayEvents(ctx context.Context, user string, date time.Time) ([]*models.Event, error) {
-	events, err := a.storage.ListEvents(ctx, user, date, date.AddDate(0, 0, 1))
+	if err := authorize(ctx, user); err != nil {
+		return nil, err
+	}
+
+	profile, err := a.getProfile(ctx, user)
 	if err != nil {
 		return nil, err
 	}
-	return events, nil
+
+	from, to := profile.Day(date)
+	return a.listEvents(ctx, profile, from, to)
 }
 
-// ListWeekEvents вернет список событий на неделю
+// ListWeekEvents вернет список событий на локальную неделю пользователя, в которую попадает date
 func (a *Calendar) ListWeekEvents(ctx context.Context, user string, date time.Time) ([]*models.Event, error) {
-	events, err := a.storage.ListEvents(ctx, user, date, date.AddDate(0, 0, 7))
+	if err := authorize(ctx, user); err != nil {
+		return nil, err
+	}
+
+	profile, err := a.getProfile(ctx, user)
 	if err != nil {
 		return nil, err
 	}
-	return events, nil
+
+	from, to := profile.Week(date)
+	return a.listEvents(ctx, profile, from, to)
 }
 
-// ListMonthEvents вернет список событий на месяц
+// ListMonthEvents вернет список событий на локальный месяц пользователя, в который попадает date
 func (a *Calendar) ListMonthEvents(ctx context.Context, user string, date time.Time) ([]*models.Event, error) {
-	events, err := a.storage.ListEvents(ctx, user, date, date.AddDate(0, 1, 0))
+	if err := authorize(ctx, user); err != nil {
+		return nil, err
+	}
+
+	profile, err := a.getProfile(ctx, user)
 	if err != nil {
 		return nil, err
 	}
+
+	from, to := profile.Month(date)
+	return a.listEvents(ctx, profile, from, to)
+}
+
+// listEvents вернет экземпляры событий пользователя в интервале [from, to), упорядоченные по началу
+// и со временем в часовом поясе пользователя
+func (a *Calendar) listEvents(ctx context.Context, profile *models.User, from, to time.Time) ([]*models.Event, error) {
+	events, err := a.storage.ListEvents(ctx, profile.Name, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// серии разворачиваются по местному времени, чтобы переход на летнее время не сдвигал экземпляры,
+	// а события на целый день переносятся на местные сутки
+	return expandEvents(inLocation(profile.Location, events...), from, to)
+}
+
+// FreeBusy вернет для каждого пользователя занятые промежутки внутри [from, to) без подробностей событий.
+// Пересекающиеся события объединяются, прозрачные и отмененные не учитываются
+func (a *Calendar) FreeBusy(ctx context.Context, users []string, from, to time.Time) (map[string][]models.Interval, error) {
+	if err := validateSearchInterval(from, to); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]models.Interval, len(users))
+	for _, user := range users {
+		profile, err := a.getProfile(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		events, err := a.listEvents(ctx, profile, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		busy := make([]models.Interval, 0, len(events))
+		for _, event := range events {
+			if !blocksTime(event, profile) {
+				continue
+			}
+
+			interval := models.Interval{Start: event.StartAt, End: event.EndAt()}
+			if interval.Start.Before(from) {
+				interval.Start = from.In(profile.Location)
+			}
+			if interval.End.After(to) {
+				interval.End = to.In(profile.Location)
+			}
+			busy = append(busy, interval)
+		}
+		result[user] = models.MergeIntervals(busy)
+	}
+
+	return result, nil
+}
+
+// FindSlots вернет самые ранние непересекающиеся промежутки длиной query.Duration внутри [From, To),
+// свободные у всех участников и попадающие в их рабочие часы
+func (a *Calendar) FindSlots(ctx context.Context, query *SlotQuery) ([]models.Interval, error) {
+	if err := validateSlotQuery(query); err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSlotLimit
+	}
+
+	profiles := make([]*models.User, 0, len(query.Users))
+	var busy []models.Interval
+	for _, user := range query.Users {
+		profile, err := a.getProfile(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+
+		events, err := a.listEvents(ctx, profile, query.From, query.To)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			if blocksTime(event, profile) {
+				busy = append(busy, models.Interval{Start: event.StartAt, End: event.EndAt()})
+			}
+		}
+		profiles = append(profiles, profile)
+	}
+	// занятость всех участников объединяется один раз, и поиск идет по промежуткам между ней
+	busy = models.MergeIntervals(busy)
+
+	var slots []models.Interval
+	start := query.From.Truncate(slotStep)
+	if start.Before(query.From) {
+		start = start.Add(slotStep)
+	}
+	for end := start.Add(query.Duration); !end.After(query.To) && len(slots) < limit; end = start.Add(query.Duration) {
+		// закончившаяся занятость не помешает и следующим промежуткам, они начинаются позже
+		for len(busy) > 0 && !busy[0].End.After(start) {
+			busy = busy[1:]
+		}
+		if len(busy) > 0 && busy[0].Start.Before(end) {
+			// ближайшее начало с прежним шагом, когда занятость закончилась
+			steps := (busy[0].End.Sub(start) + slotStep - 1) / slotStep
+			start = start.Add(steps * slotStep)
+			continue
+		}
+		if inWorkHours(query, profiles, start, end) {
+			slots = append(slots, models.Interval{Start: start, End: end})
+			start = end
+			continue
+		}
+		start = start.Add(slotStep)
+	}
+
+	return slots, nil
+}
+
+// inWorkHours проверит, что промежуток [start, end) попадает в рабочие часы всех участников
+func inWorkHours(query *SlotQuery, profiles []*models.User, start, end time.Time) bool {
+	if query.WorkDayEnd == 0 {
+		return true
+	}
+	for _, profile := range profiles {
+		midnight, _ := profile.Day(start)
+		if start.Sub(midnight) < query.WorkDayStart || end.Sub(midnight) > query.WorkDayEnd {
+			return false
+		}
+	}
+	return true
+}
+
+// ExportEvents вернет события пользователя для выгрузки в другие календари: серии целиком, без разворачивания,
+// и разовые события за последний год и на conflictHorizon вперед. Время в часовом поясе пользователя
+func (a *Calendar) ExportEvents(ctx context.Context, user string) ([]*models.Event, error) {
+	if err := authorize(ctx, user); err != nil {
+		return nil, err
+	}
+
+	profile, err := a.getProfile(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return a.exportEvents(ctx, profile, now.Add(-exportPast), now.Add(conflictHorizon))
+}
+
+// ListAllEvents вернет все события пользователя, как ExportEvents, но без окна. Нужен там,
+// где событие ищут по адресу, например в коллекции CalDAV: прошлое событие не должно пропадать
+func (a *Calendar) ListAllEvents(ctx context.Context, user string) ([]*models.Event, error) {
+	if err := authorize(ctx, user); err != nil {
+		return nil, err
+	}
+
+	profile, err := a.getProfile(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.exportEvents(ctx, profile, allEventsFrom, allEventsTo)
+}
+
+// FindEvent вернет событие пользователя по iCalendar UID, а если оно не импортировано, по UUID,
+// и следом перенесенные экземпляры серии по времени начала. Так событие ищут по адресу,
+// не читая все события пользователя. Перенесенный экземпляр сам по себе не находится
+func (a *Calendar) FindEvent(ctx context.Context, user, name string) ([]*models.Event, error) {
+	if err := authorize(ctx, user); err != nil {
+		return nil, err
+	}
+
+	profile, err := a.getProfile(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	event, err := a.storage.GetEventByExternalUID(ctx, user, name)
+	if err == ErrNotFound {
+		event, err = a.storage.GetEvent(ctx, name)
+		if err == nil && (event.User != user || event.ExternalUID != "") {
+			err = ErrNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if event.SeriesUUID != "" {
+		return nil, ErrNotFound
+	}
+
+	events := []*models.Event{event}
+	if event.RRule != "" {
+		overrides, err := a.storage.ListOverrides(ctx, event.UUID)
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(overrides, func(i, j int) bool {
+			return overrides[i].StartAt.Before(overrides[j].StartAt)
+		})
+		events = append(events, overrides...)
+	}
+	return inLocation(profile.Location, events...), nil
+}
+
+func (a *Calendar) exportEvents(ctx context.Context, profile *models.User, from, to time.Time) ([]*models.Event, error) {
+	events, err := a.storage.ListEvents(ctx, profile.Name, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	events = inLocation(profile.Location, events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StartAt.Before(events[j].StartAt)
+	})
 	return events, nil
 }
 
+// ImportEvents сохранит события пользователя из другого календаря. Событие с уже известным ExternalUID
+// обновляется, а не создается заново. Каждое событие пишется в своей транзакции, поэтому
+// конфликт одного не мешает остальным. Ошибка возвращается, только если не работает хранилище
+func (a *Calendar) ImportEvents(ctx context.Context, user string, events []*models.Event) ([]*ImportResult, error) {
+	if err := authorize(ctx, user); err != nil {
+		return nil, err
+	}
+
+	profile, err := a.getProfile(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*ImportResult, 0, len(events))
+	for _, event := range events {
+		result, err := a.importEvent(ctx, profile, event)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (a *Calendar) importEvent(ctx context.Context, profile *models.User, event *models.Event) (*ImportResult, error) {
+	event.User = profile.Name
+	if err := validateEvent(event); err != nil {
+		return &ImportResult{Status: ImportInvalid, Err: err}, nil
+	}
+	normalizeAllDay(event)
+
+	result := &ImportResult{}
+	err := a.inTransaction(ctx, func(ctx context.Context) error {
+		existing, err := a.storage.GetEventByExternalUID(ctx, profile.Name, event.ExternalUID)
+		switch {
+		case err == ErrNotFound:
+			if err := a.checkFreeTime(ctx, profile, event, ""); err != nil {
+				return err
+			}
+			result.Status = ImportCreated
+			result.UUID, err = a.storage.CreateEvent(ctx, event)
+			if err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			result.Status = ImportUpdated
+			result.UUID = existing.UUID
+			if err := a.updateEvent(ctx, profile, existing, event); err != nil {
+				return err
+			}
+		}
+
+		for _, exDate := range event.ExDates {
+			if existing != nil && existing.IsExcluded(exDate) {
+				continue
+			}
+			if err := a.storage.CancelOccurrence(ctx, result.UUID, exDate); err != nil {
+				return err
+			}
+		}
+
+		kind := models.ChangeCreated
+		if result.Status == ImportUpdated {
+			kind = models.ChangeUpdated
+		}
+		return a.publish(ctx, profile.Name, kind, result.UUID)
+	})
+	if errors.Is(err, ErrTimeBusy) {
+		return &ImportResult{UUID: result.UUID, Status: ImportConflict, Err: err}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetProfile вернет профиль пользователя или профиль по умолчанию, если пользователь его не заводил
+func (a *Calendar) GetProfile(ctx context.Context, user string) (*models.User, error) {
+	if err := authorize(ctx, user); err != nil {
+		return nil, err
+	}
+	return a.getProfile(ctx, user)
+}
+
+// getProfile GetProfile без проверки владельца: профили других пользователей нужны,
+// чтобы считать их занятость в их часовых поясах
+func (a *Calendar) getProfile(ctx context.Context, user string) (*models.User, error) {
+	profile, err := a.storage.GetUser(ctx, user)
+	if err == ErrNotFound {
+		return models.DefaultUser(user), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// UpdateProfile сохранит профиль пользователя
+func (a *Calendar) UpdateProfile(ctx context.Context, profile *models.User) error {
+	if err := authorize(ctx, profile.Name); err != nil {
+		return err
+	}
+	if profile.Location == nil {
+		return ErrInvalidTimeZone
+	}
+	if err := validateChannels(profile, a.egress); err != nil {
+		return err
+	}
+	if err := validateLocale(profile.Locale); err != nil {
+		return err
+	}
+	return a.storage.SaveUser(ctx, profile)
+}
+
 // CreateNewEvent добавит новое событие
 func (a *Calendar) CreateNewEvent(ctx context.Context, newEvent *models.Event) (string, error) {
-	currentEvents, err := a.storage.ListEvents(ctx, newEvent.User, time.Unix(0, 0), time.Unix(67098285000, 0))
+	if err := authorize(ctx, newEvent.User); err != nil {
+		return "", err
+	}
+	if err := validateEvent(newEvent); err != nil {
+		return "", err
+	}
+	normalizeAllDay(newEvent)
+
+	profile, err := a.getProfile(ctx, newEvent.User)
 	if err != nil {
 		return "", err
 	}
 
-	if !hasFreeTime(currentEvents, newEvent.StartAt, newEvent.StartAt.Add(newEvent.Duration)) {
-		return "", ErrTimeBusy
+	var uuid string
+	// проверка и запись в одной транзакции, чтобы параллельный запрос не занял то же время
+	err = a.inTransaction(ctx, func(ctx context.Context) error {
+		if err := a.checkFreeTime(ctx, profile, newEvent, ""); err != nil {
+			return err
+		}
+
+		uuid, err = a.storage.CreateEvent(ctx, newEvent)
+		if err != nil {
+			return err
+		}
+		return a.publish(ctx, newEvent.User, models.ChangeCreated, uuid)
+	})
+	if err != nil {
+		return "", err
 	}
 
-	return a.storage.CreateEvent(ctx, newEvent)
+	return uuid, nil
 }
 
-// RemoveEvent удалит событие
-func (a *Calendar) RemoveEvent(ctx context.Context, uuid string) error {
-	return a.storage.DeleteEvent(ctx, uuid)
+// RemoveEvent удалит событие, экземпляр серии или серию начиная с экземпляра occurrence
+func (a *Calendar) RemoveEvent(ctx context.Context, uuid string, scope Scope, occurrence time.Time) error {
+	target, err := a.storage.GetEvent(ctx, uuid)
+	if err != nil {
+		return err
+	}
+	// событие другого пользователя так не найти, как и в ChangeEvent
+	if err := authorize(ctx, target.User); err != nil {
+		return ErrNotFound
+	}
+	if target.RRule == "" || scope == ScopeSeries {
+		return a.deleteEvent(ctx, target)
+	}
+
+	profile, err := a.getProfile(ctx, target.User)
+	if err != nil {
+		return err
+	}
+	// экземпляры серии считаются по местному времени пользователя, как при показе
+	series := target.InLocation(profile.Location)
+
+	if err := checkOccurrence(series, occurrence); err != nil {
+		return err
+	}
+
+	if scope == ScopeOccurrence {
+		return a.inTransaction(ctx, func(ctx context.Context) error {
+			if err := a.storage.CancelOccurrence(ctx, uuid, occurrence); err != nil {
+				return err
+			}
+			return a.publish(ctx, target.User, models.ChangeUpdated, uuid)
+		})
+	}
+	if scope != ScopeFollowing {
+		return ErrInvalidScope
+	}
+
+	// удаление с первого экземпляра удаляет всю серию
+	if occurrence.Equal(series.StartAt) {
+		return a.deleteEvent(ctx, target)
+	}
+
+	head, err := series.Truncate(occurrence)
+	if err != nil {
+		return err
+	}
+	return a.inTransaction(ctx, func(ctx context.Context) error {
+		if _, err := a.storage.SplitSeries(ctx, uuid, occurrence, head.RRule, nil); err != nil {
+			return err
+		}
+		return a.publish(ctx, target.User, models.ChangeUpdated, uuid)
+	})
 }
 
-// ChangeEvent изменит событие
-func (a *Calendar) ChangeEvent(ctx context.Context, uuid string, newEvent *models.Event) error {
-	// get events on this day
-	currentEvents, err := a.storage.ListEvents(ctx, newEvent.User, time.Unix(0, 0), time.Unix(67098285000, 0))
+func (a *Calendar) deleteEvent(ctx context.Context, target *models.Event) error {
+	return a.inTransaction(ctx, func(ctx context.Context) error {
+		if err := a.storage.DeleteEvent(ctx, target.UUID); err != nil {
+			return err
+		}
+		return a.publish(ctx, target.User, models.ChangeDeleted, target.UUID)
+	})
+}
+
+// ChangeEvent изменит событие, экземпляр серии или серию начиная с экземпляра occurrence.
+// При изменении последующих экземпляров newEvent становится новой серией, начиная с occurrence
+func (a *Calendar) ChangeEvent(ctx context.Context, uuid string, newEvent *models.Event, scope Scope, occurrence time.Time) error {
+	if err := authorize(ctx, newEvent.User); err != nil {
+		return err
+	}
+	if err := validateEvent(newEvent); err != nil {
+		return err
+	}
+	normalizeAllDay(newEvent)
+
+	profile, err := a.getProfile(ctx, newEvent.User)
 	if err != nil {
 		return err
 	}
 
-	var found bool
-	// delete an event that is being modified
-	for i, event := range currentEvents {
-		if event.UUID == uuid {
-			currentEvents = append(currentEvents[:i], currentEvents[i+1:]...)
-			found = true
-			break
+	// проверка и запись в одной транзакции, чтобы параллельный запрос не занял то же время
+	return a.inTransaction(ctx, func(ctx context.Context) error {
+		created, err := a.changeEvent(ctx, profile, uuid, newEvent, scope, occurrence)
+		if err != nil {
+			return err
+		}
+		if err := a.publish(ctx, newEvent.User, models.ChangeUpdated, uuid); err != nil {
+			return err
+		}
+		if created != "" {
+			return a.publish(ctx, newEvent.User, models.ChangeCreated, created)
 		}
+		return nil
+	})
+}
+
+// changeEvent вернет UUID события, отделенного от серии, если оно было создано
+func (a *Calendar) changeEvent(ctx context.Context, profile *models.User, uuid string, newEvent *models.Event, scope Scope, occurrence time.Time) (string, error) {
+	target, err := a.storage.GetEvent(ctx, uuid)
+	if err != nil {
+		return "", err
 	}
-	if !found {
-		return ErrNotFound
+	// событие другого пользователя так не найти
+	if target.User != newEvent.User {
+		return "", ErrNotFound
 	}
 
+	if target.RRule == "" || scope == ScopeSeries {
+		return "", a.updateEvent(ctx, profile, target, newEvent)
+	}
+
+	// экземпляры серии считаются по местному времени пользователя, как при показе
+	series := target.InLocation(profile.Location)
+	if err := checkOccurrence(series, occurrence); err != nil {
+		return "", err
+	}
+
+	switch scope {
+	case ScopeOccurrence:
+		override := *newEvent
+		override.RRule = ""
+		override.SeriesUUID = uuid
+		override.RecurrenceAt = occurrence
+
+		// переносимый экземпляр больше не занимает свое время
+		if err := a.checkFreeTime(ctx, profile, &override, uuid, series.WithExDate(occurrence)); err != nil {
+			return "", err
+		}
+
+		return a.storage.OverrideOccurrence(ctx, uuid, occurrence, &override)
+	case ScopeFollowing:
+		// изменение с первого экземпляра меняет всю серию
+		if occurrence.Equal(series.StartAt) {
+			return "", a.updateEvent(ctx, profile, target, newEvent)
+		}
+
+		head, err := series.Truncate(occurrence)
+		if err != nil {
+			return "", err
+		}
+
+		// отмененные и перенесенные экземпляры начиная с occurrence переходят к продолжению серии
+		tail := *newEvent
+		tail.ExDates = nil
+		for _, exDate := range target.ExDates {
+			if !exDate.Before(occurrence) {
+				tail.ExDates = append(tail.ExDates, exDate)
+			}
+		}
+		if err := a.checkFreeTime(ctx, profile, &tail, uuid, head); err != nil {
+			return "", err
+		}
+
+		return a.storage.SplitSeries(ctx, uuid, occurrence, head.RRule, newEvent)
+	}
+
+	return "", ErrInvalidScope
+}
+
+// inTransaction выполнит fn в транзакции хранилища. Изменения, опубликованные в Bus при попытке транзакции,
+// подписчики получат только после ее фиксации: откат их отбрасывает, повтор публикует заново
+func (a *Calendar) inTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(pendingKey{}).(*pendingChanges); ok {
+		return a.storage.InTransaction(ctx, fn)
+	}
+
+	var pending *pendingChanges
+	err := a.storage.InTransaction(ctx, func(ctx context.Context) error {
+		pending = &pendingChanges{}
+		return fn(context.WithValue(ctx, pendingKey{}, pending))
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, deliver := range pending.deliver {
+		deliver()
+	}
+	return nil
+}
+
+// publish сообщит подписчикам об изменении. Вызывается в транзакции записи,
+// чтобы изменение не потерялось и не было опубликовано без записи
+func (a *Calendar) publish(ctx context.Context, user string, kind models.ChangeKind, uuid string) error {
+	return a.changes.Publish(ctx, &models.Change{Kind: kind, User: user, UUID: uuid})
+}
+
+func (a *Calendar) updateEvent(ctx context.Context, profile *models.User, target *models.Event, newEvent *models.Event) error {
+	// отмененные и перенесенные экземпляры серии при изменении сохраняются и не проверяются
+	checked := *newEvent
+	checked.ExDates = append(append([]time.Time(nil), newEvent.ExDates...), target.ExDates...)
+
 	// if no free time - abort changing
-	if !hasFreeTime(currentEvents, newEvent.StartAt, newEvent.StartAt.Add(newEvent.Duration)) {
-		return ErrTimeBusy
+	if err := a.checkFreeTime(ctx, profile, &checked, target.UUID); err != nil {
+		return err
 	}
 
-	return a.storage.UpdateEvent(ctx, uuid, newEvent)
+	return a.storage.UpdateEvent(ctx, target.UUID, newEvent)
+}
+
+// checkFreeTime вернет BusyError, если экземпляры события пересекаются с уже существующими.
+// Сохраненное событие с UUID replaced в проверке не участвует, вместо него учитываются события extra.
+// Перенесенные экземпляры серии replaced с ней не пересекаются, кроме как при переносе еще одного экземпляра
+func (a *Calendar) checkFreeTime(ctx context.Context, profile *models.User, event *models.Event, replaced string, extra ...*models.Event) error {
+	from, to, err := busyWindow(event.InLocation(profile.Location))
+	if err != nil {
+		return err
+	}
+
+	// читаем только события, которые могут пересечься с новым, а не всю историю пользователя
+	currentEvents, err := a.storage.ListEvents(ctx, event.User, from, to)
+	if err != nil {
+		return err
+	}
+
+	existing := make([]*models.Event, 0, len(currentEvents)+len(extra))
+	for _, e := range currentEvents {
+		if replaced != "" && (e.UUID == replaced || e.SeriesUUID == replaced && event.SeriesUUID != replaced) {
+			continue
+		}
+		existing = append(existing, e)
+	}
+	existing = append(existing, extra...)
+
+	conflicts, err := findConflicts(existing, event, profile)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &BusyError{Conflicts: conflicts}
+	}
+
+	return nil
 }
 
 func hasFreeTime(existingEvents []*models.Event, start, end time.Time) bool {
 	for _, event := range existingEvents {
-		eventEndAt := event.StartAt.Add(event.Duration)
-		if (event.StartAt.Before(start) || event.StartAt.Equal(start)) && eventEndAt.After(start) {
-			return false
-		}
-		if event.StartAt.After(start) && event.StartAt.Before(end) {
+		if takesTime(event, start, end) {
 			return false
 		}
 	}
 
 	return true
 }
+
+// takesTime вернет true, если событие занимает время в промежутке [start, end)
+func takesTime(event *models.Event, start, end time.Time) bool {
+	// прозрачные и отмененные события время не занимают
+	if !event.Busy() {
+		return false
+	}
+
+	eventEndAt := event.StartAt.Add(event.Duration)
+	if (event.StartAt.Before(start) || event.StartAt.Equal(start)) && eventEndAt.After(start) {
+		return true
+	}
+	return event.StartAt.After(start) && event.StartAt.Before(end)
+}
+
+// findConflicts вернет экземпляры уже существующих событий, с которыми пересекаются экземпляры события,
+// не больше maxConflicts. Серии разворачиваются по времени пользователя,
+// события на целый день учитываются, только если так настроено в профиле
+func findConflicts(existingEvents []*models.Event, event *models.Event, profile *models.User) ([]Conflict, error) {
+	if !blocksTime(event, profile) {
+		return nil, nil
+	}
+
+	blocking := make([]*models.Event, 0, len(existingEvents))
+	for _, e := range existingEvents {
+		if blocksTime(e, profile) {
+			blocking = append(blocking, e)
+		}
+	}
+	existingEvents = inLocation(profile.Location, blocking...)
+	event = event.InLocation(profile.Location)
+
+	from, to, err := busyWindow(event)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences, err := event.Occurrences(from, to)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := expandEvents(existingEvents, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []Conflict
+	// экземпляр серии может мешать нескольким экземплярам нового события, но показывается один раз
+	seen := make(map[Conflict]bool)
+	for _, occurrence := range occurrences {
+		for _, e := range existing {
+			if !takesTime(e, occurrence.StartAt, occurrence.EndAt()) {
+				continue
+			}
+
+			conflict := Conflict{UUID: e.UUID, StartAt: e.StartAt.UTC(), EndAt: e.EndAt().UTC()}
+			if seen[conflict] {
+				continue
+			}
+			seen[conflict] = true
+			conflicts = append(conflicts, conflict)
+			if len(conflicts) == maxConflicts {
+				return conflicts, nil
+			}
+		}
+	}
+
+	return conflicts, nil
+}
+
+// blocksTime вернет true, если событие занимает время пользователя
+func blocksTime(event *models.Event, profile *models.User) bool {
+	return event.Busy() && (!event.AllDay || profile.AllDayBusy)
+}
+
+// busyWindow вернет интервал, в котором лежат проверяемые на пересечения экземпляры события.
+// Бесконечные серии проверяются на conflictHorizon вперед
+func busyWindow(event *models.Event) (time.Time, time.Time, error) {
+	if event.RRule == "" {
+		return event.StartAt, event.EndAt(), nil
+	}
+
+	rule, err := models.ParseRRule(event.RRule)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	to := rule.End(event.StartAt, event.Duration)
+	if horizon := event.StartAt.Add(conflictHorizon); to.IsZero() || to.After(horizon) {
+		to = horizon
+	}
+	return event.StartAt, to, nil
+}
+
+// expandEvents развернет повторяющиеся события в экземпляры, пересекающиеся с [from, to)
+func expandEvents(events []*models.Event, from, to time.Time) ([]*models.Event, error) {
+	result := make([]*models.Event, 0, len(events))
+	for _, event := range events {
+		occurrences, err := event.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, occurrences...)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].StartAt.Before(result[j].StartAt)
+	})
+
+	return result, nil
+}
+
+// inLocation вернет копии событий со временем в часовом поясе loc
+func inLocation(loc *time.Location, events ...*models.Event) []*models.Event {
+	result := make([]*models.Event, 0, len(events))
+	for _, event := range events {
+		result = append(result, event.InLocation(loc))
+	}
+	return result
+}
+
+// normalizeAllDay приведет событие на целый день к полуночи UTC даты начала и целым суткам
+func normalizeAllDay(event *models.Event) {
+	if !event.AllDay {
+		return
+	}
+	year, month, date := event.StartAt.Date()
+	event.StartAt = time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+	event.Duration = time.Duration(event.Days()) * 24 * time.Hour
+}
+
+// validateSlotQuery проверит параметры поиска свободного времени
+func validateSlotQuery(query *SlotQuery) error {
+	if err := validateSearchInterval(query.From, query.To); err != nil {
+		return err
+	}
+	if query.Duration <= 0 || len(query.Users) == 0 {
+		return ErrInvalidSlotQuery
+	}
+	if query.WorkDayEnd != 0 && (query.WorkDayStart < 0 || query.WorkDayEnd <= query.WorkDayStart || query.WorkDayEnd > 24*time.Hour) {
+		return ErrInvalidSlotQuery
+	}
+	return nil
+}
+
+// validateSearchInterval проверит промежуток, в котором разворачиваются серии. Длина ограничена тем же
+// горизонтом, что и проверка пересечений, особенно для FreeBusy и FindSlots, доступных без авторизации
+func validateSearchInterval(from, to time.Time) error {
+	if !from.Before(to) {
+		return ErrInvalidInterval
+	}
+	if to.Sub(from) > conflictHorizon {
+		return ErrIntervalTooLong
+	}
+	return nil
+}
+
+// checkOccurrence проверит, что occurrence - неотмененный экземпляр серии
+func checkOccurrence(series *models.Event, occurrence time.Time) error {
+	ok, err := series.HasOccurrence(occurrence)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidOccurrence
+	}
+	return nil
+}
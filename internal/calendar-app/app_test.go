@@ -2,12 +2,14 @@ package app
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/bobrovka/calendar/internal/models"
 	mock "github.com/bobrovka/calendar/internal/storage/storage-mock"
 	"github.com/stretchr/testify/assert"
+	tmock "github.com/stretchr/testify/mock"
 )
 
 func TestApp_CreateEvent(t *testing.T) {
@@ -55,6 +57,114 @@ func TestApp_CreateEvent(t *testing.T) {
 		},
 		expErr: ErrTimeBusy,
 	}
+	testCases["Event for time busy by recurring event"] = testCase{
+		newEvent: &models.Event{
+			Title:    "first",
+			StartAt:  time.Date(2020, time.March, 4, 10, 30, 0, 0, time.UTC), // wednesday 10:30
+			Duration: time.Hour,
+			User:     "Kira",
+		},
+		listEventsResponse: []*models.Event{
+			&models.Event{
+				UUID:     "2",
+				Title:    "standup",
+				StartAt:  time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC), // monday 10:00
+				Duration: time.Hour,
+				User:     "Kira",
+				RRule:    "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			},
+		},
+		expErr: ErrTimeBusy,
+	}
+	testCases["Recurring event for busy time"] = testCase{
+		newEvent: &models.Event{
+			Title:    "standup",
+			StartAt:  time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC), // monday 10:00
+			Duration: time.Hour,
+			User:     "Kira",
+			RRule:    "FREQ=DAILY",
+		},
+		listEventsResponse: []*models.Event{
+			&models.Event{
+				UUID:     "2",
+				Title:    "second",
+				StartAt:  time.Date(2020, time.April, 15, 10, 30, 0, 0, time.UTC),
+				Duration: time.Hour,
+				User:     "Kira",
+			},
+		},
+		expErr: ErrTimeBusy,
+	}
+	testCases["Recurring event for free time"] = testCase{
+		newEvent: &models.Event{
+			Title:    "standup",
+			StartAt:  time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC), // monday 10:00
+			Duration: time.Hour,
+			User:     "Kira",
+			RRule:    "FREQ=WEEKLY;BYDAY=MO,WE",
+		},
+		listEventsResponse: []*models.Event{
+			&models.Event{
+				UUID:     "2",
+				Title:    "second",
+				StartAt:  time.Date(2020, time.April, 14, 10, 30, 0, 0, time.UTC), // tuesday
+				Duration: time.Hour,
+				User:     "Kira",
+			},
+		},
+		expUUID: "100",
+	}
+	// занимают время только непрозрачные и неотмененные события
+	for name, existing := range map[string]struct {
+		transparency models.Transparency
+		status       models.Status
+		expErr       error
+	}{
+		"Event over transparent event": {transparency: models.Transparent},
+		"Event over cancelled event":   {status: models.StatusCancelled},
+		"Event over tentative event":   {status: models.StatusTentative, expErr: ErrTimeBusy},
+	} {
+		testCases[name] = testCase{
+			newEvent: &models.Event{
+				Title:    "first",
+				StartAt:  time.Date(2020, time.February, 29, 15, 30, 0, 0, time.UTC),
+				Duration: time.Hour,
+				User:     "Kira",
+			},
+			listEventsResponse: []*models.Event{
+				&models.Event{
+					UUID:         "2",
+					Title:        "optional talk",
+					StartAt:      time.Date(2020, time.February, 29, 15, 0, 0, 0, time.UTC),
+					Duration:     time.Hour,
+					User:         "Kira",
+					Transparency: existing.transparency,
+					Status:       existing.status,
+				},
+			},
+			expUUID: "100",
+			expErr:  existing.expErr,
+		}
+	}
+	testCases["Transparent event for busy time"] = testCase{
+		newEvent: &models.Event{
+			Title:        "working from home",
+			StartAt:      time.Date(2020, time.February, 29, 9, 0, 0, 0, time.UTC),
+			Duration:     8 * time.Hour,
+			User:         "Kira",
+			Transparency: models.Transparent,
+		},
+		listEventsResponse: []*models.Event{
+			&models.Event{
+				UUID:     "2",
+				Title:    "second",
+				StartAt:  time.Date(2020, time.February, 29, 10, 0, 0, 0, time.UTC),
+				Duration: time.Hour,
+				User:     "Kira",
+			},
+		},
+		expUUID: "100",
+	}
 
 	for k, v := range testCases {
 		t.Run(k, func(t *testing.T) {
@@ -62,13 +172,14 @@ func TestApp_CreateEvent(t *testing.T) {
 			app, err := NewCalendar(storage, nil)
 			assert.NoError(t, err)
 
-			storage.On("ListEvents", context.Background(), v.newEvent.User, time.Unix(0, 0), time.Unix(67098285000, 0)).Return(v.listEventsResponse, nil)
+			storage.On("GetUser", tmock.Anything, v.newEvent.User).Return(nil, ErrNotFound)
+			storage.On("ListEvents", tmock.Anything, v.newEvent.User, mockTime(v.newEvent.StartAt), tmock.Anything).Return(v.listEventsResponse, nil)
 			if v.expErr == nil {
-				storage.On("CreateEvent", context.Background(), v.newEvent).Return(v.expUUID, nil)
+				storage.On("CreateEvent", tmock.Anything, v.newEvent).Return(v.expUUID, nil)
 			}
 			uuid, err := app.CreateNewEvent(context.Background(), v.newEvent)
 			if err != nil {
-				assert.Equal(t, v.expErr, err)
+				assert.True(t, errors.Is(err, v.expErr), "unexpected error %v", err)
 			} else {
 				assert.Equal(t, v.expUUID, uuid)
 			}
@@ -183,14 +294,625 @@ func TestApp_ChangeEvent(t *testing.T) {
 			app, err := NewCalendar(storage, nil)
 			assert.NoError(t, err)
 
-			storage.On("ListEvents", context.Background(), v.newEvent.User, time.Unix(0, 0), time.Unix(67098285000, 0)).Return(v.listEventsResponse, nil)
+			storage.On("GetUser", tmock.Anything, v.newEvent.User).Return(nil, ErrNotFound)
+			if v.expErr == ErrNotFound {
+				storage.On("GetEvent", tmock.Anything, v.uuid).Return(nil, ErrNotFound)
+			} else {
+				storage.On("GetEvent", tmock.Anything, v.uuid).Return(v.listEventsResponse[0], nil)
+				storage.On("ListEvents", tmock.Anything, v.newEvent.User, v.newEvent.StartAt, v.newEvent.EndAt()).Return(v.listEventsResponse, nil)
+			}
+			if v.expErr == nil {
+				storage.On("UpdateEvent", tmock.Anything, v.uuid, v.newEvent).Return(nil)
+			}
+			err = app.ChangeEvent(context.Background(), v.uuid, v.newEvent, ScopeSeries, time.Time{})
+			assert.True(t, errors.Is(err, v.expErr), "unexpected error %v", err)
+
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestApp_CreateEventInvalidRRule(t *testing.T) {
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	_, err = app.CreateNewEvent(context.Background(), &models.Event{
+		Title:    "standup",
+		StartAt:  time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC),
+		Duration: time.Hour,
+		User:     "Kira",
+		RRule:    "FREQ=SECONDLY",
+	})
+	assert.True(t, errors.Is(err, models.ErrInvalidRRule))
+
+	storage.AssertExpectations(t)
+}
+
+func TestApp_ListWeekEvents(t *testing.T) {
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	monday := time.Date(2020, time.March, 9, 0, 0, 0, 0, time.UTC)
+	standup := &models.Event{
+		UUID:     "1",
+		Title:    "standup",
+		StartAt:  time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC),
+		Duration: 15 * time.Minute,
+		User:     "Kira",
+		RRule:    "FREQ=WEEKLY;BYDAY=MO,WE",
+	}
+	meeting := &models.Event{
+		UUID:     "2",
+		Title:    "meeting",
+		StartAt:  time.Date(2020, time.March, 10, 12, 0, 0, 0, time.UTC),
+		Duration: time.Hour,
+		User:     "Kira",
+	}
+
+	storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+	storage.On("ListEvents", tmock.Anything, "Kira", monday, monday.AddDate(0, 0, 7)).Return([]*models.Event{standup, meeting}, nil)
+
+	events, err := app.ListWeekEvents(context.Background(), "Kira", monday)
+	assert.NoError(t, err)
+
+	starts := make([]time.Time, 0, len(events))
+	for _, e := range events {
+		starts = append(starts, e.StartAt)
+	}
+	assert.Equal(t, []time.Time{
+		time.Date(2020, time.March, 9, 10, 0, 0, 0, time.UTC),
+		time.Date(2020, time.March, 10, 12, 0, 0, 0, time.UTC),
+		time.Date(2020, time.March, 11, 10, 0, 0, 0, time.UTC),
+	}, starts)
+
+	storage.AssertExpectations(t)
+}
+
+func TestApp_ChangeOccurrence(t *testing.T) {
+	standup := &models.Event{
+		UUID:     "1",
+		Title:    "standup",
+		StartAt:  time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC), // monday 10:00
+		Duration: 15 * time.Minute,
+		User:     "Kira",
+		RRule:    "FREQ=DAILY;COUNT=10",
+	}
+	occurrence := time.Date(2020, time.March, 4, 10, 0, 0, 0, time.UTC)
+	moved := &models.Event{
+		Title:    "standup",
+		StartAt:  time.Date(2020, time.March, 4, 12, 0, 0, 0, time.UTC),
+		Duration: 15 * time.Minute,
+		User:     "Kira",
+	}
+
+	t.Run("Move single occurrence", func(t *testing.T) {
+		storage := &mock.StorageMock{}
+		app, err := NewCalendar(storage, nil)
+		assert.NoError(t, err)
+
+		override := *moved
+		override.SeriesUUID = "1"
+		override.RecurrenceAt = occurrence
+
+		storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+		storage.On("GetEvent", tmock.Anything, "1").Return(standup, nil)
+		storage.On("ListEvents", tmock.Anything, "Kira", moved.StartAt, moved.EndAt()).Return([]*models.Event{standup}, nil)
+		storage.On("OverrideOccurrence", tmock.Anything, "1", occurrence, &override).Return("2", nil)
+
+		err = app.ChangeEvent(context.Background(), "1", moved, ScopeOccurrence, occurrence)
+		assert.NoError(t, err)
+
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("Change this and following", func(t *testing.T) {
+		storage := &mock.StorageMock{}
+		app, err := NewCalendar(storage, nil)
+		assert.NoError(t, err)
+
+		tail := *moved
+		tail.RRule = "FREQ=DAILY;COUNT=8"
+
+		storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+		storage.On("GetEvent", tmock.Anything, "1").Return(standup, nil)
+		storage.On("ListEvents", tmock.Anything, "Kira", moved.StartAt, tmock.Anything).Return([]*models.Event{standup}, nil)
+		storage.On("SplitSeries", tmock.Anything, "1", occurrence, "FREQ=DAILY;COUNT=2", &tail).Return("2", nil)
+
+		err = app.ChangeEvent(context.Background(), "1", &tail, ScopeFollowing, occurrence)
+		assert.NoError(t, err)
+
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("Not an occurrence of the series", func(t *testing.T) {
+		storage := &mock.StorageMock{}
+		app, err := NewCalendar(storage, nil)
+		assert.NoError(t, err)
+
+		storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+		storage.On("GetEvent", tmock.Anything, "1").Return(standup, nil)
+
+		err = app.ChangeEvent(context.Background(), "1", moved, ScopeOccurrence, occurrence.Add(time.Hour))
+		assert.Equal(t, ErrInvalidOccurrence, err)
+
+		storage.AssertExpectations(t)
+	})
+}
+
+func TestApp_RemoveOccurrence(t *testing.T) {
+	standup := &models.Event{
+		UUID:     "1",
+		Title:    "standup",
+		StartAt:  time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC), // monday 10:00
+		Duration: 15 * time.Minute,
+		User:     "Kira",
+		RRule:    "FREQ=WEEKLY;BYDAY=MO,WE",
+	}
+	occurrence := time.Date(2020, time.March, 11, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Cancel single occurrence", func(t *testing.T) {
+		storage := &mock.StorageMock{}
+		app, err := NewCalendar(storage, nil)
+		assert.NoError(t, err)
+
+		storage.On("GetEvent", tmock.Anything, "1").Return(standup, nil)
+		storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+		storage.On("CancelOccurrence", tmock.Anything, "1", occurrence).Return(nil)
+
+		err = app.RemoveEvent(context.Background(), "1", ScopeOccurrence, occurrence)
+		assert.NoError(t, err)
+
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("Remove this and following", func(t *testing.T) {
+		storage := &mock.StorageMock{}
+		app, err := NewCalendar(storage, nil)
+		assert.NoError(t, err)
+
+		storage.On("GetEvent", tmock.Anything, "1").Return(standup, nil)
+		storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+		storage.On("SplitSeries", tmock.Anything, "1", occurrence, "FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20200311T095959Z", (*models.Event)(nil)).Return("", nil)
+
+		err = app.RemoveEvent(context.Background(), "1", ScopeFollowing, occurrence)
+		assert.NoError(t, err)
+
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("Unknown scope", func(t *testing.T) {
+		storage := &mock.StorageMock{}
+		app, err := NewCalendar(storage, nil)
+		assert.NoError(t, err)
+
+		storage.On("GetEvent", tmock.Anything, "1").Return(standup, nil)
+		storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+
+		err = app.RemoveEvent(context.Background(), "1", Scope(9), occurrence)
+		assert.Equal(t, ErrInvalidScope, err)
+
+		storage.AssertExpectations(t)
+	})
+
+	t.Run("Listing skips cancelled occurrence", func(t *testing.T) {
+		storage := &mock.StorageMock{}
+		app, err := NewCalendar(storage, nil)
+		assert.NoError(t, err)
+
+		monday := time.Date(2020, time.March, 9, 0, 0, 0, 0, time.UTC)
+		storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+		storage.On("ListEvents", tmock.Anything, "Kira", monday, monday.AddDate(0, 0, 7)).Return([]*models.Event{standup.WithExDate(occurrence)}, nil)
+
+		events, err := app.ListWeekEvents(context.Background(), "Kira", monday)
+		assert.NoError(t, err)
+		assert.Len(t, events, 1)
+		assert.Equal(t, time.Date(2020, time.March, 9, 10, 0, 0, 0, time.UTC), events[0].StartAt)
+
+		storage.AssertExpectations(t)
+	})
+}
+
+func TestApp_ListEventsInUserTimeZone(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	type testCase struct {
+		profile  *models.User
+		list     func(app App, date time.Time) ([]*models.Event, error)
+		date     time.Time
+		from, to time.Time
+	}
+
+	testCases := make(map[string]testCase)
+
+	testCases["Local day"] = testCase{
+		profile: &models.User{Name: "Kira", Location: moscow, WeekStart: time.Monday},
+		list: func(app App, date time.Time) ([]*models.Event, error) {
+			return app.ListDayEvents(context.Background(), "Kira", date)
+		},
+		date: time.Date(2020, time.March, 1, 22, 30, 0, 0, time.UTC), // 01:30 march 2 in Moscow
+		from: time.Date(2020, time.March, 2, 0, 0, 0, 0, moscow),
+		to:   time.Date(2020, time.March, 3, 0, 0, 0, 0, moscow),
+	}
+	testCases["Week starting on sunday"] = testCase{
+		profile: &models.User{Name: "Kira", Location: moscow, WeekStart: time.Sunday},
+		list: func(app App, date time.Time) ([]*models.Event, error) {
+			return app.ListWeekEvents(context.Background(), "Kira", date)
+		},
+		date: time.Date(2020, time.March, 4, 12, 0, 0, 0, time.UTC), // wednesday
+		from: time.Date(2020, time.March, 1, 0, 0, 0, 0, moscow),
+		to:   time.Date(2020, time.March, 8, 0, 0, 0, 0, moscow),
+	}
+	testCases["Day of DST switch"] = testCase{
+		profile: &models.User{Name: "Kira", Location: newYork, WeekStart: time.Monday},
+		list: func(app App, date time.Time) ([]*models.Event, error) {
+			return app.ListDayEvents(context.Background(), "Kira", date)
+		},
+		date: time.Date(2020, time.March, 8, 12, 0, 0, 0, time.UTC),
+		from: time.Date(2020, time.March, 8, 5, 0, 0, 0, time.UTC),
+		to:   time.Date(2020, time.March, 9, 4, 0, 0, 0, time.UTC), // 23 hours
+	}
+	testCases["Local month"] = testCase{
+		profile: &models.User{Name: "Kira", Location: newYork, WeekStart: time.Monday},
+		list: func(app App, date time.Time) ([]*models.Event, error) {
+			return app.ListMonthEvents(context.Background(), "Kira", date)
+		},
+		date: time.Date(2020, time.April, 1, 3, 0, 0, 0, time.UTC), // still march in New York
+		from: time.Date(2020, time.March, 1, 0, 0, 0, 0, newYork),
+		to:   time.Date(2020, time.April, 1, 0, 0, 0, 0, newYork),
+	}
+
+	for k, v := range testCases {
+		t.Run(k, func(t *testing.T) {
+			storage := &mock.StorageMock{}
+			app, err := NewCalendar(storage, nil)
+			assert.NoError(t, err)
+
+			storage.On("GetUser", tmock.Anything, "Kira").Return(v.profile, nil)
+			storage.On("ListEvents", tmock.Anything, "Kira", mockTime(v.from), mockTime(v.to)).Return([]*models.Event{
+				&models.Event{UUID: "1", StartAt: v.from.UTC(), Duration: time.Hour, User: "Kira"},
+			}, nil)
+
+			events, err := v.list(app, v.date)
+			assert.NoError(t, err)
+			assert.Len(t, events, 1)
+			assert.Equal(t, v.profile.Location, events[0].StartAt.Location())
+
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestApp_RecurringEventKeepsLocalTimeOverDST(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	profile := &models.User{Name: "Kira", Location: newYork, WeekStart: time.Monday}
+	standup := &models.Event{
+		UUID:     "1",
+		Title:    "standup",
+		StartAt:  time.Date(2020, time.March, 2, 15, 0, 0, 0, time.UTC), // 10:00 EST
+		Duration: 15 * time.Minute,
+		User:     "Kira",
+		RRule:    "FREQ=WEEKLY",
+	}
+	storage.On("GetUser", tmock.Anything, "Kira").Return(profile, nil)
+	storage.On("ListEvents", tmock.Anything, "Kira", tmock.Anything, tmock.Anything).Return([]*models.Event{standup}, nil)
+
+	events, err := app.ListDayEvents(context.Background(), "Kira", time.Date(2020, time.March, 9, 12, 0, 0, 0, newYork))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, time.Date(2020, time.March, 9, 10, 0, 0, 0, newYork), events[0].StartAt) // 10:00 EDT
+
+	storage.AssertExpectations(t)
+}
+
+// mockTime сравнивает моменты времени без учета часового пояса
+func mockTime(exp time.Time) interface{} {
+	return tmock.MatchedBy(func(t time.Time) bool {
+		return t.Equal(exp)
+	})
+}
+
+func TestApp_CreateEventRaceLostInStorage(t *testing.T) {
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	newEvent := &models.Event{
+		Title:    "first",
+		StartAt:  time.Date(2020, time.February, 29, 15, 30, 0, 0, time.UTC),
+		Duration: time.Hour,
+		User:     "Kira",
+	}
+
+	// параллельный запрос занял время между проверкой и записью
+	storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+	storage.On("ListEvents", tmock.Anything, "Kira", newEvent.StartAt, newEvent.EndAt()).Return([]*models.Event{}, nil)
+	storage.On("CreateEvent", tmock.Anything, newEvent).Return("", ErrTimeBusy)
+
+	_, err = app.CreateNewEvent(context.Background(), newEvent)
+	assert.Equal(t, ErrTimeBusy, err)
+
+	storage.AssertExpectations(t)
+}
+
+func TestApp_CreateEventReportsConflicts(t *testing.T) {
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	monday := time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC)
+	standup := &models.Event{UUID: "2", Title: "standup", StartAt: monday, Duration: time.Hour, User: "Kira", RRule: "FREQ=DAILY;COUNT=5"}
+	lunch := &models.Event{UUID: "3", Title: "lunch", StartAt: monday.AddDate(0, 0, 2).Add(2 * time.Hour), Duration: time.Hour, User: "Kira"}
+	newEvent := &models.Event{
+		Title:    "long review",
+		StartAt:  monday.AddDate(0, 0, 1).Add(30 * time.Minute),
+		Duration: 2 * time.Hour,
+		User:     "Kira",
+		RRule:    "FREQ=DAILY;COUNT=2",
+	}
+
+	storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+	storage.On("ListEvents", tmock.Anything, "Kira", mockTime(newEvent.StartAt), tmock.Anything).Return([]*models.Event{standup, lunch}, nil)
+
+	_, err = app.CreateNewEvent(context.Background(), newEvent)
+	busy, ok := err.(*BusyError)
+	if assert.True(t, ok, "unexpected error %v", err) {
+		assert.Equal(t, []Conflict{
+			{UUID: "2", StartAt: monday.AddDate(0, 0, 1), EndAt: monday.AddDate(0, 0, 1).Add(time.Hour)},
+			{UUID: "2", StartAt: monday.AddDate(0, 0, 2), EndAt: monday.AddDate(0, 0, 2).Add(time.Hour)},
+			{UUID: "3", StartAt: lunch.StartAt, EndAt: lunch.EndAt()},
+		}, busy.Conflicts)
+	}
+
+	storage.AssertExpectations(t)
+}
+
+func TestApp_ListDayEventsIncludesOvernightEvent(t *testing.T) {
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	day := time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC)
+	overnight := &models.Event{
+		UUID:     "1",
+		Title:    "release",
+		StartAt:  day.Add(-time.Hour), // 23:00 - 02:00
+		Duration: 3 * time.Hour,
+		User:     "Kira",
+	}
+
+	storage.On("GetUser", tmock.Anything, "Kira").Return(nil, ErrNotFound)
+	storage.On("ListEvents", tmock.Anything, "Kira", day, day.AddDate(0, 0, 1)).Return([]*models.Event{overnight}, nil)
+
+	events, err := app.ListDayEvents(context.Background(), "Kira", day.Add(10*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "release", events[0].Title)
+
+	storage.AssertExpectations(t)
+}
+
+func TestApp_ListAllDayEventInLocalDay(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	profile := &models.User{Name: "Kira", Location: moscow, WeekStart: time.Monday}
+	holiday := &models.Event{
+		UUID:     "1",
+		Title:    "holiday",
+		StartAt:  time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC),
+		Duration: 24 * time.Hour,
+		User:     "Kira",
+		AllDay:   true,
+	}
+	from := time.Date(2020, time.March, 2, 0, 0, 0, 0, moscow)
+
+	storage.On("GetUser", tmock.Anything, "Kira").Return(profile, nil)
+	storage.On("ListEvents", tmock.Anything, "Kira", mockTime(from), mockTime(from.AddDate(0, 0, 1))).Return([]*models.Event{holiday}, nil)
+
+	events, err := app.ListDayEvents(context.Background(), "Kira", from.Add(12*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, from, events[0].StartAt)
+	assert.Equal(t, from.AddDate(0, 0, 1), events[0].EndAt())
+
+	storage.AssertExpectations(t)
+}
+
+func TestApp_CreateEventOverAllDayEvent(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	type testCase struct {
+		allDayBusy bool
+		expErr     error
+	}
+
+	testCases := make(map[string]testCase)
+
+	testCases["All-day events are free"] = testCase{allDayBusy: false}
+	testCases["All-day events are busy"] = testCase{allDayBusy: true, expErr: ErrTimeBusy}
+
+	for k, v := range testCases {
+		t.Run(k, func(t *testing.T) {
+			storage := &mock.StorageMock{}
+			app, err := NewCalendar(storage, nil)
+			assert.NoError(t, err)
+
+			profile := &models.User{Name: "Kira", Location: moscow, WeekStart: time.Monday, AllDayBusy: v.allDayBusy}
+			holiday := &models.Event{
+				UUID:     "1",
+				Title:    "holiday",
+				StartAt:  time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC),
+				Duration: 24 * time.Hour,
+				User:     "Kira",
+				AllDay:   true,
+			}
+			// 01:00 по Москве - уже 2 марта, хотя в UTC еще 1 марта
+			newEvent := &models.Event{
+				Title:    "early call",
+				StartAt:  time.Date(2020, time.March, 2, 1, 0, 0, 0, moscow),
+				Duration: time.Hour,
+				User:     "Kira",
+			}
+
+			storage.On("GetUser", tmock.Anything, "Kira").Return(profile, nil)
+			storage.On("ListEvents", tmock.Anything, "Kira", mockTime(newEvent.StartAt), mockTime(newEvent.EndAt())).Return([]*models.Event{holiday}, nil)
 			if v.expErr == nil {
-				storage.On("UpdateEvent", context.Background(), v.uuid, v.newEvent).Return(nil)
+				storage.On("CreateEvent", tmock.Anything, newEvent).Return("2", nil)
 			}
-			err = app.ChangeEvent(context.Background(), v.uuid, v.newEvent)
-			assert.Equal(t, v.expErr, err)
+
+			_, err = app.CreateNewEvent(context.Background(), newEvent)
+			assert.True(t, errors.Is(err, v.expErr), "unexpected error %v", err)
 
 			storage.AssertExpectations(t)
 		})
 	}
 }
+
+func TestApp_FreeBusy(t *testing.T) {
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	monday := time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC)
+	friday := monday.AddDate(0, 0, 4)
+	at := func(day, hour int) time.Time {
+		return monday.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour)
+	}
+
+	storage.On("GetUser", tmock.Anything, tmock.Anything).Return(nil, ErrNotFound)
+	storage.On("ListEvents", tmock.Anything, "Kira", monday, friday).Return([]*models.Event{
+		// тянется с прошлой недели
+		&models.Event{UUID: "1", Title: "on call", StartAt: monday.Add(-time.Hour), Duration: 2 * time.Hour, User: "Kira"},
+		&models.Event{UUID: "2", Title: "standup", StartAt: at(0, 10), Duration: time.Hour, User: "Kira", RRule: "FREQ=DAILY;COUNT=2"},
+		&models.Event{UUID: "3", Title: "review", StartAt: at(0, 10).Add(30 * time.Minute), Duration: time.Hour, User: "Kira"},
+		&models.Event{UUID: "4", Title: "lunch", StartAt: at(0, 13), Duration: time.Hour, User: "Kira", Transparency: models.Transparent},
+		&models.Event{UUID: "5", Title: "offsite", StartAt: at(2, 9), Duration: time.Hour, User: "Kira", Status: models.StatusCancelled},
+	}, nil)
+	storage.On("ListEvents", tmock.Anything, "Ivan", monday, friday).Return([]*models.Event{}, nil)
+
+	busy, err := app.FreeBusy(context.Background(), []string{"Kira", "Ivan"}, monday, friday)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]models.Interval{
+		"Kira": {
+			{Start: monday, End: at(0, 1)},
+			{Start: at(0, 10), End: at(0, 11).Add(30 * time.Minute)},
+			{Start: at(1, 10), End: at(1, 11)},
+		},
+		"Ivan": {},
+	}, busy)
+
+	_, err = app.FreeBusy(context.Background(), []string{"Kira"}, friday, monday)
+	assert.Equal(t, ErrInvalidInterval, err)
+
+	_, err = app.FreeBusy(context.Background(), []string{"Kira"}, monday, monday.AddDate(30, 0, 0))
+	assert.Equal(t, ErrIntervalTooLong, err)
+
+	storage.AssertExpectations(t)
+}
+
+func TestApp_FindSlots(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	monday := time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return monday.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	storage.On("GetUser", tmock.Anything, "Kira").Return(&models.User{Name: "Kira", Location: moscow, WeekStart: time.Monday}, nil)
+	storage.On("GetUser", tmock.Anything, "Ivan").Return(nil, ErrNotFound)
+	storage.On("ListEvents", tmock.Anything, "Kira", monday, monday.AddDate(0, 0, 1)).Return([]*models.Event{
+		&models.Event{UUID: "1", Title: "lunch", StartAt: at(9, 0), Duration: time.Hour, User: "Kira"},
+		&models.Event{UUID: "2", Title: "focus", StartAt: at(11, 0), Duration: 3 * time.Hour, User: "Kira", Transparency: models.Transparent},
+	}, nil)
+	storage.On("ListEvents", tmock.Anything, "Ivan", monday, monday.AddDate(0, 0, 1)).Return([]*models.Event{
+		&models.Event{UUID: "3", Title: "call", StartAt: at(10, 30), Duration: 30 * time.Minute, User: "Ivan"},
+	}, nil)
+
+	// 9:00-18:00 по Москве и по UTC пересекаются с 9:00 до 15:00 UTC
+	slots, err := app.FindSlots(context.Background(), &SlotQuery{
+		Users:        []string{"Kira", "Ivan"},
+		Duration:     time.Hour,
+		From:         monday,
+		To:           monday.AddDate(0, 0, 1),
+		WorkDayStart: 9 * time.Hour,
+		WorkDayEnd:   18 * time.Hour,
+		Limit:        4,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Interval{
+		{Start: at(11, 0), End: at(12, 0)},
+		{Start: at(12, 0), End: at(13, 0)},
+		{Start: at(13, 0), End: at(14, 0)},
+		{Start: at(14, 0), End: at(15, 0)},
+	}, slots)
+
+	_, err = app.FindSlots(context.Background(), &SlotQuery{
+		Users:        []string{"Kira"},
+		Duration:     time.Hour,
+		From:         monday,
+		To:           monday.AddDate(0, 0, 1),
+		WorkDayStart: 18 * time.Hour,
+		WorkDayEnd:   9 * time.Hour,
+	})
+	assert.Equal(t, ErrInvalidSlotQuery, err)
+
+	// поиск на десятилетия вперед занял бы процессор надолго
+	_, err = app.FindSlots(context.Background(), &SlotQuery{
+		Users:    []string{"Kira"},
+		Duration: time.Hour,
+		From:     monday,
+		To:       monday.AddDate(50, 0, 0),
+	})
+	assert.Equal(t, ErrIntervalTooLong, err)
+
+	storage.AssertExpectations(t)
+}
+
+func TestApp_PublishesChangesAfterCommit(t *testing.T) {
+	storage := &mock.StorageMock{}
+	calendar := &Calendar{storage: storage, changes: NewBus()}
+	changes, cancel := calendar.changes.Subscribe("Kira")
+	defer cancel()
+
+	rollback := errors.New("rollback")
+	err := calendar.inTransaction(context.Background(), func(ctx context.Context) error {
+		if err := calendar.publish(ctx, "Kira", models.ChangeCreated, "1"); err != nil {
+			return err
+		}
+		return rollback
+	})
+	assert.Equal(t, rollback, err)
+	assert.Len(t, changes, 0)
+
+	err = calendar.inTransaction(context.Background(), func(ctx context.Context) error {
+		if err := calendar.publish(ctx, "Kira", models.ChangeUpdated, "1"); err != nil {
+			return err
+		}
+		// до фиксации подписчик изменения не видит
+		assert.Len(t, changes, 0)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Change{Kind: models.ChangeUpdated, User: "Kira", UUID: "1"}, <-changes)
+}
@@ -0,0 +1,93 @@
+package app_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	pg "github.com/bobrovka/calendar/internal/storage/storage-pg"
+	_ "github.com/jackc/pgx/v4/stdlib"
+)
+
+// BenchmarkCreateNewEvent показывает, что время записи не растет вместе с историей пользователя:
+// проверка свободного времени читает только события, пересекающиеся с новым.
+// StoragePg замеряется, только если в CALENDAR_TEST_PG задана строка подключения к базе с примененными миграциями
+func BenchmarkCreateNewEvent(b *testing.B) {
+	b.Run("memory", func(b *testing.B) {
+		benchmarkCreateNewEvent(b, func(b *testing.B) (app.EventStorage, string) {
+			return memory.NewStorageMemory(), "Kira"
+		})
+	})
+	b.Run("pg", func(b *testing.B) {
+		dsn := os.Getenv("CALENDAR_TEST_PG")
+		if dsn == "" {
+			b.Skip("CALENDAR_TEST_PG is not set")
+		}
+		benchmarkCreateNewEvent(b, func(b *testing.B) (app.EventStorage, string) {
+			storage, err := pg.NewStoragePgDSN(dsn)
+			if err != nil {
+				b.Fatal(err)
+			}
+			// у каждого замера своя история, после замера она удаляется вместе с напоминаниями
+			user := fmt.Sprintf("bench-%d", time.Now().UnixNano())
+			b.Cleanup(func() {
+				db, err := sql.Open("pgx", dsn)
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				defer db.Close()
+				if _, err := db.Exec(`DELETE FROM events WHERE user_name=$1`, user); err != nil {
+					b.Error(err)
+				}
+			})
+			return storage, user
+		})
+	})
+}
+
+func benchmarkCreateNewEvent(b *testing.B, newStorage func(b *testing.B) (app.EventStorage, string)) {
+	for _, history := range []int{100, 10000, 100000} {
+		b.Run(fmt.Sprintf("history=%d", history), func(b *testing.B) {
+			ctx := context.Background()
+			storage, user := newStorage(b)
+			calendar, err := app.NewCalendar(storage, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			start := time.Date(2010, time.January, 1, 9, 0, 0, 0, time.UTC)
+			for i := 0; i < history; i++ {
+				_, err := storage.CreateEvent(ctx, &models.Event{
+					Title:    "past meeting",
+					StartAt:  start.Add(time.Duration(i) * 2 * time.Hour),
+					Duration: time.Hour,
+					User:     user,
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			next := start.Add(time.Duration(history) * 2 * time.Hour)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := calendar.CreateNewEvent(ctx, &models.Event{
+					Title:    "meeting",
+					StartAt:  next.Add(time.Duration(i) * 2 * time.Hour),
+					Duration: time.Hour,
+					User:     user,
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"sync"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// subscriberBuffer сколько изменений может ждать подписчика, прежде чем он считается отставшим
+const subscriberBuffer = 64
+
+// ChangeBus шина изменений календарей. Приложение публикует в нее изменения в транзакции записи
+// с контекстом этой транзакции, WatchEvents подписывается на изменения календаря пользователя.
+// Шина не должна доставлять изменение подписчикам раньше фиксации транзакции
+type ChangeBus interface {
+	// Publish передаст изменение подписчикам. Ошибка отменяет запись
+	Publish(ctx context.Context, change *models.Change) error
+	// Subscribe подпишет на изменения календаря user. Канал закрывается вызовом cancel
+	// или шиной, если подписчик не успевает читать изменения
+	Subscribe(user string) (changes <-chan *models.Change, cancel func())
+}
+
+// pendingKey ключ контекста транзакции приложения, в котором Bus копит изменения до фиксации
+type pendingKey struct{}
+
+// pendingChanges изменения одной попытки транзакции. Повтор транзакции начинает их заново,
+// откат отбрасывает
+type pendingChanges struct {
+	deliver []func()
+}
+
+// Bus шина изменений внутри процесса
+type Bus struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan *models.Change]struct{}
+	closed      bool
+}
+
+// NewBus создает шину без подписчиков
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[string]map[chan *models.Change]struct{}),
+	}
+}
+
+// Publish передаст изменение подписчикам пользователя, не дожидаясь их. Отставший подписчик отключается.
+// Изменение, опубликованное в транзакции приложения, передается только после ее фиксации
+func (b *Bus) Publish(ctx context.Context, change *models.Change) error {
+	if pending, ok := ctx.Value(pendingKey{}).(*pendingChanges); ok {
+		pending.deliver = append(pending.deliver, func() { b.publish(change) })
+		return nil
+	}
+	b.publish(change)
+	return nil
+}
+
+func (b *Bus) publish(change *models.Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers[change.User] {
+		select {
+		case ch <- change:
+		default:
+			b.unsubscribe(change.User, ch)
+		}
+	}
+}
+
+// Subscribe подпишет на изменения календаря user
+func (b *Bus) Subscribe(user string) (<-chan *models.Change, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan *models.Change, subscriberBuffer)
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if b.subscribers[user] == nil {
+		b.subscribers[user] = make(map[chan *models.Change]struct{})
+	}
+	b.subscribers[user][ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.unsubscribe(user, ch)
+	}
+}
+
+// DisconnectAll отключит всех подписчиков, например когда изменения могли быть потеряны:
+// они получат ErrWatchLagging и запросят снимок заново
+func (b *Bus) DisconnectAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for user, subscribers := range b.subscribers {
+		for ch := range subscribers {
+			b.unsubscribe(user, ch)
+		}
+	}
+}
+
+// Close отключит всех подписчиков и не даст подписаться новым, чтобы при остановке сервиса
+// WatchEvents завершились и клиенты переподключились к другому экземпляру
+func (b *Bus) Close() {
+	b.DisconnectAll()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+}
+
+func (b *Bus) unsubscribe(user string, ch chan *models.Change) {
+	if _, ok := b.subscribers[user][ch]; !ok {
+		return
+	}
+	delete(b.subscribers[user], ch)
+	if len(b.subscribers[user]) == 0 {
+		delete(b.subscribers, user)
+	}
+	close(ch)
+}
@@ -0,0 +1,27 @@
+package app
+
+import "context"
+
+// callerKey ключ пользователя, от имени которого выполняется вызов
+type callerKey struct{}
+
+// WithCaller вернет контекст вызова от имени аутентифицированного пользователя user
+func WithCaller(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, callerKey{}, user)
+}
+
+// CallerFromContext вернет пользователя, от имени которого выполняется вызов
+func CallerFromContext(ctx context.Context) (string, bool) {
+	user, ok := ctx.Value(callerKey{}).(string)
+	return user, ok
+}
+
+// authorize проверит, что вызов идет от имени владельца календаря user. Вызовы без пользователя
+// в контексте - изнутри сервиса или при выключенной аутентификации - не ограничиваются
+func authorize(ctx context.Context, user string) error {
+	caller, ok := CallerFromContext(ctx)
+	if ok && caller != user {
+		return ErrForbidden
+	}
+	return nil
+}
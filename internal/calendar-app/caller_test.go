@@ -0,0 +1,60 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_CallerOwnsCalendar(t *testing.T) {
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+
+	kira := app.WithCaller(context.Background(), "Kira")
+	lena := app.WithCaller(context.Background(), "Lena")
+	start := time.Date(2030, time.March, 4, 10, 0, 0, 0, time.UTC)
+	event := func(user, title string) *models.Event {
+		return &models.Event{Title: title, StartAt: start, Duration: time.Hour, User: user}
+	}
+
+	uuid, err := calendar.CreateNewEvent(kira, event("Kira", "review"))
+	assert.NoError(t, err)
+
+	_, err = calendar.CreateNewEvent(lena, event("Kira", "prank"))
+	assert.Equal(t, app.ErrForbidden, err)
+
+	_, err = calendar.ListDayEvents(lena, "Kira", start)
+	assert.Equal(t, app.ErrForbidden, err)
+
+	_, err = calendar.GetProfile(lena, "Kira")
+	assert.Equal(t, app.ErrForbidden, err)
+
+	_, err = calendar.ExportEvents(lena, "Kira")
+	assert.Equal(t, app.ErrForbidden, err)
+
+	// чужое событие не видно ни под своим, ни под чужим именем
+	err = calendar.ChangeEvent(lena, uuid, event("Lena", "mine now"), app.ScopeSeries, time.Time{})
+	assert.Equal(t, app.ErrNotFound, err)
+	err = calendar.ChangeEvent(lena, uuid, event("Kira", "prank"), app.ScopeSeries, time.Time{})
+	assert.Equal(t, app.ErrForbidden, err)
+	err = calendar.RemoveEvent(lena, uuid, app.ScopeSeries, time.Time{})
+	assert.Equal(t, app.ErrNotFound, err)
+
+	// занятость без подробностей видна всем
+	busy, err := calendar.FreeBusy(lena, []string{"Kira"}, start, start.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, busy["Kira"], 1)
+
+	events, err := calendar.ListDayEvents(kira, "Kira", start)
+	assert.NoError(t, err)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "review", events[0].Title)
+	}
+
+	assert.NoError(t, calendar.RemoveEvent(kira, uuid, app.ScopeSeries, time.Time{}))
+}
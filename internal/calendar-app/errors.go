@@ -1,6 +1,9 @@
 package app
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	// ErrNotFound объект не найден
@@ -8,4 +11,63 @@ var (
 
 	// ErrTimeBusy время уже занято
 	ErrTimeBusy = errors.New("this time is busy")
+
+	// ErrInvalidOccurrence в серии нет такого экземпляра
+	ErrInvalidOccurrence = errors.New("occurrence is not part of the series")
+
+	// ErrInvalidTimeZone неизвестный часовой пояс
+	ErrInvalidTimeZone = errors.New("unknown time zone")
+	// ErrInvalidChannel неизвестный канал напоминаний
+	ErrInvalidChannel = errors.New("unknown notification channel")
+	// ErrInvalidEmail неверный адрес почты или он не задан для канала email
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrInvalidLocale язык напоминаний не поддерживается
+	ErrInvalidLocale = errors.New("unsupported locale")
+	// ErrInvalidWebhook неверный адрес webhook или он не задан для канала webhook
+	ErrInvalidWebhook = errors.New("invalid webhook url")
+
+	// ErrInvalidScope неизвестная область изменения
+	ErrInvalidScope = errors.New("unknown change scope")
+
+	// ErrInvalidInterval конец промежутка не позже начала
+	ErrInvalidInterval = errors.New("interval end must be after its start")
+
+	// ErrIntervalTooLong промежуток поиска занятости или свободного времени длиннее conflictHorizon
+	ErrIntervalTooLong = errors.New("interval must be at most two years")
+
+	// ErrForbidden календарь принадлежит другому пользователю
+	ErrForbidden = errors.New("access to another user's calendar is denied")
+
+	// ErrInvalidSlotQuery неверные параметры поиска свободного времени
+	ErrInvalidSlotQuery = errors.New("slot query needs users, positive duration and valid working hours")
+
+	// ErrInvalidPageSize отрицательный размер страницы
+	ErrInvalidPageSize = errors.New("page size must not be negative")
+
+	// ErrInvalidPageToken токен страницы испорчен или выдан для другой выборки
+	ErrInvalidPageToken = errors.New("page token is malformed or belongs to another query")
+
+	// ErrWatchLagging подписчик не успевал читать изменения и отключен, нужно подписаться заново
+	ErrWatchLagging = errors.New("watcher fell behind calendar changes, watch again")
 )
+
+// Conflict экземпляр события, который занимает нужное время
+type Conflict struct {
+	UUID    string
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+// BusyError ErrTimeBusy с событиями, которые занимают время. errors.Is(err, ErrTimeBusy) для нее верно
+type BusyError struct {
+	Conflicts []Conflict
+}
+
+func (e *BusyError) Error() string {
+	return ErrTimeBusy.Error()
+}
+
+// Is ...
+func (e *BusyError) Is(target error) bool {
+	return target == ErrTimeBusy
+}
@@ -0,0 +1,52 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_ImportEvents(t *testing.T) {
+	ctx := context.Background()
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+
+	monday := time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC)
+	batch := func(title string) []*models.Event {
+		return []*models.Event{
+			{ExternalUID: "standup@other", Title: title, StartAt: monday, Duration: 15 * time.Minute, RRule: "FREQ=DAILY;COUNT=5",
+				ExDates: []time.Time{monday.AddDate(0, 0, 2)}},
+			{ExternalUID: "clash@other", Title: "clash", StartAt: monday.AddDate(0, 0, 1), Duration: time.Hour},
+			{ExternalUID: "bad@other", Title: "bad", StartAt: monday, RRule: "FREQ=HOURLY"},
+		}
+	}
+
+	results, err := calendar.ImportEvents(ctx, "Kira", batch("standup"))
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+	assert.Equal(t, app.ImportCreated, results[0].Status)
+	// пересекается со вторым экземпляром только что импортированной серии
+	assert.Equal(t, app.ImportConflict, results[1].Status)
+	assert.True(t, errors.Is(results[1].Err, app.ErrTimeBusy))
+	assert.Equal(t, app.ImportInvalid, results[2].Status)
+	assert.Error(t, results[2].Err)
+
+	again, err := calendar.ImportEvents(ctx, "Kira", batch("daily standup"))
+	assert.NoError(t, err)
+	assert.Equal(t, app.ImportUpdated, again[0].Status)
+	assert.Equal(t, results[0].UUID, again[0].UUID)
+
+	events, err := calendar.ListWeekEvents(ctx, "Kira", monday)
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+	for _, e := range events {
+		assert.Equal(t, "daily standup", e.Title)
+		assert.NotEqual(t, monday.AddDate(0, 0, 2), e.StartAt)
+	}
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+const (
+	// defaultPageSize размер страницы, если он не задан
+	defaultPageSize = 100
+	// maxPageSize страницы больше этого урезаются
+	maxPageSize = 1000
+)
+
+// RangeQuery параметры постраничного списка событий за произвольный промежуток
+type RangeQuery struct {
+	User string
+	From time.Time
+	To   time.Time
+	// PageSize сколько экземпляров вернуть, по умолчанию defaultPageSize
+	PageSize int
+	// PageToken NextPageToken предыдущей страницы, пустой для первой
+	PageToken string
+	// Desc от поздних событий к ранним
+	Desc bool
+}
+
+// Page страница событий
+type Page struct {
+	Events []*models.Event
+	// NextPageToken токен следующей страницы, пустой на последней
+	NextPageToken string
+}
+
+// pageToken содержимое токена страницы. Query привязывает токен к выборке,
+// чтобы его нельзя было продолжить с другими from, to или порядком
+type pageToken struct {
+	StartAt time.Time `json:"s"`
+	UUID    string    `json:"u"`
+	Query   uint64    `json:"q"`
+}
+
+// ListEventsRange вернет страницу экземпляров событий пользователя, пересекающихся с [From, To),
+// упорядоченных по началу и UUID и со временем в часовом поясе пользователя.
+// Разовые события выбираются хранилищем по ключу, серии и события на целый день разворачиваются здесь
+func (a *Calendar) ListEventsRange(ctx context.Context, query *RangeQuery) (*Page, error) {
+	if err := authorize(ctx, query.User); err != nil {
+		return nil, err
+	}
+	// серии разворачиваются на все окно для каждой страницы, поэтому оно ограничено
+	if err := validateSearchInterval(query.From, query.To); err != nil {
+		return nil, err
+	}
+
+	size := query.PageSize
+	switch {
+	case size < 0:
+		return nil, ErrInvalidPageSize
+	case size == 0:
+		size = defaultPageSize
+	case size > maxPageSize:
+		size = maxPageSize
+	}
+
+	after, err := decodePageToken(query)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := a.getProfile(ctx, query.User)
+	if err != nil {
+		return nil, err
+	}
+
+	// на одно событие больше, чтобы знать, есть ли следующая страница
+	pageQuery := &models.PageQuery{
+		User:  query.User,
+		From:  query.From,
+		To:    query.To,
+		After: after,
+		Desc:  query.Desc,
+		Limit: size + 1,
+	}
+	single, err := a.storage.ListEventsPage(ctx, pageQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	floating, err := a.storage.ListSeriesAndAllDay(ctx, query.User, query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	occurrences, err := expandEvents(inLocation(profile.Location, floating...), query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+
+	// разовые события после ключа уже отобраны хранилищем, экземпляры серий отсекаются здесь
+	events := inLocation(profile.Location, single...)
+	for _, occurrence := range occurrences {
+		if pageQuery.Follows(occurrence.Key()) {
+			events = append(events, occurrence)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if query.Desc {
+			return events[j].Key().Less(events[i].Key())
+		}
+		return events[i].Key().Less(events[j].Key())
+	})
+
+	page := &Page{Events: events}
+	if len(events) > size {
+		page.Events = events[:size]
+		page.NextPageToken, err = encodePageToken(query, events[size-1].Key())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// queryHash отпечаток выборки, к которой относится токен
+func queryHash(query *RangeQuery) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s\x00%d\x00%d\x00%t", query.User, query.From.UnixNano(), query.To.UnixNano(), query.Desc)
+	return h.Sum64()
+}
+
+func encodePageToken(query *RangeQuery, last models.PageKey) (string, error) {
+	data, err := json.Marshal(&pageToken{StartAt: last.StartAt.UTC(), UUID: last.UUID, Query: queryHash(query)})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePageToken вернет ключ, после которого начинается страница, или нулевой ключ для первой страницы
+func decodePageToken(query *RangeQuery) (models.PageKey, error) {
+	if query.PageToken == "" {
+		return models.PageKey{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(query.PageToken)
+	if err != nil {
+		return models.PageKey{}, ErrInvalidPageToken
+	}
+	var token pageToken
+	if err = json.Unmarshal(data, &token); err != nil || token.UUID == "" || token.Query != queryHash(query) {
+		return models.PageKey{}, ErrInvalidPageToken
+	}
+	return models.PageKey{StartAt: token.StartAt, UUID: token.UUID}, nil
+}
@@ -0,0 +1,135 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_ListEventsRangePages(t *testing.T) {
+	ctx := context.Background()
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+	assert.NoError(t, calendar.UpdateProfile(ctx, &models.User{Name: "Kira", Location: moscow, WeekStart: time.Monday}))
+
+	day := time.Date(2030, time.March, 4, 0, 0, 0, 0, moscow)
+	events := []*models.Event{
+		{Title: "standup", StartAt: day.Add(9 * time.Hour), Duration: 15 * time.Minute, RRule: "FREQ=DAILY;COUNT=3"},
+		{Title: "review", StartAt: day.Add(11 * time.Hour), Duration: time.Hour},
+		{Title: "lunch", StartAt: day.Add(11 * time.Hour), Duration: time.Hour, Transparency: models.Transparent},
+		{Title: "holiday", StartAt: day.AddDate(0, 0, 1), Duration: 24 * time.Hour, AllDay: true},
+		{Title: "retro", StartAt: day.AddDate(0, 0, 2).Add(16 * time.Hour), Duration: time.Hour},
+		{Title: "next month", StartAt: day.AddDate(0, 1, 0), Duration: time.Hour},
+	}
+	for _, e := range events {
+		e.User = "Kira"
+		_, err = calendar.CreateNewEvent(ctx, e)
+		assert.NoError(t, err)
+	}
+
+	from, to := day, day.AddDate(0, 0, 7)
+	all, err := calendar.ListEventsRange(ctx, &app.RangeQuery{User: "Kira", From: from, To: to})
+	assert.NoError(t, err)
+	assert.Empty(t, all.NextPageToken)
+	if assert.Len(t, all.Events, 7) {
+		assert.Equal(t, "standup", all.Events[0].Title)
+		assert.Equal(t, "holiday", all.Events[3].Title)
+		// событие на целый день стоит в местной полуночи
+		assert.Equal(t, day.AddDate(0, 0, 1), all.Events[3].StartAt)
+		assert.Equal(t, "retro", all.Events[6].Title)
+	}
+
+	walk := func(desc bool) []*models.Event {
+		var result []*models.Event
+		query := &app.RangeQuery{User: "Kira", From: from, To: to, PageSize: 2, Desc: desc}
+		for i := 0; i < 10; i++ {
+			page, err := calendar.ListEventsRange(ctx, query)
+			assert.NoError(t, err)
+			assert.True(t, len(page.Events) <= 2)
+			result = append(result, page.Events...)
+			if page.NextPageToken == "" {
+				return result
+			}
+			query.PageToken = page.NextPageToken
+		}
+		t.Fatal("pages do not end")
+		return nil
+	}
+
+	assert.Equal(t, titles(all.Events), titles(walk(false)))
+
+	reversed := make([]*models.Event, 0, len(all.Events))
+	for i := len(all.Events) - 1; i >= 0; i-- {
+		reversed = append(reversed, all.Events[i])
+	}
+	assert.Equal(t, titles(reversed), titles(walk(true)))
+}
+
+func TestCalendar_ListEventsRangeInvalid(t *testing.T) {
+	type testCase struct {
+		query  app.RangeQuery
+		expErr error
+	}
+
+	ctx := context.Background()
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+
+	from := time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err = calendar.CreateNewEvent(ctx, &models.Event{Title: "review", StartAt: from.Add(time.Duration(i) * time.Hour), Duration: time.Hour, User: "Kira"})
+		assert.NoError(t, err)
+	}
+	page, err := calendar.ListEventsRange(ctx, &app.RangeQuery{User: "Kira", From: from, To: from.AddDate(0, 0, 1), PageSize: 1})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, page.NextPageToken)
+
+	testCases := make(map[string]testCase)
+	testCases["empty interval"] = testCase{
+		query:  app.RangeQuery{User: "Kira", From: from, To: from},
+		expErr: app.ErrInvalidInterval,
+	}
+	testCases["oversized interval"] = testCase{
+		query:  app.RangeQuery{User: "Kira", From: time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC), To: time.Date(9999, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		expErr: app.ErrIntervalTooLong,
+	}
+	testCases["negative page size"] = testCase{
+		query:  app.RangeQuery{User: "Kira", From: from, To: from.AddDate(0, 0, 1), PageSize: -1},
+		expErr: app.ErrInvalidPageSize,
+	}
+	testCases["garbage token"] = testCase{
+		query:  app.RangeQuery{User: "Kira", From: from, To: from.AddDate(0, 0, 1), PageToken: "not a token"},
+		expErr: app.ErrInvalidPageToken,
+	}
+	testCases["token of another interval"] = testCase{
+		query:  app.RangeQuery{User: "Kira", From: from, To: from.AddDate(0, 0, 2), PageToken: page.NextPageToken},
+		expErr: app.ErrInvalidPageToken,
+	}
+	testCases["token of another order"] = testCase{
+		query:  app.RangeQuery{User: "Kira", From: from, To: from.AddDate(0, 0, 1), PageToken: page.NextPageToken, Desc: true},
+		expErr: app.ErrInvalidPageToken,
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := calendar.ListEventsRange(ctx, &tc.query)
+			assert.Equal(t, tc.expErr, err)
+		})
+	}
+}
+
+func titles(events []*models.Event) []string {
+	result := make([]string, 0, len(events))
+	for _, e := range events {
+		result = append(result, e.Title)
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_ChangeSeriesWithMovedOccurrence(t *testing.T) {
+	ctx := context.Background()
+	monday := time.Date(2030, time.March, 4, 10, 0, 0, 0, time.UTC)
+	standup := func(title string, start time.Time, rrule string) *models.Event {
+		return &models.Event{Title: title, StartAt: start, Duration: time.Hour, User: "Kira", RRule: rrule}
+	}
+
+	// второй экземпляр перенесен на полчаса и пересекается со своим прежним временем
+	setup := func(t *testing.T, moved time.Time) (app.App, string) {
+		calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+		assert.NoError(t, err)
+
+		uuid, err := calendar.CreateNewEvent(ctx, standup("standup", monday, "FREQ=DAILY;COUNT=5"))
+		assert.NoError(t, err)
+		err = calendar.ChangeEvent(ctx, uuid, standup("standup", moved.Add(30*time.Minute), ""), app.ScopeOccurrence, moved)
+		assert.NoError(t, err)
+		return calendar, uuid
+	}
+
+	t.Run("Whole series", func(t *testing.T) {
+		calendar, uuid := setup(t, monday.AddDate(0, 0, 1))
+
+		err := calendar.ChangeEvent(ctx, uuid, standup("daily standup", monday, "FREQ=DAILY;COUNT=5"), app.ScopeSeries, time.Time{})
+		assert.NoError(t, err)
+	})
+
+	t.Run("This and following", func(t *testing.T) {
+		calendar, uuid := setup(t, monday.AddDate(0, 0, 3))
+
+		tail := standup("daily standup", monday.AddDate(0, 0, 2), "FREQ=DAILY;COUNT=3")
+		err := calendar.ChangeEvent(ctx, uuid, tail, app.ScopeFollowing, monday.AddDate(0, 0, 2))
+		assert.NoError(t, err)
+
+		events, err := calendar.ListWeekEvents(ctx, "Kira", monday)
+		assert.NoError(t, err)
+		assert.Len(t, events, 5)
+	})
+}
+
+func TestCalendar_FindSlotsBetweenSeries(t *testing.T) {
+	ctx := context.Background()
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+
+	monday := time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC)
+	_, err = calendar.CreateNewEvent(ctx, &models.Event{Title: "night", StartAt: monday, Duration: 22*time.Hour + 50*time.Minute, RRule: "FREQ=DAILY", User: "Kira"})
+	assert.NoError(t, err)
+	_, err = calendar.CreateNewEvent(ctx, &models.Event{Title: "standup", StartAt: monday.Add(10 * time.Hour), Duration: 15 * time.Minute, RRule: "FREQ=DAILY", User: "Lena"})
+	assert.NoError(t, err)
+
+	// свободно только с 23:00, если считать с шагом от начала поиска
+	slots, err := calendar.FindSlots(ctx, &app.SlotQuery{
+		Users:    []string{"Kira", "Lena"},
+		Duration: time.Hour,
+		From:     monday,
+		To:       monday.AddDate(0, 0, 700),
+		Limit:    3,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Interval{
+		{Start: monday.Add(23 * time.Hour), End: monday.Add(24 * time.Hour)},
+		{Start: monday.Add(47 * time.Hour), End: monday.Add(48 * time.Hour)},
+		{Start: monday.Add(71 * time.Hour), End: monday.Add(72 * time.Hour)},
+	}, slots)
+}
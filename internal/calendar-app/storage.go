@@ -9,10 +9,38 @@ import (
 
 // EventStorage хранилище событий
 type EventStorage interface {
+	// ListEvents вернет разовые события пользователя, пересекающиеся с [from, to),
+	// а также повторяющиеся события, начавшиеся до to: их экземпляры разворачивает приложение.
+	// Событие нулевой длительности пересекается с интервалом, если начинается внутри него
 	ListEvents(ctx context.Context, user string, from, to time.Time) ([]*models.Event, error)
+	// ListEventsPage вернет до query.Limit разовых событий пользователя со временем начала, пересекающихся
+	// с [From, To) и идущих в порядке (StartAt, UUID) после query.After. UUID сравниваются побайтово
+	ListEventsPage(ctx context.Context, query *models.PageQuery) ([]*models.Event, error)
+	// ListSeriesAndAllDay вернет то, что ListEvents, кроме разовых событий со временем начала:
+	// местное время этих событий зависит от пояса пользователя, поэтому их сортирует приложение
+	ListSeriesAndAllDay(ctx context.Context, user string, from, to time.Time) ([]*models.Event, error)
+	GetEvent(ctx context.Context, id string) (*models.Event, error)
+	// GetEventByExternalUID вернет событие пользователя, импортированное с этим iCalendar UID, или ErrNotFound
+	GetEventByExternalUID(ctx context.Context, user, uid string) (*models.Event, error)
+	// ListOverrides вернет перенесенные экземпляры серии
+	ListOverrides(ctx context.Context, seriesID string) ([]*models.Event, error)
 	CreateEvent(ctx context.Context, event *models.Event) (string, error)
 	UpdateEvent(ctx context.Context, id string, event *models.Event) error
 	DeleteEvent(ctx context.Context, id string) error
 
-	PopNotifications(ctx context.Context) ([]*models.Event, error)
+	// CancelOccurrence отменит один экземпляр серии (EXDATE)
+	CancelOccurrence(ctx context.Context, id string, occurrence time.Time) error
+	// OverrideOccurrence заменит один экземпляр серии отдельным событием
+	OverrideOccurrence(ctx context.Context, id string, occurrence time.Time, override *models.Event) (string, error)
+	// SplitSeries заменит правило серии на rrule и, если tail не nil, создаст его как продолжение серии.
+	// Исключения и замененные экземпляры серии начиная с at переходят к продолжению, а без него удаляются
+	SplitSeries(ctx context.Context, id string, at time.Time, rrule string, tail *models.Event) (string, error)
+
+	// GetUser вернет профиль пользователя или ErrNotFound
+	GetUser(ctx context.Context, name string) (*models.User, error)
+	SaveUser(ctx context.Context, user *models.User) error
+
+	// InTransaction выполнит fn атомарно: вызовы хранилища с контекстом, переданным в fn,
+	// читают и пишут в одной транзакции, изолированной от параллельных записей
+	InTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/egress"
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// maxNotifyBefore за сколько времени до события можно попросить напоминание
+const maxNotifyBefore = 365 * 24 * time.Hour
+
+var (
+	errEmpty       = errors.New("must not be empty")
+	errNotSet      = errors.New("must be set")
+	errNotPositive = errors.New("must be positive")
+	errNegative    = errors.New("must not be negative")
+	errTooEarly    = errors.New("must be at most a year")
+	errUnknown     = errors.New("unknown value")
+)
+
+// FieldError неверное значение одного поля. Field называется как в API, например notifyBefore
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+// ValidationError событие не прошло проверку, Fields перечисляет все неверные поля
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		parts = append(parts, field.Field+": "+field.Err.Error())
+	}
+	return "invalid event: " + strings.Join(parts, "; ")
+}
+
+// Is вернет true для ошибок отдельных полей, например models.ErrInvalidRRule
+func (e *ValidationError) Is(target error) bool {
+	for _, field := range e.Fields {
+		if errors.Is(field.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEvent проверит поля события перед записью и вернет ValidationError со всеми ошибками сразу
+func validateEvent(event *models.Event) error {
+	invalid := &ValidationError{}
+	check := func(field string, err error) {
+		if err != nil {
+			invalid.Fields = append(invalid.Fields, FieldError{Field: field, Err: err})
+		}
+	}
+
+	if strings.TrimSpace(event.Title) == "" {
+		check("title", errEmpty)
+	}
+	if event.StartAt.IsZero() {
+		check("startAt", errNotSet)
+	}
+	if event.Duration <= 0 {
+		check("duration", errNotPositive)
+	}
+	switch {
+	case event.NotifyBefore < 0:
+		check("notifyBefore", errNegative)
+	case event.NotifyBefore > maxNotifyBefore:
+		check("notifyBefore", errTooEarly)
+	}
+	if event.User == "" {
+		check("user", errEmpty)
+	}
+	if event.RRule != "" {
+		_, err := models.ParseRRule(event.RRule)
+		check("rrule", err)
+	}
+	switch event.Transparency {
+	case models.Opaque, models.Transparent:
+	default:
+		check("transparency", errUnknown)
+	}
+	switch event.Status {
+	case models.StatusConfirmed, models.StatusTentative, models.StatusCancelled:
+	default:
+		check("status", errUnknown)
+	}
+
+	if len(invalid.Fields) > 0 {
+		return invalid
+	}
+	return nil
+}
+
+// validateChannels проверит, что каналы напоминаний известны, а для выбранных задан адрес
+func validateChannels(profile *models.User, policy *egress.Policy) error {
+	if profile.Email != "" {
+		addr, err := mail.ParseAddress(profile.Email)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+		}
+		// адрес уходит в SMTP RCPT TO как есть, поэтому имя и скобки в нем недопустимы
+		if addr.Address != profile.Email {
+			return fmt.Errorf("%w: want a bare address like %s", ErrInvalidEmail, addr.Address)
+		}
+	}
+	if profile.WebhookURL != "" {
+		// на внутренние адреса webhook не отправляется, чтобы через него не обращались к сервисам в сети
+		if err := policy.CheckURL(profile.WebhookURL); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidWebhook, err)
+		}
+	}
+
+	for _, channel := range profile.Channels {
+		switch channel {
+		case models.ChannelFile:
+		case models.ChannelEmail:
+			if profile.Email == "" {
+				return fmt.Errorf("%w: email channel needs an address", ErrInvalidEmail)
+			}
+		case models.ChannelWebhook:
+			if profile.WebhookURL == "" {
+				return fmt.Errorf("%w: webhook channel needs an url", ErrInvalidWebhook)
+			}
+		default:
+			return fmt.Errorf("%w: %q", ErrInvalidChannel, channel)
+		}
+	}
+	return nil
+}
+
+// validateLocale проверит, что для языка есть шаблоны напоминаний
+func validateLocale(locale string) error {
+	switch locale {
+	case "", models.LocaleEnglish, models.LocaleRussian:
+		return nil
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidLocale, locale)
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+	mock "github.com/bobrovka/calendar/internal/storage/storage-mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_CreateEventValidation(t *testing.T) {
+	type testCase struct {
+		change    func(e *models.Event)
+		expFields []string
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["Blank title"] = testCase{
+		change:    func(e *models.Event) { e.Title = "  " },
+		expFields: []string{"title"},
+	}
+	testCases["Zero duration"] = testCase{
+		change:    func(e *models.Event) { e.Duration = 0 },
+		expFields: []string{"duration"},
+	}
+	testCases["Negative duration"] = testCase{
+		change:    func(e *models.Event) { e.Duration = -time.Hour },
+		expFields: []string{"duration"},
+	}
+	testCases["Notification more than a year before"] = testCase{
+		change:    func(e *models.Event) { e.NotifyBefore = 366 * 24 * time.Hour },
+		expFields: []string{"notifyBefore"},
+	}
+	testCases["Negative notification"] = testCase{
+		change:    func(e *models.Event) { e.NotifyBefore = -time.Minute },
+		expFields: []string{"notifyBefore"},
+	}
+	testCases["Unknown transparency"] = testCase{
+		change:    func(e *models.Event) { e.Transparency = models.Transparency(7) },
+		expFields: []string{"transparency"},
+	}
+	testCases["Unknown status"] = testCase{
+		change:    func(e *models.Event) { e.Status = -1 },
+		expFields: []string{"status"},
+	}
+	testCases["Everything wrong at once"] = testCase{
+		change: func(e *models.Event) {
+			*e = models.Event{RRule: "FREQ=SECONDLY"}
+		},
+		expFields: []string{"title", "startAt", "duration", "user", "rrule"},
+	}
+
+	for k, v := range testCases {
+		t.Run(k, func(t *testing.T) {
+			storage := &mock.StorageMock{}
+			app, err := NewCalendar(storage, nil)
+			assert.NoError(t, err)
+
+			event := &models.Event{
+				Title:        "standup",
+				StartAt:      time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC),
+				Duration:     15 * time.Minute,
+				User:         "Kira",
+				NotifyBefore: time.Hour,
+			}
+			v.change(event)
+
+			_, err = app.CreateNewEvent(context.Background(), event)
+			var invalid *ValidationError
+			if assert.True(t, errors.As(err, &invalid), "unexpected error %v", err) {
+				fields := make([]string, 0, len(invalid.Fields))
+				for _, field := range invalid.Fields {
+					fields = append(fields, field.Field)
+				}
+				assert.Equal(t, v.expFields, fields)
+			}
+
+			// до хранилища неверное событие не доходит
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestApp_ChangeEventValidation(t *testing.T) {
+	storage := &mock.StorageMock{}
+	app, err := NewCalendar(storage, nil)
+	assert.NoError(t, err)
+
+	err = app.ChangeEvent(context.Background(), "1", &models.Event{
+		Title:    "standup",
+		StartAt:  time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC),
+		Duration: -time.Hour,
+		User:     "Kira",
+	}, ScopeSeries, time.Time{})
+	assert.Equal(t, &ValidationError{Fields: []FieldError{{Field: "duration", Err: errNotPositive}}}, err)
+
+	storage.AssertExpectations(t)
+}
+
+func TestApp_UpdateProfileNotifications(t *testing.T) {
+	type testCase struct {
+		profile models.User
+		expErr  error
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["Default channel"] = testCase{
+		profile: models.User{},
+	}
+	testCases["All channels"] = testCase{
+		profile: models.User{
+			Email:      "kira@example.com",
+			WebhookURL: "https://example.com/hook",
+			Channels:   []models.Channel{models.ChannelEmail, models.ChannelWebhook, models.ChannelFile},
+		},
+	}
+	testCases["Unknown channel"] = testCase{
+		profile: models.User{Channels: []models.Channel{"pigeon"}},
+		expErr:  ErrInvalidChannel,
+	}
+	testCases["Email channel without address"] = testCase{
+		profile: models.User{Channels: []models.Channel{models.ChannelEmail}},
+		expErr:  ErrInvalidEmail,
+	}
+	testCases["Bad email"] = testCase{
+		profile: models.User{Email: "kira at example"},
+		expErr:  ErrInvalidEmail,
+	}
+	testCases["Email with display name"] = testCase{
+		profile: models.User{Email: "Kira <kira@example.com>"},
+		expErr:  ErrInvalidEmail,
+	}
+	testCases["Russian"] = testCase{
+		profile: models.User{Locale: models.LocaleRussian},
+	}
+	testCases["Unsupported locale"] = testCase{
+		profile: models.User{Locale: "de"},
+		expErr:  ErrInvalidLocale,
+	}
+	testCases["Webhook without scheme"] = testCase{
+		profile: models.User{WebhookURL: "example.com/hook", Channels: []models.Channel{models.ChannelWebhook}},
+		expErr:  ErrInvalidWebhook,
+	}
+	testCases["Webhook to cloud metadata"] = testCase{
+		profile: models.User{WebhookURL: "http://169.254.169.254/latest/meta-data", Channels: []models.Channel{models.ChannelWebhook}},
+		expErr:  ErrInvalidWebhook,
+	}
+	testCases["Webhook to localhost"] = testCase{
+		profile: models.User{WebhookURL: "http://localhost:8080/hook", Channels: []models.Channel{models.ChannelWebhook}},
+		expErr:  ErrInvalidWebhook,
+	}
+
+	for k, v := range testCases {
+		t.Run(k, func(t *testing.T) {
+			storage := &mock.StorageMock{}
+			app, err := NewCalendar(storage, nil)
+			assert.NoError(t, err)
+
+			profile := v.profile
+			profile.Name = "Kira"
+			profile.Location = time.UTC
+			if v.expErr == nil {
+				storage.On("SaveUser", context.Background(), &profile).Return(nil)
+			}
+
+			err = app.UpdateProfile(context.Background(), &profile)
+			assert.True(t, errors.Is(err, v.expErr), "unexpected error %v", err)
+			storage.AssertExpectations(t)
+		})
+	}
+}
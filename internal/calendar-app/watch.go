@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// EventUpdate сообщение WatchEvents: снимок событий окна или изменение одного события
+type EventUpdate struct {
+	// Change изменение, nil у снимка
+	Change *models.Change
+	// Events экземпляры в окне со временем в часовом поясе пользователя: у снимка все,
+	// у изменения только экземпляры измененного события. Они заменяют прежние экземпляры этого события,
+	// у удаленного или ушедшего из окна события пусто
+	Events []*models.Event
+}
+
+// WatchEvents передаст в send снимок событий пользователя в [from, to), а затем изменения событий,
+// пока не отменен ctx или send не вернет ошибку. Подписка оформляется до снимка, поэтому изменения
+// между ними не теряются. Если подписчик отстал от изменений, вернет ErrWatchLagging
+func (a *Calendar) WatchEvents(ctx context.Context, user string, from, to time.Time, send func(*EventUpdate) error) error {
+	if err := authorize(ctx, user); err != nil {
+		return err
+	}
+	if err := validateSearchInterval(from, to); err != nil {
+		return err
+	}
+
+	profile, err := a.getProfile(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	changes, cancel := a.changes.Subscribe(user)
+	defer cancel()
+
+	snapshot, err := a.listEvents(ctx, profile, from, to)
+	if err != nil {
+		return err
+	}
+	if err := send(&EventUpdate{Events: snapshot}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case change, ok := <-changes:
+			if !ok {
+				return ErrWatchLagging
+			}
+
+			update, err := a.eventUpdate(ctx, profile, change, from, to)
+			if err != nil {
+				return err
+			}
+			// созданное за пределами окна событие подписчику не интересно
+			if update.Change.Kind == models.ChangeCreated && len(update.Events) == 0 {
+				continue
+			}
+			if err := send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// eventUpdate прочитает актуальное состояние измененного события
+func (a *Calendar) eventUpdate(ctx context.Context, profile *models.User, change *models.Change, from, to time.Time) (*EventUpdate, error) {
+	if change.Kind == models.ChangeDeleted {
+		return &EventUpdate{Change: change}, nil
+	}
+
+	event, err := a.storage.GetEvent(ctx, change.UUID)
+	if err == ErrNotFound {
+		// событие удалили, пока изменение шло к подписчику, об удалении придет свое изменение
+		return &EventUpdate{Change: &models.Change{Kind: models.ChangeDeleted, User: change.User, UUID: change.UUID}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := expandEvents(inLocation(profile.Location, event), from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &EventUpdate{Change: change, Events: events}, nil
+}
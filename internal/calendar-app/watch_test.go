@@ -0,0 +1,110 @@
+package app_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_WatchEvents(t *testing.T) {
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	day := time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC)
+	event := func(title string, start time.Time) *models.Event {
+		return &models.Event{Title: title, StartAt: start, Duration: time.Hour, User: "Kira"}
+	}
+	existing, err := calendar.CreateNewEvent(ctx, event("existing", day.Add(9*time.Hour)))
+	assert.NoError(t, err)
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	updates := make(chan *app.EventUpdate, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- calendar.WatchEvents(watchCtx, "Kira", day, day.AddDate(0, 0, 1), func(update *app.EventUpdate) error {
+			updates <- update
+			return nil
+		})
+	}()
+
+	next := func() *app.EventUpdate {
+		select {
+		case update := <-updates:
+			return update
+		case <-time.After(time.Second):
+			t.Fatal("no update")
+			return nil
+		}
+	}
+
+	snapshot := next()
+	assert.Nil(t, snapshot.Change)
+	assert.Equal(t, []string{"existing"}, titles(snapshot.Events))
+
+	// вне окна, подписчику не приходит
+	_, err = calendar.CreateNewEvent(ctx, event("tomorrow", day.AddDate(0, 0, 1).Add(9*time.Hour)))
+	assert.NoError(t, err)
+
+	created, err := calendar.CreateNewEvent(ctx, event("review", day.Add(11*time.Hour)))
+	assert.NoError(t, err)
+	update := next()
+	assert.Equal(t, &models.Change{Kind: models.ChangeCreated, User: "Kira", UUID: created}, update.Change)
+	assert.Equal(t, []string{"review"}, titles(update.Events))
+
+	// событие ушло из окна: экземпляров нет, но подписчик должен его убрать
+	assert.NoError(t, calendar.ChangeEvent(ctx, existing, event("moved", day.AddDate(0, 0, 2)), app.ScopeSeries, time.Time{}))
+	update = next()
+	assert.Equal(t, models.ChangeUpdated, update.Change.Kind)
+	assert.Equal(t, existing, update.Change.UUID)
+	assert.Empty(t, update.Events)
+
+	assert.NoError(t, calendar.RemoveEvent(ctx, created, app.ScopeSeries, time.Time{}))
+	update = next()
+	assert.Equal(t, &models.Change{Kind: models.ChangeDeleted, User: "Kira", UUID: created}, update.Change)
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestCalendar_WatchEventsOversizedWindow(t *testing.T) {
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+
+	from := time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC)
+	err = calendar.WatchEvents(context.Background(), "Kira", from, from.AddDate(100, 0, 0), func(*app.EventUpdate) error {
+		t.Fatal("nothing must be sent")
+		return nil
+	})
+	assert.Equal(t, app.ErrIntervalTooLong, err)
+}
+
+func TestBus_DisconnectsLaggingSubscriber(t *testing.T) {
+	bus := app.NewBus()
+	lagging, _ := bus.Subscribe("Kira")
+	other, cancel := bus.Subscribe("Lena")
+	defer cancel()
+
+	for i := 0; i < 100; i++ {
+		bus.Publish(context.Background(), &models.Change{User: "Kira", UUID: "1"})
+	}
+
+	received := 0
+	for range lagging {
+		received++
+	}
+	assert.True(t, received > 0 && received < 100)
+	assert.Len(t, other, 0)
+
+	bus.Close()
+	_, ok := <-other
+	assert.False(t, ok)
+	closed, _ := bus.Subscribe("Kira")
+	_, ok = <-closed
+	assert.False(t, ok)
+}
@@ -1,18 +1,47 @@
 package internal
 
+import "fmt"
+
 // Config базовый конфиг приложения
 type Config struct {
-	HTTPListen     string `config:"host"`             // ip и port на котором должен слушать web-сервер
-	LogFile        string `config:"logfile,required"` // путь к файлу логов
-	LogFileSender  string
-	LogLevel       string `config:"loglevel"` // уровень логирования (error / warn / info / debug)
-	PgName         string
-	PgHost         string
-	PgPort         int
-	PgUser         string
-	PgPassword     string
-	RabbitHost     string
-	RabbitPort     int
-	RabbitUser     string
-	RabbitPassword string
+	HTTPListen            string `config:"host"`             // ip и port на котором должен слушать web-сервер
+	LogFile               string `config:"logfile,required"` // путь к файлу логов
+	LogFileSender         string
+	SenderMaxAttempts     int    // сколько раз рассыльщик пробует доставить напоминание, прежде чем отправить его в очередь недоставленных
+	NotificationFile      string // файл, в который рассыльщик дописывает напоминания канала file
+	NotificationTemplates string // каталог с шаблонами <канал>.<язык>.<часть>.tmpl, заменяющими встроенные
+	SMTPAddr              string // host:port SMTP-сервера для канала email, пусто - канал не настроен
+	SMTPFrom              string
+	SMTPUser              string // без пользователя письма отправляются без аутентификации
+	SMTPPassword          string
+	WebhookAllowedHosts   []string // внутренние хосты, IP и подсети CIDR, на которые можно отправлять webhook
+	LogLevel              string   `config:"loglevel"` // уровень логирования (error / warn / info / debug)
+	PgName                string
+	PgHost                string
+	PgPort                int
+	PgUser                string
+	PgPassword            string
+	RabbitHost            string
+	RabbitPort            int
+	RabbitUser            string
+	RabbitPassword        string
+	WebListen             string // ip и port ленты iCalendar, CalDAV и REST API, пусто - не запускать
+	AuthJWTKey            string // ключ HS256 для проверки JWT
+	AuthTokenFile         string // файл со статическими токенами "<user> <token>"; без ключа и файла аутентификация выключена
+}
+
+// redacted чем заменяются секреты при печати конфига
+const redacted = "***"
+
+// String напечатает конфиг без паролей и ключей, чтобы они не попали в логи
+func (c Config) String() string {
+	// у plain нет метода String, иначе fmt вызвал бы его рекурсивно
+	type plain Config
+	p := plain(c)
+	for _, secret := range []*string{&p.PgPassword, &p.RabbitPassword, &p.SMTPPassword, &p.AuthJWTKey} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return fmt.Sprintf("%+v", p)
 }
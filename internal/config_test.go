@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_String(t *testing.T) {
+	cfg := &Config{
+		LogFile:      "calendar.log",
+		PgUser:       "calendar",
+		PgPassword:   "pg-secret",
+		AuthJWTKey:   "jwt-secret",
+		SMTPUser:     "calendar",
+		SMTPPassword: "smtp-secret",
+	}
+
+	printed := fmt.Sprint(cfg)
+	assert.Contains(t, printed, "PgUser:calendar")
+	assert.Contains(t, printed, "PgPassword:***")
+	assert.Contains(t, printed, "RabbitPassword: ")
+	assert.Contains(t, printed, "SMTPPassword:***")
+	assert.NotContains(t, printed, "secret")
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/bobrovka/calendar/internal/queue"
 	"github.com/cenkalti/backoff/v3"
 
 	"github.com/streadway/amqp"
@@ -15,9 +16,15 @@ import (
 
 // Consumer ...
 type Consumer struct {
-	conn         *amqp.Connection
-	channel      *amqp.Channel
-	done         chan error
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	// publisher канал с подтверждениями, через который сообщения перекладываются в очереди повторов
+	// и недоставленных. mu публикации в нем идут по одной
+	mu           sync.Mutex
+	publisher    *amqp.Channel
+	confirms     chan amqp.Confirmation
+	deliveryTag  uint64
+	done         chan error // закрытие соединения для Handle, буфер не дает watch зависнуть после выхода из него
 	consumerTag  string
 	uri          string
 	exchangeName string
@@ -34,7 +41,7 @@ func NewConsumer(consumerTag, uri, exchangeName, exchangeType, queue, bindingKey
 		exchangeType: exchangeType,
 		queue:        queue,
 		bindingKey:   bindingKey,
-		done:         make(chan error),
+		done:         make(chan error, 1),
 	}
 }
 
@@ -63,6 +70,7 @@ func (c *Consumer) reConnect() (<-chan amqp.Delivery, error) {
 				fmt.Printf("Couldn't connect: %+v", err)
 				continue
 			}
+			c.watch()
 
 			return msgs, nil
 		}
@@ -78,17 +86,6 @@ func (c *Consumer) connect() error {
 		return fmt.Errorf("Dial: %s", err)
 	}
 
-	go func() {
-		errMQ := <-c.conn.NotifyClose(make(chan *amqp.Error))
-		log.Printf("closing: %s", errMQ)
-		if errMQ != nil {
-			// Понимаем, что канал сообщений закрыт, надо пересоздать соединение.
-			c.done <- errors.New("Channel Closed")
-		} else {
-			c.done <- nil
-		}
-	}()
-
 	c.channel, err = c.conn.Channel()
 	if err != nil {
 		return fmt.Errorf("Channel: %s", err)
@@ -106,22 +103,42 @@ func (c *Consumer) connect() error {
 		return fmt.Errorf("Exchange Declare: %s", err)
 	}
 
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.publisher, err = c.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("Channel: %s", err)
+	}
+	if err = c.publisher.Confirm(false); err != nil {
+		return fmt.Errorf("Confirm: %s", err)
+	}
+	c.confirms = c.publisher.NotifyPublish(make(chan amqp.Confirmation, notifyBuffer))
+	c.deliveryTag = 0
+
 	return nil
 }
 
-// Задекларировать очередь, которую будем слушать.
-func (c *Consumer) announceQueue() (<-chan amqp.Delivery, error) {
-	queue, err := c.channel.QueueDeclare(
-		c.queue,
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+// watch сообщит в c.done о закрытии соединения. Нужен только Handle, который по нему переподключается,
+// разовые DeadLetters и Replay его не запускают
+func (c *Consumer) watch() {
+	closed := c.conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		errMQ := <-closed
+		log.Printf("closing: %s", errMQ)
+		if errMQ != nil {
+			// Понимаем, что канал сообщений закрыт, надо пересоздать соединение.
+			c.done <- errors.New("Channel Closed")
+		} else {
+			c.done <- nil
+		}
+	}()
+}
 
+// Задекларировать очередь, которую будем слушать, вместе с очередями повторов и недоставленных.
+func (c *Consumer) announceQueue() (<-chan amqp.Delivery, error) {
+	queue, err := c.declareQueues()
 	if err != nil {
-		return nil, fmt.Errorf("Queue Declare: %s", err)
+		return nil, err
 	}
 
 	// Число сообщений, которые можно подтвердить за раз.
@@ -130,17 +147,6 @@ func (c *Consumer) announceQueue() (<-chan amqp.Delivery, error) {
 		return nil, fmt.Errorf("Error setting qos: %s", err)
 	}
 
-	// Создаём биндинг (правило маршрутизации).
-	if err = c.channel.QueueBind(
-		queue.Name,
-		c.bindingKey,
-		c.exchangeName,
-		false,
-		nil,
-	); err != nil {
-		return nil, fmt.Errorf("Queue Bind: %s", err)
-	}
-
 	msgs, err := c.channel.Consume(
 		queue.Name,
 		c.consumerTag,
@@ -173,6 +179,7 @@ func (c *Consumer) Handle(ctx context.Context, wg *sync.WaitGroup, fn func(<-cha
 	if err != nil {
 		return fmt.Errorf("Error: %v", err)
 	}
+	c.watch()
 
 	for {
 		for i := 0; i < threads; i++ {
@@ -201,8 +208,34 @@ func (c *Consumer) Handle(ctx context.Context, wg *sync.WaitGroup, fn func(<-cha
 	}
 }
 
+// Subscribe ...
+func (c *Consumer) Subscribe(ctx context.Context, workers int, handler queue.Handler) error {
+	wg := &sync.WaitGroup{}
+	err := c.Handle(ctx, wg, func(msgs <-chan amqp.Delivery) {
+		for {
+			select {
+			case msg, ok := <-msgs:
+				// если закроется канал, завершить обработчик
+				if !ok {
+					return
+				}
+				handler(ctx, &delivery{consumer: c, msg: msg})
+			case <-ctx.Done():
+				// если завершается программа, завершить обработчик
+				return
+			}
+		}
+	}, workers)
+	wg.Wait()
+	return err
+}
+
 func (c *Consumer) gracefulStop() error {
-	err := c.channel.Close()
+	err := c.publisher.Close()
+	if err != nil {
+		return err
+	}
+	err = c.channel.Close()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,281 @@
+package consumer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/queue"
+	"github.com/streadway/amqp"
+)
+
+const (
+	// confirmTimeout сколько ждать подтверждения публикации от брокера
+	confirmTimeout = 5 * time.Second
+	// notifyBuffer запас для подтверждений, пришедших после таймаута
+	notifyBuffer = 64
+	// attemptHeader заголовок с номером попытки доставки
+	attemptHeader = "x-attempt"
+	// reasonHeader заголовок с причиной, по которой сообщение попало в очередь недоставленных
+	reasonHeader = "x-dead-reason"
+	// doneHeader заголовок с частями обработки, выполненными в прошлых попытках, через запятую
+	doneHeader = "x-done"
+)
+
+// retryDelays сколько сообщение ждет в очереди повторов перед очередной попыткой.
+// Сообщения после последней ступени ждут столько же, сколько на ней
+var retryDelays = []time.Duration{10 * time.Second, time.Minute, 10 * time.Minute, time.Hour}
+
+var (
+	// ErrNacked брокер не смог принять сообщение
+	ErrNacked = errors.New("message is nacked by broker")
+	// ErrConfirmTimeout подтверждение не пришло за confirmTimeout
+	ErrConfirmTimeout = errors.New("publish confirmation timed out")
+	// ErrChannelClosed канал закрылся, не дождавшись подтверждения
+	ErrChannelClosed = errors.New("channel closed before confirmation")
+)
+
+func (c *Consumer) retryExchange() string {
+	return c.exchangeName + ".retry"
+}
+
+// retryQueue имя очереди повторов i-й ступени, оно же ключ маршрутизации в нее
+func (c *Consumer) retryQueue(i int) string {
+	return c.queue + ".retry." + strconv.Itoa(i+1)
+}
+
+func (c *Consumer) deadExchange() string {
+	return c.exchangeName + ".dead"
+}
+
+func (c *Consumer) deadQueue() string {
+	return c.queue + ".dead"
+}
+
+// declareQueues задекларирует основную очередь, очереди повторов и очередь недоставленных.
+// Сообщение в очереди повторов лежит ее TTL и возвращается в основной обменник.
+// Основная очередь декларируется с прежними аргументами, иначе RabbitMQ откажет в декларации
+// уже существующей очереди, поэтому отклоненное в ней сообщение уходит в очередь недоставленных
+// только по политике dead-letter (make rabbit.policy)
+func (c *Consumer) declareQueues() (amqp.Queue, error) {
+	var main amqp.Queue
+
+	for _, exchange := range []string{c.retryExchange(), c.deadExchange()} {
+		if err := c.channel.ExchangeDeclare(exchange, "direct", true, false, false, false, nil); err != nil {
+			return main, fmt.Errorf("Exchange Declare: %s", err)
+		}
+	}
+
+	if _, err := c.channel.QueueDeclare(c.deadQueue(), true, false, false, false, nil); err != nil {
+		return main, fmt.Errorf("Queue Declare: %s", err)
+	}
+	if err := c.channel.QueueBind(c.deadQueue(), c.bindingKey, c.deadExchange(), false, nil); err != nil {
+		return main, fmt.Errorf("Queue Bind: %s", err)
+	}
+
+	for i, delay := range retryDelays {
+		name := c.retryQueue(i)
+		if _, err := c.channel.QueueDeclare(name, true, false, false, false, amqp.Table{
+			"x-message-ttl":             int64(delay / time.Millisecond),
+			"x-dead-letter-exchange":    c.exchangeName,
+			"x-dead-letter-routing-key": c.bindingKey,
+		}); err != nil {
+			return main, fmt.Errorf("Queue Declare: %s", err)
+		}
+		if err := c.channel.QueueBind(name, name, c.retryExchange(), false, nil); err != nil {
+			return main, fmt.Errorf("Queue Bind: %s", err)
+		}
+	}
+
+	main, err := c.channel.QueueDeclare(c.queue, true, false, false, false, nil)
+	if err != nil {
+		return main, fmt.Errorf("Queue Declare: %s", err)
+	}
+
+	// Создаём биндинг (правило маршрутизации).
+	if err = c.channel.QueueBind(main.Name, c.bindingKey, c.exchangeName, false, nil); err != nil {
+		return main, fmt.Errorf("Queue Bind: %s", err)
+	}
+
+	return main, nil
+}
+
+// republish опубликует копию сообщения с заголовками headers и дождется подтверждения брокера
+func (c *Consumer) republish(exchange, key string, msg *amqp.Delivery, headers amqp.Table) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := c.publisher.Publish(exchange, key, false, false, amqp.Publishing{
+		Headers:      headers,
+		ContentType:  msg.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.MessageId,
+		Body:         msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	c.deliveryTag++
+	timer := time.NewTimer(confirmTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case confirmation, ok := <-c.confirms:
+			if !ok {
+				return ErrChannelClosed
+			}
+			// запоздавшее подтверждение публикации, которая уже завершилась по таймауту
+			if confirmation.DeliveryTag < c.deliveryTag {
+				continue
+			}
+			if !confirmation.Ack {
+				return ErrNacked
+			}
+			return nil
+		case <-timer.C:
+			return ErrConfirmTimeout
+		}
+	}
+}
+
+// DeadLetters ...
+func (c *Consumer) DeadLetters(ctx context.Context, limit int) ([]*queue.DeadLetter, error) {
+	if err := c.connect(); err != nil {
+		return nil, fmt.Errorf("Error: %v", err)
+	}
+	// неподтвержденные сообщения вернутся в очередь при закрытии канала
+	defer c.gracefulStop()
+
+	if _, err := c.declareQueues(); err != nil {
+		return nil, err
+	}
+
+	var result []*queue.DeadLetter
+	for len(result) < limit && ctx.Err() == nil {
+		msg, ok, err := c.channel.Get(c.deadQueue(), false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		reason, _ := msg.Headers[reasonHeader].(string)
+		result = append(result, &queue.DeadLetter{Body: msg.Body, Attempts: attempt(msg.Headers), Reason: reason})
+	}
+	return result, ctx.Err()
+}
+
+// Replay ...
+func (c *Consumer) Replay(ctx context.Context, limit int) (int, error) {
+	if err := c.connect(); err != nil {
+		return 0, fmt.Errorf("Error: %v", err)
+	}
+	defer c.gracefulStop()
+
+	if _, err := c.declareQueues(); err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for ; replayed < limit && ctx.Err() == nil; replayed++ {
+		msg, ok, err := c.channel.Get(c.deadQueue(), false)
+		if err != nil {
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+		if err := c.republish(c.exchangeName, c.bindingKey, &msg, nil); err != nil {
+			return replayed, err
+		}
+		if err := msg.Ack(false); err != nil {
+			return replayed, err
+		}
+	}
+	return replayed, ctx.Err()
+}
+
+// attempt номер попытки доставки из заголовков, без заголовка это первая попытка
+func attempt(headers amqp.Table) int {
+	switch n := headers[attemptHeader].(type) {
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	}
+	return 1
+}
+
+// delivery сообщение RabbitMQ, подтверждается по одному
+type delivery struct {
+	consumer *Consumer
+	msg      amqp.Delivery
+}
+
+// Body ...
+func (d *delivery) Body() []byte {
+	return d.msg.Body
+}
+
+// Attempt ...
+func (d *delivery) Attempt() int {
+	return attempt(d.msg.Headers)
+}
+
+// Done ...
+func (d *delivery) Done() []string {
+	done, _ := d.msg.Headers[doneHeader].(string)
+	if done == "" {
+		return nil
+	}
+	return strings.Split(done, ",")
+}
+
+// Ack ...
+func (d *delivery) Ack() error {
+	return d.msg.Ack(false)
+}
+
+// Retry ...
+func (d *delivery) Retry(done []string) error {
+	n := d.Attempt()
+	step := n - 1
+	if step >= len(retryDelays) {
+		step = len(retryDelays) - 1
+	}
+
+	headers := amqp.Table{attemptHeader: int64(n + 1)}
+	if len(done) > 0 {
+		headers[doneHeader] = strings.Join(done, ",")
+	}
+	err := d.consumer.republish(d.consumer.retryExchange(), d.consumer.retryQueue(step), &d.msg, headers)
+	if err != nil {
+		// без очереди повторов сообщение уходит в очередь недоставленных, откуда его можно вернуть Replay.
+		// Возврат в основную очередь повторялся бы сразу и без счета попыток
+		if deadErr := d.DeadLetter(fmt.Sprintf("cannot schedule retry %d: %v", n+1, err)); deadErr != nil {
+			return fmt.Errorf("%v, dead letter: %v", err, deadErr)
+		}
+		return err
+	}
+	return d.msg.Ack(false)
+}
+
+// DeadLetter ...
+func (d *delivery) DeadLetter(reason string) error {
+	headers := amqp.Table{attemptHeader: int64(d.Attempt()), reasonHeader: reason}
+	err := d.consumer.republish(d.consumer.deadExchange(), d.consumer.bindingKey, &d.msg, headers)
+	if err != nil {
+		// по политике dead-letter основная очередь сама переложит отклоненное сообщение
+		// в очередь недоставленных, только без причины
+		if rejectErr := d.msg.Reject(false); rejectErr != nil {
+			return fmt.Errorf("%v, reject: %v", err, rejectErr)
+		}
+		return err
+	}
+	return d.msg.Ack(false)
+}
@@ -0,0 +1,121 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrForbiddenHost адрес внутри сети сервиса: loopback, link-local или частная подсеть
+var ErrForbiddenHost = errors.New("internal address is not allowed")
+
+// Policy решает, на какие адреса сервис может отправлять запросы пользователей, например webhook.
+// Внутренние адреса запрещены, кроме явно разрешенных в конфиге, иначе через webhook можно
+// обратиться к сервисам внутри сети
+type Policy struct {
+	hosts map[string]bool
+	nets  []*net.IPNet
+}
+
+// NewPolicy создает политику, разрешающую внутренние адреса из allowed: имена хостов, IP или подсети CIDR
+func NewPolicy(allowed []string) (*Policy, error) {
+	p := &Policy{hosts: make(map[string]bool)}
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("bad allowed subnet %q: %w", entry, err)
+			}
+			p.nets = append(p.nets, ipNet)
+			continue
+		}
+		p.hosts[entry] = true
+	}
+	return p, nil
+}
+
+// CheckURL проверит адрес до сохранения: http или https и хост не внутренний.
+// Имя хоста здесь не разрешается, так как позже оно может указать на другой адрес, поэтому
+// при отправке адрес проверяет еще и Dialer
+func (p *Policy) CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("url must be absolute http or https: %q", raw)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if p.hostAllowed(host) {
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenHost, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !p.ipAllowed(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenHost, host)
+	}
+	return nil
+}
+
+// DialContext соединяется как dialer, но отказывает, если хост разрешился во внутренний адрес.
+// Адрес проверяется после разрешения имени, поэтому подмена DNS после проверки URL не помогает
+func (p *Policy) DialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if p.hostAllowed(strings.ToLower(host)) {
+			return dialer.DialContext(ctx, network, address)
+		}
+
+		checked := *dialer
+		checked.Control = func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !p.ipAllowed(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenHost, host)
+			}
+			if dialer.Control != nil {
+				return dialer.Control(network, address, c)
+			}
+			return nil
+		}
+		return checked.DialContext(ctx, network, address)
+	}
+}
+
+func (p *Policy) hostAllowed(host string) bool {
+	return p.hosts[strings.TrimSuffix(host, ".")]
+}
+
+// ipAllowed вернет true для внешнего адреса или внутреннего из разрешенных
+func (p *Policy) ipAllowed(ip net.IP) bool {
+	if p.hosts[ip.String()] {
+		return true
+	}
+	for _, ipNet := range p.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return !internal(ip)
+}
+
+// internal вернет true для адресов, ведущих внутрь сети сервиса
+func internal(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsUnspecified() || ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
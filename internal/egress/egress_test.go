@@ -0,0 +1,68 @@
+package egress_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bobrovka/calendar/internal/egress"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_CheckURL(t *testing.T) {
+	policy, err := egress.NewPolicy([]string{"hooks.internal", "10.1.0.0/16"})
+	assert.NoError(t, err)
+
+	for _, raw := range []string{
+		"https://example.com/hook",
+		"http://93.184.216.34:8080/hook",
+		"http://hooks.internal/hook",
+		"http://10.1.2.3/hook",
+	} {
+		assert.NoError(t, policy.CheckURL(raw), raw)
+	}
+
+	for _, raw := range []string{
+		"http://localhost/hook",
+		"http://api.localhost/hook",
+		"http://127.0.0.1:9000/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.2.0.1/hook",
+		"http://172.16.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://0.0.0.0/hook",
+	} {
+		err := policy.CheckURL(raw)
+		assert.True(t, errors.Is(err, egress.ErrForbiddenHost), "%s: %v", raw, err)
+	}
+
+	assert.Error(t, policy.CheckURL("ftp://example.com/hook"))
+	assert.Error(t, policy.CheckURL("example.com/hook"))
+
+	_, err = egress.NewPolicy([]string{"10.0.0.0/99"})
+	assert.Error(t, err)
+}
+
+func TestPolicy_DialContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	address := server.Listener.Addr().String()
+
+	blocked, err := egress.NewPolicy(nil)
+	assert.NoError(t, err)
+	// имя проходит проверку URL, но разрешается в loopback
+	_, port, _ := net.SplitHostPort(address)
+	_, err = blocked.DialContext(&net.Dialer{})(context.Background(), "tcp", net.JoinHostPort("localhost", port))
+	assert.True(t, errors.Is(err, egress.ErrForbiddenHost), "unexpected error %v", err)
+
+	allowed, err := egress.NewPolicy([]string{"127.0.0.0/8"})
+	assert.NoError(t, err)
+	conn, err := allowed.DialContext(&net.Dialer{})(context.Background(), "tcp", address)
+	if assert.NoError(t, err) {
+		conn.Close()
+	}
+}
@@ -0,0 +1,135 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bobrovka/calendar/pkg/calendar/api"
+	"github.com/golang/protobuf/descriptor"
+	"github.com/golang/protobuf/proto"
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/golang/protobuf/ptypes/empty"
+)
+
+const (
+	timestampType = ".google.protobuf.Timestamp"
+	durationType  = ".google.protobuf.Duration"
+	emptyType     = ".google.protobuf.Empty"
+)
+
+// emptyResponse ответ методов, возвращающих google.protobuf.Empty
+var emptyResponse = &empty.Empty{}
+
+// apiFile описание api.proto, зашитое в сгенерированный код: из него берутся
+// типы параметров запросов и схемы OpenAPI, поэтому они не расходятся с proto
+var apiFile, _ = descriptor.ForMessage(&api.Event{})
+
+// messageDescriptor описание сообщения api.proto или google.protobuf.Empty
+func messageDescriptor(message proto.Message) *pb.DescriptorProto {
+	_, md := descriptor.ForMessage(message.(descriptor.Message))
+	return md
+}
+
+// typeName полное имя типа для сообщения, как в полях type_name
+func typeName(message proto.Message) string {
+	return "." + proto.MessageName(message)
+}
+
+// findMessage найдет сообщение api.proto по полному имени, например .Event
+func findMessage(name string) *pb.DescriptorProto {
+	for _, md := range apiFile.GetMessageType() {
+		if "."+md.GetName() == name {
+			return md
+		}
+	}
+	return nil
+}
+
+// findEnum найдет перечисление api.proto по полному имени
+func findEnum(name string) *pb.EnumDescriptorProto {
+	for _, ed := range apiFile.GetEnumType() {
+		if "."+ed.GetName() == name {
+			return ed
+		}
+	}
+	return nil
+}
+
+func findField(md *pb.DescriptorProto, name string) *pb.FieldDescriptorProto {
+	for _, field := range md.GetField() {
+		if field.GetName() == name || field.GetJsonName() == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// setParam запишет параметр пути или query с именем вида event.user в JSON-объект запроса
+func setParam(fields map[string]interface{}, md *pb.DescriptorProto, name, value string) error {
+	parts := strings.Split(name, ".")
+	node := fields
+	for i, part := range parts {
+		field := findField(md, part)
+		if field == nil {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+		if i == len(parts)-1 {
+			v, err := paramValue(field, value)
+			if err != nil {
+				return fmt.Errorf("parameter %q: %w", name, err)
+			}
+			node[part] = v
+			return nil
+		}
+
+		if md = findMessage(field.GetTypeName()); md == nil || isRepeated(field) {
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+		child, ok := node[part].(map[string]interface{})
+		if !ok {
+			if node[part] != nil {
+				return fmt.Errorf("parameter %q: %s must be an object", name, part)
+			}
+			child = make(map[string]interface{})
+			node[part] = child
+		}
+		node = child
+	}
+	return nil
+}
+
+// paramValue переведет строку из URL в JSON-значение поля
+func paramValue(field *pb.FieldDescriptorProto, value string) (interface{}, error) {
+	if isRepeated(field) {
+		return nil, fmt.Errorf("repeated fields can not be set from the URL")
+	}
+
+	switch field.GetType() {
+	case pb.FieldDescriptorProto_TYPE_STRING:
+		return value, nil
+	case pb.FieldDescriptorProto_TYPE_BOOL:
+		return strconv.ParseBool(value)
+	case pb.FieldDescriptorProto_TYPE_ENUM:
+		// period=week так же, как period=WEEK
+		return strings.ToUpper(value), nil
+	case pb.FieldDescriptorProto_TYPE_MESSAGE:
+		switch field.GetTypeName() {
+		case timestampType, durationType:
+			return value, nil
+		}
+	case pb.FieldDescriptorProto_TYPE_BYTES, pb.FieldDescriptorProto_TYPE_GROUP:
+	default:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("%q is not a number", value)
+		}
+		return json.Number(value), nil
+	}
+	kind := strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+	return nil, fmt.Errorf("%s fields can not be set from the URL", kind)
+}
+
+func isRepeated(field *pb.FieldDescriptorProto) bool {
+	return field.GetLabel() == pb.FieldDescriptorProto_LABEL_REPEATED
+}
@@ -0,0 +1,317 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bobrovka/calendar/pkg/calendar/api"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"go.uber.org/zap"
+	// error details of the service must be registered to be rendered in JSON
+	_ "google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Prefix общий префикс путей REST API
+const Prefix = "/v1/"
+
+// specPath путь, по которому отдается OpenAPI-описание
+const specPath = Prefix + "openapi.json"
+
+// maxBodySize ограничение на размер тела запроса
+const maxBodySize = 1 << 20
+
+// route HTTP-маршрут к методу gRPC-сервиса Events. Параметры пути вида {event.user}
+// записываются в поле запроса с тем же именем, тело - в поле body ("*" - весь запрос),
+// остальные скалярные поля запроса можно передать в query
+type route struct {
+	method   string
+	pattern  string
+	rpc      string
+	summary  string
+	body     string
+	request  func() proto.Message
+	response proto.Message
+	call     func(ctx context.Context, client api.EventsClient, request proto.Message) (proto.Message, error)
+}
+
+var routes = []route{
+	{
+		method:   http.MethodGet,
+		pattern:  "/v1/users/{user}/events",
+		rpc:      "ListEvents",
+		summary:  "List events of the day, week or month containing date, in the user's time zone",
+		request:  func() proto.Message { return &api.ListRequest{} },
+		response: &api.ListResponse{},
+		call: func(ctx context.Context, client api.EventsClient, request proto.Message) (proto.Message, error) {
+			return client.ListEvents(ctx, request.(*api.ListRequest))
+		},
+	},
+	{
+		method:   http.MethodGet,
+		pattern:  "/v1/users/{user}/events:range",
+		rpc:      "ListEventsRange",
+		summary:  "List events overlapping [from, to) page by page",
+		request:  func() proto.Message { return &api.ListRangeRequest{} },
+		response: &api.ListRangeResponse{},
+		call: func(ctx context.Context, client api.EventsClient, request proto.Message) (proto.Message, error) {
+			return client.ListEventsRange(ctx, request.(*api.ListRangeRequest))
+		},
+	},
+	{
+		method:   http.MethodPost,
+		pattern:  "/v1/users/{event.user}/events",
+		rpc:      "CreateEvent",
+		summary:  "Create an event",
+		body:     "event",
+		request:  func() proto.Message { return &api.CreateRequest{} },
+		response: &api.CreateResponse{},
+		call: func(ctx context.Context, client api.EventsClient, request proto.Message) (proto.Message, error) {
+			return client.CreateEvent(ctx, request.(*api.CreateRequest))
+		},
+	},
+	{
+		method:   http.MethodPut,
+		pattern:  "/v1/events/{uuid}",
+		rpc:      "UpdateEvent",
+		summary:  "Update an event, a whole series or a part of it",
+		body:     "*",
+		request:  func() proto.Message { return &api.UpdateRequest{} },
+		response: emptyResponse,
+		call: func(ctx context.Context, client api.EventsClient, request proto.Message) (proto.Message, error) {
+			return client.UpdateEvent(ctx, request.(*api.UpdateRequest))
+		},
+	},
+	{
+		method:   http.MethodDelete,
+		pattern:  "/v1/events/{uuid}",
+		rpc:      "DeleteEvent",
+		summary:  "Delete an event, a whole series or a part of it",
+		request:  func() proto.Message { return &api.DeleteRequest{} },
+		response: emptyResponse,
+		call: func(ctx context.Context, client api.EventsClient, request proto.Message) (proto.Message, error) {
+			return client.DeleteEvent(ctx, request.(*api.DeleteRequest))
+		},
+	},
+}
+
+// Handler HTTP/JSON шлюз к gRPC-сервису Events для клиентов, не умеющих gRPC
+type Handler struct {
+	client api.EventsClient
+	logger *zap.SugaredLogger
+}
+
+// NewHandler создает шлюз, вызывающий сервис через client
+func NewHandler(client api.EventsClient, logger *zap.SugaredLogger) *Handler {
+	return &Handler{
+		client: client,
+		logger: logger,
+	}
+}
+
+// ServeHTTP ...
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == specPath {
+		h.spec(w, r)
+		return
+	}
+
+	var allowed []string
+	for i := range routes {
+		rt := &routes[i]
+		params, ok := match(rt.pattern, r.URL.Path)
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			allowed = append(allowed, rt.method)
+			continue
+		}
+		h.serve(w, r, rt, params)
+		return
+	}
+
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, status.New(codes.Unimplemented, http.StatusText(http.StatusMethodNotAllowed)), http.StatusMethodNotAllowed)
+		return
+	}
+	writeError(w, status.New(codes.NotFound, http.StatusText(http.StatusNotFound)), http.StatusNotFound)
+}
+
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, rt *route, params map[string]string) {
+	request := rt.request()
+	if err := readRequest(r, rt, params, request); err != nil {
+		h.logger.Errorw("error reading request", "methodName", rt.rpc, "err", err)
+		st := status.New(codes.InvalidArgument, err.Error())
+		writeError(w, st, httpStatus(st.Code()))
+		return
+	}
+
+	// токен проверяет сам сервис, шлюз только передает его
+	ctx := r.Context()
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", authorization)
+	}
+
+	response, err := rt.call(ctx, h.client, request)
+	if err != nil {
+		st := status.Convert(err)
+		writeError(w, st, httpStatus(st.Code()))
+		return
+	}
+
+	writeMessage(w, http.StatusOK, response)
+}
+
+// spec отдаст OpenAPI-описание маршрутов
+func (h *Handler) spec(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, status.New(codes.Unimplemented, http.StatusText(http.StatusMethodNotAllowed)), http.StatusMethodNotAllowed)
+		return
+	}
+
+	data, err := OpenAPI()
+	if err != nil {
+		h.logger.Errorw("error OpenAPI", "methodName", "spec", "err", err)
+		writeError(w, status.New(codes.Internal, err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// match сравнит путь с шаблоном маршрута и вернет значения параметров пути
+func match(pattern, path string) (map[string]string, bool) {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, segment := range want {
+		if name, ok := paramName(segment); ok {
+			if got[i] == "" {
+				return nil, false
+			}
+			params[name] = got[i]
+			continue
+		}
+		if segment != got[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// paramName вернет имя параметра, если сегмент шаблона имеет вид {name}
+func paramName(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+// readRequest соберет запрос из тела, параметров пути и query
+func readRequest(r *http.Request, rt *route, params map[string]string, request proto.Message) error {
+	message := messageDescriptor(request)
+	fields := make(map[string]interface{})
+
+	if rt.body != "" {
+		var body interface{}
+		decoder := json.NewDecoder(io.LimitReader(r.Body, maxBodySize))
+		decoder.UseNumber()
+		if err := decoder.Decode(&body); err != nil {
+			return fmt.Errorf("invalid request body: %w", err)
+		}
+		if rt.body == "*" {
+			object, ok := body.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("request body must be a JSON object")
+			}
+			fields = object
+		} else {
+			fields[rt.body] = body
+		}
+	}
+
+	for name, value := range params {
+		if err := setParam(fields, message, name, value); err != nil {
+			return err
+		}
+	}
+	for name, values := range r.URL.Query() {
+		_, inPath := params[name]
+		if inPath || rt.body == "*" || name == rt.body || strings.HasPrefix(name, rt.body+".") {
+			return fmt.Errorf("query parameter %q is not allowed", name)
+		}
+		if err := setParam(fields, message, name, values[len(values)-1]); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return jsonpb.Unmarshal(bytes.NewReader(data), request)
+}
+
+func writeMessage(w http.ResponseWriter, code int, message proto.Message) {
+	var b bytes.Buffer
+	marshaler := jsonpb.Marshaler{EmitDefaults: true}
+	if err := marshaler.Marshal(&b, message); err != nil {
+		code = http.StatusInternalServerError
+		b.Reset()
+		fmt.Fprintf(&b, `{"code":%d,"message":%q}`, codes.Internal, err.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(b.Bytes())
+}
+
+// writeError ответит статусом gRPC в JSON: code, message и details
+func writeError(w http.ResponseWriter, st *status.Status, code int) {
+	writeMessage(w, code, st.Proto())
+}
+
+// httpStatus HTTP-код для кода gRPC
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,220 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/auth"
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/service"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/bobrovka/calendar/pkg/calendar/api"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newServer поднимет gRPC-сервис в памяти и шлюз к нему
+func newServer(t *testing.T, options ...grpc.ServerOption) *httptest.Server {
+	logger := zap.NewNop().Sugar()
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), logger)
+	assert.NoError(t, err)
+
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(options...)
+	api.RegisterEventsServer(grpcServer, service.NewEventService(calendar, logger))
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufconn", grpc.WithInsecure(), grpc.WithContextDialer(
+		func(context.Context, string) (net.Conn, error) { return lis.Dial() },
+	))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	server := httptest.NewServer(NewHandler(api.NewEventsClient(conn), logger))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func do(t *testing.T, server *httptest.Server, method, path, body string) (int, map[string]interface{}) {
+	request, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+	assert.NoError(t, err)
+
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	defer response.Body.Close()
+
+	var result map[string]interface{}
+	assert.NoError(t, json.NewDecoder(response.Body).Decode(&result))
+	return response.StatusCode, result
+}
+
+func TestHandler_Events(t *testing.T) {
+	server := newServer(t)
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1).Add(10 * time.Hour)
+
+	code, created := do(t, server, http.MethodPost, "/v1/users/Kira/events",
+		`{"title":"review","startAt":"`+start.Format(time.RFC3339)+`","duration":"3600s","notifyBefore":"0s"}`)
+	assert.Equal(t, http.StatusOK, code)
+	uuid, _ := created["uuid"].(string)
+	assert.NotEmpty(t, uuid)
+
+	code, _ = do(t, server, http.MethodPut, "/v1/events/"+uuid,
+		`{"event":{"title":"long review","user":"Kira","startAt":"`+start.Format(time.RFC3339)+`","duration":"7200s","notifyBefore":"600s"},"scope":"SERIES"}`)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, list := do(t, server, http.MethodGet, "/v1/users/Kira/events?period=week&date="+start.Format(time.RFC3339), "")
+	assert.Equal(t, http.StatusOK, code)
+	events, _ := list["events"].([]interface{})
+	if assert.Len(t, events, 1) {
+		event := events[0].(map[string]interface{})
+		assert.Equal(t, "long review", event["title"])
+		assert.Equal(t, "7200s", event["duration"])
+		assert.Equal(t, "OPAQUE", event["transparency"])
+	}
+
+	code, _ = do(t, server, http.MethodDelete, "/v1/events/"+uuid, "")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, list = do(t, server, http.MethodGet, "/v1/users/Kira/events?period=WEEK&date="+start.Format(time.RFC3339), "")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, list["events"])
+}
+
+func TestHandler_ErrorDetails(t *testing.T) {
+	server := newServer(t)
+	start := time.Date(2030, time.March, 4, 10, 0, 0, 0, time.UTC)
+
+	code, created := do(t, server, http.MethodPost, "/v1/users/Kira/events",
+		`{"title":"review","startAt":"`+start.Format(time.RFC3339)+`","duration":"3600s","notifyBefore":"0s"}`)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, clash := do(t, server, http.MethodPost, "/v1/users/Kira/events",
+		`{"title":"clash","startAt":"`+start.Add(30*time.Minute).Format(time.RFC3339)+`","duration":"3600s","notifyBefore":"0s"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, float64(9), clash["code"]) // FailedPrecondition
+	details, _ := clash["details"].([]interface{})
+	if assert.Len(t, details, 2) {
+		failure := details[1].(map[string]interface{})
+		assert.Equal(t, "type.googleapis.com/google.rpc.PreconditionFailure", failure["@type"])
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"type":        "TIME_BUSY",
+			"subject":     created["uuid"],
+			"description": "2030-03-04T10:00:00Z/2030-03-04T11:00:00Z",
+		}}, failure["violations"])
+	}
+
+	code, invalid := do(t, server, http.MethodPost, "/v1/users/Kira/events", `{"title":"no time"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, float64(3), invalid["code"]) // InvalidArgument
+	assert.Contains(t, fmt.Sprint(invalid["details"]), "field:event")
+
+	code, invalid = do(t, server, http.MethodPost, "/v1/users/Kira/events",
+		`{"title":" ","startAt":"`+start.Format(time.RFC3339)+`","duration":"-60s","notifyBefore":"0s"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	details, _ = invalid["details"].([]interface{})
+	if assert.Len(t, details, 1) {
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"field": "event.title", "description": "must not be empty"},
+			map[string]interface{}{"field": "event.duration", "description": "must be positive"},
+		}, details[0].(map[string]interface{})["fieldViolations"])
+	}
+
+	code, _ = do(t, server, http.MethodDelete, "/v1/events/missing", "")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestHandler_ForwardsAuthorization(t *testing.T) {
+	jwt := auth.NewJWT([]byte("secret"))
+	server := newServer(t, grpc.UnaryInterceptor(auth.UnaryServerInterceptor(jwt)))
+
+	code, _ := do(t, server, http.MethodGet, "/v1/users/Kira/events?period=day&date=2030-01-01T00:00:00Z", "")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	token, err := jwt.Issue("Kira", time.Time{})
+	assert.NoError(t, err)
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/v1/users/Kira/events?period=day&date=2030-01-01T00:00:00Z", nil)
+	assert.NoError(t, err)
+	request.Header.Set("Authorization", "Bearer "+token)
+	response, err := http.DefaultClient.Do(request)
+	assert.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+func TestHandler_BadRequests(t *testing.T) {
+	type testCase struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["unknown query parameter"] = testCase{
+		method: http.MethodGet,
+		path:   "/v1/users/Kira/events?colour=red",
+		code:   http.StatusBadRequest,
+	}
+	testCases["unknown enum value"] = testCase{
+		method: http.MethodGet,
+		path:   "/v1/users/Kira/events?period=year",
+		code:   http.StatusBadRequest,
+	}
+	testCases["query parameter for body field"] = testCase{
+		method: http.MethodPost,
+		path:   "/v1/users/Kira/events?event.title=x",
+		body:   `{}`,
+		code:   http.StatusBadRequest,
+	}
+	testCases["broken body"] = testCase{
+		method: http.MethodPost,
+		path:   "/v1/users/Kira/events",
+		body:   `{"title":`,
+		code:   http.StatusBadRequest,
+	}
+	testCases["unknown body field"] = testCase{
+		method: http.MethodPut,
+		path:   "/v1/events/42",
+		body:   `{"colour":"red"}`,
+		code:   http.StatusBadRequest,
+	}
+	testCases["wrong method"] = testCase{
+		method: http.MethodPatch,
+		path:   "/v1/events/42",
+		code:   http.StatusMethodNotAllowed,
+	}
+	testCases["unknown path"] = testCase{
+		method: http.MethodGet,
+		path:   "/v1/calendars",
+		code:   http.StatusNotFound,
+	}
+
+	server := newServer(t)
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			code, result := do(t, server, tc.method, tc.path, tc.body)
+			assert.Equal(t, tc.code, code)
+			assert.NotEmpty(t, result["message"])
+		})
+	}
+}
+
+func TestOpenAPI_UpToDate(t *testing.T) {
+	data, err := OpenAPI()
+	assert.NoError(t, err)
+
+	committed, err := ioutil.ReadFile("../../api/openapi.json")
+	assert.NoError(t, err)
+	assert.Equal(t, string(committed), string(data), "api/openapi.json is stale, run go generate ./internal/gateway")
+}
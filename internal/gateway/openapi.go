@@ -0,0 +1,221 @@
+package gateway
+
+import (
+	"encoding/json"
+	"strings"
+
+	pb "github.com/golang/protobuf/protoc-gen-go/descriptor"
+)
+
+//go:generate go run ../../cmd/openapi -o ../../api/openapi.json
+
+// schema объект схемы OpenAPI
+type schema map[string]interface{}
+
+// statusSchema тело ответа с ошибкой: статус gRPC
+var statusSchema = schema{
+	"type": "object",
+	"properties": schema{
+		"code":    schema{"type": "integer", "format": "int32", "description": "gRPC status code"},
+		"message": schema{"type": "string"},
+		"details": schema{
+			"type":  "array",
+			"items": schema{"type": "object", "properties": schema{"@type": schema{"type": "string"}}, "additionalProperties": true},
+		},
+	},
+}
+
+// OpenAPI вернет описание маршрутов шлюза в формате OpenAPI 3.0. Схемы строятся по описанию
+// api.proto в сгенерированном коде, так что после изменения proto достаточно go generate
+func OpenAPI() ([]byte, error) {
+	schemas := map[string]schema{"rpcStatus": statusSchema}
+	paths := make(map[string]map[string]schema)
+	for i := range routes {
+		rt := &routes[i]
+		if paths[rt.pattern] == nil {
+			paths[rt.pattern] = make(map[string]schema)
+		}
+		paths[rt.pattern][strings.ToLower(rt.method)] = operation(rt, schemas)
+	}
+
+	doc := schema{
+		"openapi": "3.0.3",
+		"info": schema{
+			"title":   "Calendar Events API",
+			"version": "1.0",
+			"description": "HTTP/JSON mapping of the Events gRPC service. Messages use the proto3 JSON encoding: " +
+				"timestamps are RFC 3339 strings, durations are strings like \"900s\".",
+		},
+		"paths":      paths,
+		"components": schema{"schemas": schemas},
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func operation(rt *route, schemas map[string]schema) schema {
+	md := messageDescriptor(rt.request())
+
+	inPath := make(map[string]bool)
+	parameters := []schema{}
+	for _, segment := range strings.Split(rt.pattern, "/") {
+		name, ok := paramName(segment)
+		if !ok {
+			continue
+		}
+		inPath[strings.SplitN(name, ".", 2)[0]] = true
+		parameters = append(parameters, schema{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"schema":   fieldSchema(pathField(md, name), schemas),
+		})
+	}
+
+	op := schema{
+		"operationId": rt.rpc,
+		"summary":     rt.summary,
+		"responses": schema{
+			"200": schema{
+				"description": "OK",
+				"content":     jsonContent(messageRef(typeName(rt.response), schemas)),
+			},
+			"default": schema{
+				"description": "Error",
+				"content":     jsonContent(schema{"$ref": "#/components/schemas/rpcStatus"}),
+			},
+		},
+	}
+
+	switch rt.body {
+	case "":
+		for _, field := range md.GetField() {
+			if inPath[field.GetName()] || !queryField(field) {
+				continue
+			}
+			parameters = append(parameters, schema{
+				"name":   field.GetName(),
+				"in":     "query",
+				"schema": fieldSchema(field, schemas),
+			})
+		}
+	case "*":
+		op["requestBody"] = schema{
+			"required": true,
+			"content":  jsonContent(messageSchema(md, inPath, schemas)),
+		}
+	default:
+		op["requestBody"] = schema{
+			"required": true,
+			"content":  jsonContent(fieldSchema(findField(md, rt.body), schemas)),
+		}
+	}
+	if len(parameters) > 0 {
+		op["parameters"] = parameters
+	}
+	return op
+}
+
+// pathField найдет поле по имени параметра пути вида event.user
+func pathField(md *pb.DescriptorProto, name string) *pb.FieldDescriptorProto {
+	parts := strings.Split(name, ".")
+	for _, part := range parts[:len(parts)-1] {
+		md = findMessage(findField(md, part).GetTypeName())
+	}
+	return findField(md, parts[len(parts)-1])
+}
+
+// queryField можно ли передать поле в query, как это понимает setParam
+func queryField(field *pb.FieldDescriptorProto) bool {
+	if isRepeated(field) {
+		return false
+	}
+	switch field.GetType() {
+	case pb.FieldDescriptorProto_TYPE_MESSAGE:
+		return field.GetTypeName() == timestampType || field.GetTypeName() == durationType
+	case pb.FieldDescriptorProto_TYPE_BYTES, pb.FieldDescriptorProto_TYPE_GROUP:
+		return false
+	}
+	return true
+}
+
+func jsonContent(s schema) schema {
+	return schema{"application/json": schema{"schema": s}}
+}
+
+// messageRef ссылка на схему сообщения api.proto, схема добавляется в schemas
+func messageRef(name string, schemas map[string]schema) schema {
+	if name == emptyType {
+		return schema{"type": "object"}
+	}
+
+	short := strings.TrimPrefix(name, ".")
+	if _, ok := schemas[short]; !ok {
+		// заглушка против бесконечной рекурсии на ссылающихся друг на друга сообщениях
+		schemas[short] = schema{}
+		schemas[short] = messageSchema(findMessage(name), nil, schemas)
+	}
+	return schema{"$ref": "#/components/schemas/" + short}
+}
+
+func messageSchema(md *pb.DescriptorProto, skip map[string]bool, schemas map[string]schema) schema {
+	properties := make(schema)
+	for _, field := range md.GetField() {
+		if skip[field.GetName()] {
+			continue
+		}
+		properties[field.GetName()] = fieldSchema(field, schemas)
+	}
+	return schema{"type": "object", "properties": properties}
+}
+
+func fieldSchema(field *pb.FieldDescriptorProto, schemas map[string]schema) schema {
+	var s schema
+	switch field.GetType() {
+	case pb.FieldDescriptorProto_TYPE_STRING:
+		s = schema{"type": "string"}
+	case pb.FieldDescriptorProto_TYPE_BYTES:
+		s = schema{"type": "string", "format": "byte"}
+	case pb.FieldDescriptorProto_TYPE_BOOL:
+		s = schema{"type": "boolean"}
+	case pb.FieldDescriptorProto_TYPE_DOUBLE, pb.FieldDescriptorProto_TYPE_FLOAT:
+		s = schema{"type": "number"}
+	case pb.FieldDescriptorProto_TYPE_INT64, pb.FieldDescriptorProto_TYPE_UINT64, pb.FieldDescriptorProto_TYPE_SINT64,
+		pb.FieldDescriptorProto_TYPE_FIXED64, pb.FieldDescriptorProto_TYPE_SFIXED64:
+		// в JSON 64-битные числа передаются строками
+		s = schema{"type": "string", "format": "int64"}
+	case pb.FieldDescriptorProto_TYPE_ENUM:
+		s = enumRef(field.GetTypeName(), schemas)
+	case pb.FieldDescriptorProto_TYPE_MESSAGE:
+		switch field.GetTypeName() {
+		case timestampType:
+			s = schema{"type": "string", "format": "date-time"}
+		case durationType:
+			s = schema{"type": "string", "example": "900s"}
+		default:
+			s = messageRef(field.GetTypeName(), schemas)
+		}
+	default:
+		s = schema{"type": "integer", "format": "int32"}
+	}
+
+	if isRepeated(field) {
+		return schema{"type": "array", "items": s}
+	}
+	return s
+}
+
+func enumRef(name string, schemas map[string]schema) schema {
+	short := strings.TrimPrefix(name, ".")
+	if _, ok := schemas[short]; !ok {
+		values := []string{}
+		for _, value := range findEnum(name).GetValue() {
+			values = append(values, value.GetName())
+		}
+		schemas[short] = schema{"type": "string", "enum": values}
+	}
+	return schema{"$ref": "#/components/schemas/" + short}
+}
@@ -0,0 +1,326 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+var (
+	// ErrNoCalendar в файле нет VCALENDAR
+	ErrNoCalendar = errors.New("no VCALENDAR in file")
+
+	// ErrInvalidEvent VEVENT не удалось разобрать
+	ErrInvalidEvent = errors.New("invalid VEVENT")
+
+	// ErrRecurrenceOverride VEVENT с RECURRENCE-ID: перенесенные экземпляры серий не импортируются
+	ErrRecurrenceOverride = fmt.Errorf("%w: moved occurrences of a series are not supported", ErrInvalidEvent)
+)
+
+// ImportedEvent разобранный VEVENT. Если его не удалось перевести в событие, Event пуст, а Err объясняет почему
+type ImportedEvent struct {
+	UID   string
+	Event *models.Event
+	Err   error
+}
+
+// property строка содержимого: NAME;PARAM=VALUE:value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode разберет VEVENT из календаря r. Время без часового пояса и даты событий на целый день
+// считаются в loc. Ошибка возвращается, только если r не календарь; ошибки отдельных событий
+// попадают в ImportedEvent.Err
+func Decode(r io.Reader, loc *time.Location) ([]*ImportedEvent, error) {
+	props, err := readProperties(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result   []*ImportedEvent
+		inside   bool
+		event    []property
+		calendar bool
+	)
+	// VTIMEZONE и прочие компоненты вне VEVENT пропускаются
+	for _, prop := range props {
+		switch {
+		case prop.name == "BEGIN" && prop.value == "VCALENDAR":
+			calendar = true
+		case prop.name == "BEGIN" && prop.value == "VEVENT":
+			inside, event = true, nil
+		case prop.name == "END" && prop.value == "VEVENT" && inside:
+			result = append(result, decodeEvent(event, loc))
+			inside = false
+		case inside:
+			event = append(event, prop)
+		}
+	}
+	if !calendar {
+		return nil, ErrNoCalendar
+	}
+
+	return result, nil
+}
+
+// readProperties прочитает строки содержимого, склеив перенесенные
+func readProperties(r io.Reader) ([]property, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	props := make([]property, 0, len(lines))
+	for _, line := range lines {
+		prop, ok := parseProperty(line)
+		if ok {
+			props = append(props, prop)
+		}
+	}
+	return props, nil
+}
+
+func parseProperty(line string) (property, bool) {
+	// двоеточие может встретиться в значении параметра в кавычках
+	colon, quoted := -1, false
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		}
+		if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := property{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string, len(parts)-1),
+		value:  line[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 {
+			prop.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return prop, true
+}
+
+func decodeEvent(props []property, loc *time.Location) *ImportedEvent {
+	imported := &ImportedEvent{}
+	fail := func(format string, args ...interface{}) *ImportedEvent {
+		imported.Err = fmt.Errorf("%w: %s", ErrInvalidEvent, fmt.Sprintf(format, args...))
+		return imported
+	}
+
+	event := &models.Event{}
+	var (
+		end      time.Time
+		hasStart bool
+		alarm    bool
+		override bool
+	)
+	for _, prop := range props {
+		if alarm {
+			if prop.name == "END" && prop.value == "VALARM" {
+				alarm = false
+			}
+			if prop.name == "TRIGGER" && prop.params["VALUE"] != "DATE-TIME" && prop.params["RELATED"] != "END" {
+				trigger, err := parseDuration(prop.value)
+				// берется первое напоминание до начала события
+				if err == nil && trigger <= 0 && event.NotifyBefore == 0 {
+					event.NotifyBefore = -trigger
+				}
+			}
+			continue
+		}
+
+		var err error
+		switch prop.name {
+		case "BEGIN":
+			alarm = prop.value == "VALARM"
+		case "UID":
+			imported.UID = prop.value
+		case "SUMMARY":
+			event.Title = unescape(prop.value)
+		case "DESCRIPTION":
+			event.Description = unescape(prop.value)
+		case "DTSTART":
+			event.StartAt, event.AllDay, err = parseTime(prop, loc)
+			hasStart = true
+		case "DTEND":
+			end, _, err = parseTime(prop, loc)
+		case "DURATION":
+			event.Duration, err = parseDuration(prop.value)
+		case "RRULE":
+			event.RRule = prop.value
+		case "EXDATE":
+			for _, value := range strings.Split(prop.value, ",") {
+				var exDate time.Time
+				exDate, _, err = parseTime(property{name: prop.name, params: prop.params, value: value}, loc)
+				if err != nil {
+					break
+				}
+				event.ExDates = append(event.ExDates, exDate)
+			}
+		case "RECURRENCE-ID":
+			override = true
+		case "TRANSP":
+			if strings.ToUpper(prop.value) == "TRANSPARENT" {
+				event.Transparency = models.Transparent
+			}
+		case "STATUS":
+			switch strings.ToUpper(prop.value) {
+			case "TENTATIVE":
+				event.Status = models.StatusTentative
+			case "CANCELLED":
+				event.Status = models.StatusCancelled
+			}
+		}
+		if err != nil {
+			return fail("%s: %v", prop.name, err)
+		}
+	}
+
+	if override {
+		imported.Err = ErrRecurrenceOverride
+		return imported
+	}
+	if imported.UID == "" {
+		return fail("no UID")
+	}
+	if !hasStart {
+		return fail("no DTSTART")
+	}
+	if !end.IsZero() {
+		event.Duration = end.Sub(event.StartAt)
+	}
+	if event.AllDay {
+		// даты считались в loc, храним их как полночь UTC
+		year, month, date := event.StartAt.Date()
+		event.StartAt = time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+		if event.Duration == 0 {
+			event.Duration = 24 * time.Hour
+		}
+	}
+	if event.Duration < 0 {
+		return fail("event ends before it starts")
+	}
+	if event.RRule != "" {
+		if _, err := models.ParseRRule(event.RRule); err != nil {
+			return fail("%v", err)
+		}
+	}
+
+	event.ExternalUID = imported.UID
+	imported.Event = event
+	return imported
+}
+
+// parseTime разберет DATE или DATE-TIME: в UTC, с TZID или плавающее время в loc.
+// Вторым значением вернет, была ли это дата без времени
+func parseTime(prop property, loc *time.Location) (time.Time, bool, error) {
+	value := prop.value
+	if prop.params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(utcLayout, value)
+		return t, false, err
+	}
+
+	if tzid, ok := prop.params["TZID"]; ok {
+		// имена поясов не из базы IANA, например из Outlook, считаются поясом пользователя
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	t, err := time.ParseInLocation(localLayout, value, loc)
+	return t, false, err
+}
+
+// parseDuration разберет длительность RFC 5545 3.3.6, например -PT15M, P1W или P1DT2H
+func parseDuration(value string) (time.Duration, error) {
+	s := strings.ToUpper(value)
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign, s = -1, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("bad duration %q", value)
+	}
+	s = s[1:]
+
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+	var d time.Duration
+	for s != "" {
+		if s[0] == 'T' {
+			units = map[byte]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+			s = s[1:]
+			continue
+		}
+
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, fmt.Errorf("bad duration %q", value)
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("bad duration %q", value)
+		}
+		unit, ok := units[s[i]]
+		if !ok {
+			return 0, fmt.Errorf("bad duration %q", value)
+		}
+		d += time.Duration(n) * unit
+		s = s[i+1:]
+	}
+	return sign * d, nil
+}
+
+// unescape вернет текстовое значение без экранирования RFC 5545 3.3.11
+func unescape(text string) string {
+	return strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	).Replace(text)
+}
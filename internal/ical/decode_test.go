@@ -0,0 +1,136 @@
+package ical
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	calendar := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VTIMEZONE",
+		"TZID:Russian Standard Time",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:standup@other",
+		"DTSTART;TZID=America/New_York:20200302T090000",
+		"DTEND;TZID=America/New_York:20200302T091500",
+		`SUMMARY:standup\, daily`,
+		"DESCRIPTION:long description that was folded by the exporting client at se",
+		" venty five octets",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE",
+		"EXDATE;TZID=America/New_York:20200304T090000,20200309T090000",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"TRIGGER:-PT10M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:vacation@other",
+		"DTSTART;VALUE=DATE:20200309",
+		"DTEND;VALUE=DATE:20200314",
+		"SUMMARY:vacation",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:outlook@other",
+		"DTSTART;TZID=Russian Standard Time:20200305T120000",
+		"DURATION:PT1H",
+		"STATUS:TENTATIVE",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:moved@other",
+		"RECURRENCE-ID:20200302T140000Z",
+		"DTSTART:20200302T150000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:broken@other",
+		"DTSTART:yesterday",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20200302T150000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	imported, err := Decode(strings.NewReader(calendar), moscow)
+	assert.NoError(t, err)
+	assert.Len(t, imported, 6)
+
+	assert.NoError(t, imported[0].Err)
+	assert.Equal(t, &models.Event{
+		Title:        "standup, daily",
+		StartAt:      time.Date(2020, time.March, 2, 9, 0, 0, 0, newYork),
+		Duration:     15 * time.Minute,
+		Description:  "long description that was folded by the exporting client at seventy five octets",
+		NotifyBefore: 10 * time.Minute,
+		RRule:        "FREQ=WEEKLY;BYDAY=MO,WE",
+		ExDates: []time.Time{
+			time.Date(2020, time.March, 4, 9, 0, 0, 0, newYork),
+			time.Date(2020, time.March, 9, 9, 0, 0, 0, newYork),
+		},
+		ExternalUID: "standup@other",
+	}, imported[0].Event)
+
+	assert.NoError(t, imported[1].Err)
+	assert.Equal(t, time.Date(2020, time.March, 9, 0, 0, 0, 0, time.UTC), imported[1].Event.StartAt)
+	assert.Equal(t, 5, imported[1].Event.Days())
+	assert.True(t, imported[1].Event.AllDay)
+	assert.Equal(t, models.Transparent, imported[1].Event.Transparency)
+
+	// пояс не из базы IANA считается поясом пользователя
+	assert.NoError(t, imported[2].Err)
+	assert.Equal(t, time.Date(2020, time.March, 5, 12, 0, 0, 0, moscow), imported[2].Event.StartAt)
+	assert.Equal(t, models.StatusTentative, imported[2].Event.Status)
+
+	for i, uid := range []string{"moved@other", "broken@other", ""} {
+		assert.Equal(t, uid, imported[3+i].UID)
+		assert.Nil(t, imported[3+i].Event)
+		assert.True(t, errors.Is(imported[3+i].Err, ErrInvalidEvent))
+	}
+}
+
+func TestDecode_RoundTrip(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	event := &models.Event{
+		UUID:         "1",
+		Title:        "retro; with, punctuation",
+		StartAt:      time.Date(2020, time.March, 2, 18, 0, 0, 0, moscow),
+		Duration:     90 * time.Minute,
+		Description:  "first line\nsecond line",
+		NotifyBefore: time.Hour,
+		RRule:        "FREQ=WEEKLY;INTERVAL=2",
+	}
+
+	var b strings.Builder
+	assert.NoError(t, Encode(&b, &Calendar{Location: moscow, Events: []*models.Event{event}}))
+
+	imported, err := Decode(strings.NewReader(b.String()), time.UTC)
+	assert.NoError(t, err)
+	assert.Len(t, imported, 1)
+	assert.NoError(t, imported[0].Err)
+
+	exp := *event
+	exp.UUID = ""
+	exp.ExternalUID = "1"
+	assert.Equal(t, &exp, imported[0].Event)
+}
+
+func TestDecode_NotCalendar(t *testing.T) {
+	_, err := Decode(strings.NewReader("hello"), time.UTC)
+	assert.Equal(t, ErrNoCalendar, err)
+}
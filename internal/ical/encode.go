@@ -0,0 +1,217 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// ContentType MIME-тип выгрузки
+const ContentType = "text/calendar; charset=utf-8"
+
+const (
+	dateLayout    = "20060102"
+	localLayout   = "20060102T150405"
+	utcLayout     = "20060102T150405Z"
+	maxLineOctets = 75
+	productID     = "-//bobrovka//calendar//EN"
+)
+
+// Calendar календарь пользователя для выгрузки в формате RFC 5545
+type Calendar struct {
+	Name string // X-WR-CALNAME
+	// Location часовой пояс пользователя. Время пишется с TZID этого пояса и его VTIMEZONE, чтобы клиенты
+	// разворачивали серии по местному времени, как и сервис. Для UTC время пишется с суффиксом Z
+	Location *time.Location
+	Stamp    time.Time // DTSTAMP, момент выгрузки
+	// Events события со временем в часовом поясе Location, события на целый день - с местными полуночами
+	Events []*models.Event
+}
+
+// Encode запишет календарь в w. Серии пишутся одним VEVENT с RRULE и EXDATE,
+// перенесенные экземпляры - отдельным VEVENT с тем же UID и RECURRENCE-ID
+func Encode(w io.Writer, cal *Calendar) error {
+	loc := cal.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	// события, импортированные из других календарей, сохраняют свой UID
+	uids := make(map[string]string, len(cal.Events))
+	for _, event := range cal.Events {
+		uids[event.UUID] = event.UUID
+		if event.ExternalUID != "" {
+			uids[event.UUID] = event.ExternalUID
+		}
+	}
+
+	e := &encoder{w: bufio.NewWriter(w), loc: loc, stamp: cal.Stamp.UTC().Format(utcLayout), stampYear: cal.Stamp.Year(), uids: uids}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", productID)
+	e.line("CALSCALE", "GREGORIAN")
+	if cal.Name != "" {
+		e.line("X-WR-CALNAME", escape(cal.Name))
+	}
+	if loc != time.UTC {
+		e.line("X-WR-TIMEZONE", loc.String())
+		if from, ok := zonedFrom(cal.Events); ok {
+			e.timezone(from)
+		}
+	}
+	for _, event := range cal.Events {
+		e.event(event)
+	}
+	e.line("END", "VCALENDAR")
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w         *bufio.Writer
+	loc       *time.Location
+	stamp     string
+	stampYear int
+	uids      map[string]string
+	err       error
+}
+
+func (e *encoder) event(event *models.Event) {
+	id := event.UUID
+	if event.SeriesUUID != "" {
+		id = event.SeriesUUID
+	}
+	uid, ok := e.uids[id]
+	if !ok {
+		uid = id
+	}
+
+	e.line("BEGIN", "VEVENT")
+	e.line("UID", uid)
+	e.line("DTSTAMP", e.stamp)
+	if event.SeriesUUID != "" {
+		e.time("RECURRENCE-ID", event.RecurrenceAt, event.AllDay)
+	}
+	e.time("DTSTART", event.StartAt, event.AllDay)
+	if event.AllDay {
+		e.line("DURATION", fmt.Sprintf("P%dD", event.Days()))
+	} else {
+		e.line("DURATION", formatDuration(event.Duration))
+	}
+	e.line("SUMMARY", escape(event.Title))
+	if event.Description != "" {
+		e.line("DESCRIPTION", escape(event.Description))
+	}
+	if event.RRule != "" {
+		e.line("RRULE", event.RRule)
+	}
+	for _, exDate := range event.ExDates {
+		e.time("EXDATE", exDate, event.AllDay)
+	}
+	if event.Transparency == models.Transparent {
+		e.line("TRANSP", "TRANSPARENT")
+	}
+	switch event.Status {
+	case models.StatusTentative:
+		e.line("STATUS", "TENTATIVE")
+	case models.StatusCancelled:
+		e.line("STATUS", "CANCELLED")
+	}
+	if event.NotifyBefore > 0 {
+		e.line("BEGIN", "VALARM")
+		e.line("ACTION", "DISPLAY")
+		e.line("DESCRIPTION", escape(event.Title))
+		e.line("TRIGGER", "-"+formatDuration(event.NotifyBefore))
+		e.line("END", "VALARM")
+	}
+	e.line("END", "VEVENT")
+}
+
+// time запишет момент времени: дату для событий на целый день, местное время с TZID
+// или время в UTC
+func (e *encoder) time(name string, t time.Time, allDay bool) {
+	switch {
+	case allDay:
+		e.line(name+";VALUE=DATE", t.In(e.loc).Format(dateLayout))
+	case e.loc == time.UTC:
+		e.line(name, t.UTC().Format(utcLayout))
+	default:
+		e.line(name+";TZID="+e.loc.String(), t.In(e.loc).Format(localLayout))
+	}
+}
+
+// line запишет свойство, перенося строки длиннее 75 октетов
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+
+	content := name + ":" + value
+	// строки продолжения начинаются с пробела, он тоже входит в 75 октетов
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		// не разрезать многобайтный символ UTF-8
+		for cut > 0 && content[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		if _, e.err = e.w.WriteString(content[:cut] + "\r\n "); e.err != nil {
+			return
+		}
+		content = content[cut:]
+		limit = maxLineOctets - 1
+	}
+	_, e.err = e.w.WriteString(content + "\r\n")
+}
+
+// escape экранирует текстовое значение по RFC 5545 3.3.11
+func escape(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+// formatDuration запишет длительность в формате RFC 5545 3.3.6, например PT1H30M или P1DT2H
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+	d -= minutes * time.Minute
+	seconds := d / time.Second
+
+	var b strings.Builder
+	b.WriteString("P")
+	if days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 || days == 0 {
+		b.WriteString("T")
+		if hours > 0 {
+			fmt.Fprintf(&b, "%dH", hours)
+		}
+		if minutes > 0 {
+			fmt.Fprintf(&b, "%dM", minutes)
+		}
+		if seconds > 0 || hours == 0 && minutes == 0 {
+			fmt.Fprintf(&b, "%dS", seconds)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,180 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+
+	start := time.Date(2020, time.March, 2, 10, 0, 0, 0, moscow)
+	cal := &Calendar{
+		Name:     "Kira",
+		Location: moscow,
+		Stamp:    time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC),
+		Events: []*models.Event{
+			{
+				UUID:         "1",
+				Title:        "standup, daily",
+				StartAt:      start,
+				Duration:     15 * time.Minute,
+				Description:  "line one\nline two",
+				NotifyBefore: 10 * time.Minute,
+				RRule:        "FREQ=DAILY;COUNT=5",
+				ExDates:      []time.Time{start.AddDate(0, 0, 2)},
+			},
+			{
+				UUID:         "2",
+				Title:        "standup, daily",
+				StartAt:      start.AddDate(0, 0, 1).Add(time.Hour),
+				Duration:     15 * time.Minute,
+				SeriesUUID:   "1",
+				RecurrenceAt: start.AddDate(0, 0, 1),
+			},
+			{
+				UUID:         "3",
+				Title:        "vacation",
+				StartAt:      time.Date(2020, time.March, 9, 0, 0, 0, 0, moscow),
+				Duration:     5 * 24 * time.Hour,
+				AllDay:       true,
+				Transparency: models.Transparent,
+			},
+		},
+	}
+
+	var b strings.Builder
+	assert.NoError(t, Encode(&b, cal))
+
+	exp := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//bobrovka//calendar//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Kira",
+		"X-WR-TIMEZONE:Europe/Moscow",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Moscow",
+		"BEGIN:STANDARD",
+		"DTSTART:20141026T020000",
+		"TZOFFSETFROM:+0400",
+		"TZOFFSETTO:+0300",
+		"TZNAME:MSK",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:1",
+		"DTSTAMP:20200301T120000Z",
+		"DTSTART;TZID=Europe/Moscow:20200302T100000",
+		"DURATION:PT15M",
+		`SUMMARY:standup\, daily`,
+		`DESCRIPTION:line one\nline two`,
+		"RRULE:FREQ=DAILY;COUNT=5",
+		"EXDATE;TZID=Europe/Moscow:20200304T100000",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		`DESCRIPTION:standup\, daily`,
+		"TRIGGER:-PT10M",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:1",
+		"DTSTAMP:20200301T120000Z",
+		"RECURRENCE-ID;TZID=Europe/Moscow:20200303T100000",
+		"DTSTART;TZID=Europe/Moscow:20200303T110000",
+		"DURATION:PT15M",
+		`SUMMARY:standup\, daily`,
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:3",
+		"DTSTAMP:20200301T120000Z",
+		"DTSTART;VALUE=DATE:20200309",
+		"DURATION:P5D",
+		"SUMMARY:vacation",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	assert.Equal(t, exp, b.String())
+}
+
+func TestEncode_TimezoneWithDaylightSaving(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	var b strings.Builder
+	err = Encode(&b, &Calendar{
+		Location: newYork,
+		Stamp:    time.Date(2030, time.March, 1, 12, 0, 0, 0, time.UTC),
+		Events: []*models.Event{
+			{UUID: "1", Title: "standup", StartAt: time.Date(2030, time.March, 4, 9, 0, 0, 0, newYork), Duration: time.Hour, RRule: "FREQ=WEEKLY"},
+		},
+	})
+	assert.NoError(t, err)
+
+	// смены прошлых лет перечисляются, ежегодные смены текущего года повторяются по RRULE
+	exp := strings.Join([]string{
+		"BEGIN:VTIMEZONE",
+		"TZID:America/New_York",
+		"BEGIN:STANDARD",
+		"DTSTART:20291104T020000",
+		"TZOFFSETFROM:-0400",
+		"TZOFFSETTO:-0500",
+		"TZNAME:EST",
+		"END:STANDARD",
+		"BEGIN:DAYLIGHT",
+		"DTSTART:20300310T020000",
+		"TZOFFSETFROM:-0500",
+		"TZOFFSETTO:-0400",
+		"TZNAME:EDT",
+		"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU",
+		"END:DAYLIGHT",
+		"BEGIN:STANDARD",
+		"DTSTART:20301103T020000",
+		"TZOFFSETFROM:-0400",
+		"TZOFFSETTO:-0500",
+		"TZNAME:EST",
+		"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+	}, "\r\n")
+	assert.Contains(t, b.String(), exp)
+	assert.Contains(t, b.String(), "DTSTART;TZID=America/New_York:20300304T090000")
+}
+
+func TestEncode_FoldsLongLines(t *testing.T) {
+	var b strings.Builder
+	err := Encode(&b, &Calendar{
+		Events: []*models.Event{
+			{UUID: "1", Title: strings.Repeat("встреча ", 20), Duration: time.Hour},
+		},
+	})
+	assert.NoError(t, err)
+
+	for _, line := range strings.Split(b.String(), "\r\n") {
+		assert.True(t, len(line) <= maxLineOctets, line)
+	}
+	assert.Contains(t, strings.Replace(b.String(), "\r\n ", "", -1), "SUMMARY:"+strings.Repeat("встреча ", 20))
+}
+
+func TestFormatDuration(t *testing.T) {
+	testCases := map[time.Duration]string{
+		0:                         "PT0S",
+		90 * time.Minute:          "PT1H30M",
+		26 * time.Hour:            "P1DT2H",
+		48 * time.Hour:            "P2D",
+		time.Hour + 5*time.Second: "PT1H5S",
+		-15 * time.Minute:         "PT15M",
+	}
+
+	for d, exp := range testCases {
+		assert.Equal(t, exp, formatDuration(d))
+	}
+}
@@ -0,0 +1,153 @@
+package ical
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// period промежуток, в котором у часового пояса одно смещение от UTC
+type period struct {
+	onset      time.Time // начало, нулевое - с начала времен
+	offsetFrom int       // смещение до onset, секунды
+	offset     int
+	name       string
+	dst        bool
+}
+
+// timezone запишет VTIMEZONE пояса e.loc. RFC 5545 требует его для каждого TZID в календаре.
+// Смены смещения перечисляются от периода, в котором начинается from, до конца года выгрузки,
+// а повторяющиеся каждый год смены получают RRULE и действуют и дальше
+func (e *encoder) timezone(from time.Time) {
+	year := e.stampYear
+	if from.Year() > year {
+		year = from.Year()
+	}
+	// смены следующего года нужны только для проверки, повторяются ли смены последнего
+	cut := time.Date(year+1, time.January, 1, 0, 0, 0, 0, e.loc)
+	periods := zonePeriods(from.In(e.loc), cut.AddDate(1, 0, 0))
+
+	e.line("BEGIN", "VTIMEZONE")
+	e.line("TZID", e.loc.String())
+	for i, p := range periods {
+		if !p.onset.Before(cut) {
+			break
+		}
+		// последняя смена года с тем же правилом в следующем году повторяется ежегодно
+		rule := ""
+		if i+2 < len(periods) && !periods[i+2].onset.Before(cut) && periods[i+2].onset.Before(cut.AddDate(1, 0, 0)) &&
+			sameTransition(p, periods[i+2]) {
+			rule = yearlyRule(p)
+		}
+		e.observance(p, rule)
+	}
+	e.line("END", "VTIMEZONE")
+}
+
+// observance запишет STANDARD или DAYLIGHT для периода p
+func (e *encoder) observance(p period, rule string) {
+	kind := "STANDARD"
+	if p.dst {
+		kind = "DAYLIGHT"
+	}
+
+	e.line("BEGIN", kind)
+	e.line("DTSTART", onsetLocal(p).Format(localLayout))
+	e.line("TZOFFSETFROM", formatOffset(p.offsetFrom))
+	e.line("TZOFFSETTO", formatOffset(p.offset))
+	if p.name != "" {
+		e.line("TZNAME", escape(p.name))
+	}
+	if rule != "" {
+		e.line("RRULE", rule)
+	}
+	e.line("END", kind)
+}
+
+// zonePeriods вернет периоды часового пояса t.Location() от периода, содержащего t, до until
+func zonePeriods(t time.Time, until time.Time) []period {
+	var periods []period
+	for {
+		start, end := t.ZoneBounds()
+		name, offset := t.Zone()
+		p := period{onset: start, offsetFrom: offset, offset: offset, name: name, dst: t.IsDST()}
+		if !start.IsZero() {
+			_, p.offsetFrom = start.Add(-time.Second).Zone()
+		}
+		periods = append(periods, p)
+
+		if end.IsZero() || !end.Before(until) {
+			return periods
+		}
+		t = end
+	}
+}
+
+// onsetLocal начало периода по часам, действовавшим до него, как его записывает RFC 5545
+func onsetLocal(p period) time.Time {
+	if p.onset.IsZero() {
+		return time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return p.onset.In(time.FixedZone("", p.offsetFrom))
+}
+
+// yearlyRule правило RRULE, по которому смена p повторяется каждый год: месяц и n-й (или последний) день недели
+func yearlyRule(p period) string {
+	local := onsetLocal(p)
+	return fmt.Sprintf("FREQ=YEARLY;BYMONTH=%d;BYDAY=%s", local.Month(), nthWeekday(local))
+}
+
+// sameTransition проверит, что смены a и b одинаковы и приходятся на один и тот же день года по правилу
+func sameTransition(a, b period) bool {
+	if a.onset.IsZero() || b.onset.IsZero() {
+		return false
+	}
+	la, lb := onsetLocal(a), onsetLocal(b)
+	return a.offsetFrom == b.offsetFrom && a.offset == b.offset && a.name == b.name && a.dst == b.dst &&
+		la.Format("150405") == lb.Format("150405") && yearlyRule(a) == yearlyRule(b)
+}
+
+// nthWeekday номер дня недели в месяце в записи BYDAY, например 2SU или -1SU для последнего воскресенья
+func nthWeekday(t time.Time) string {
+	day := [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}[t.Weekday()]
+	daysInMonth := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if t.Day()+7 > daysInMonth {
+		return "-1" + day
+	}
+	return fmt.Sprintf("%d%s", (t.Day()-1)/7+1, day)
+}
+
+// formatOffset запишет смещение от UTC в формате RFC 5545 3.3.14, например +0300 или -0430
+func formatOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign, seconds = "-", -seconds
+	}
+	offset := fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		offset += fmt.Sprintf("%02d", seconds%60)
+	}
+	return offset
+}
+
+// zonedFrom вернет самый ранний момент, который запишется с TZID, и false, если таких нет
+func zonedFrom(events []*models.Event) (time.Time, bool) {
+	var from time.Time
+	found := false
+	for _, event := range events {
+		if event.AllDay {
+			continue
+		}
+		times := append([]time.Time{event.StartAt}, event.ExDates...)
+		if event.SeriesUUID != "" {
+			times = append(times, event.RecurrenceAt)
+		}
+		for _, t := range times {
+			if !found || t.Before(from) {
+				from, found = t, true
+			}
+		}
+	}
+	return from, found
+}
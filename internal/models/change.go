@@ -0,0 +1,21 @@
+package models
+
+// ChangeKind вид изменения события
+type ChangeKind int
+
+const (
+	// ChangeCreated событие создано
+	ChangeCreated ChangeKind = iota
+	// ChangeUpdated событие или его экземпляры изменены
+	ChangeUpdated
+	// ChangeDeleted событие удалено вместе с перенесенными экземплярами серии
+	ChangeDeleted
+)
+
+// Change изменение события в календаре пользователя. Само событие не передается:
+// получатель прочитает его актуальное состояние
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	User string     `json:"user"`
+	UUID string     `json:"uuid"`
+}
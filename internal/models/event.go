@@ -13,8 +13,220 @@ type Event struct {
 	Description  string        `db:"descr"`
 	User         string        `db:"user_name"`
 	NotifyBefore time.Duration `db:"notify_before"`
+	RRule        string        `db:"rrule"` // правило повторения RFC 5545, пусто для разового события
+	ExDates      []time.Time   `db:"-"`     // отмененные экземпляры серии
+	SeriesUUID   string        `db:"series_uuid"`
+	RecurrenceAt time.Time     `db:"recurrence_at"` // исходное начало экземпляра серии
+	// AllDay событие на целые дни: StartAt - полночь UTC даты начала, Duration - целое число суток.
+	// Такое событие занимает местные сутки пользователя в его часовом поясе
+	AllDay       bool         `db:"all_day"`
+	Transparency Transparency `db:"transparency"`
+	Status       Status       `db:"status"`
+	ExternalUID  string       `db:"external_uid"` // UID события в импортированном iCalendar
 }
 
-func (e Event) String() string {
-	return e.Title + " is starting at " + e.StartAt.Format("15:04:05")
+// Transparency занимает ли событие время владельца
+type Transparency int
+
+const (
+	// Opaque событие занимает время
+	Opaque Transparency = iota
+	// Transparent событие видно в календаре, но время остается свободным
+	Transparent
+)
+
+// Status статус события
+type Status int
+
+const (
+	// StatusConfirmed событие подтверждено
+	StatusConfirmed Status = iota
+	// StatusTentative участие под вопросом, время все равно занято
+	StatusTentative
+	// StatusCancelled событие отменено и время не занимает
+	StatusCancelled
+)
+
+// FloatingSlack наибольшее смещение часового пояса от UTC. Местные сутки, в которые попадает
+// событие на целый день, могут начинаться на столько раньше или позже полуночи UTC
+const FloatingSlack = 14 * time.Hour
+
+const day = 24 * time.Hour
+
+// Days вернет число дней, которые занимает событие на целый день.
+// Длительность округляется до целых суток, так как местные сутки при переводе часов короче или длиннее
+func (e *Event) Days() int {
+	days := int((e.Duration + day/2) / day)
+	if days < 1 {
+		return 1
+	}
+	return days
+}
+
+// InLocation вернет копию события со временем в часовом поясе loc.
+// Событие на целый день переносится на местные полночи тех же дат
+func (e *Event) InLocation(loc *time.Location) *Event {
+	local := *e
+	if !e.AllDay {
+		local.StartAt = e.StartAt.In(loc)
+		return &local
+	}
+
+	year, month, date := e.StartAt.Date()
+	local.StartAt = time.Date(year, month, date, 0, 0, 0, 0, loc)
+	local.Duration = local.StartAt.AddDate(0, 0, e.Days()).Sub(local.StartAt)
+	return &local
+}
+
+// NotifyAt вернет время напоминания о событии. Напоминание о событии на целый день
+// отсчитывается от местной полуночи пользователя с часовым поясом loc
+func (e *Event) NotifyAt(loc *time.Location) time.Time {
+	if !e.AllDay {
+		return e.StartAt.Add(-e.NotifyBefore)
+	}
+	return e.InLocation(loc).StartAt.Add(-e.NotifyBefore)
+}
+
+// Busy вернет true, если событие занимает время: оно непрозрачно и не отменено
+func (e *Event) Busy() bool {
+	return e.Transparency == Opaque && e.Status != StatusCancelled
+}
+
+// EndAt вернет время окончания события
+func (e *Event) EndAt() time.Time {
+	return e.StartAt.Add(e.Duration)
+}
+
+// Overlaps проверит, пересекается ли событие с интервалом [from, to)
+func (e *Event) Overlaps(from, to time.Time) bool {
+	return e.StartAt.Before(to) && (e.EndAt().After(from) || e.StartAt.Equal(from))
+}
+
+// Occurrences вернет экземпляры события, пересекающиеся с интервалом [from, to).
+// Для разового события это само событие, если оно попадает в интервал
+func (e *Event) Occurrences(from, to time.Time) ([]*Event, error) {
+	if e.RRule == "" {
+		if e.Overlaps(from, to) {
+			return []*Event{e}, nil
+		}
+		return nil, nil
+	}
+
+	rule, err := ParseRRule(e.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	var occurrences []*Event
+	rule.Starts(e.StartAt, to, func(start time.Time) {
+		if e.IsExcluded(start) {
+			return
+		}
+		occurrence := *e
+		occurrence.StartAt = start
+		occurrence.RecurrenceAt = start
+		if occurrence.Overlaps(from, to) {
+			occurrences = append(occurrences, &occurrence)
+		}
+	})
+
+	return occurrences, nil
+}
+
+// reminderSearchYears на сколько лет вперед ищется экземпляр серии, о котором напомнить
+const reminderSearchYears = 10
+
+// NextReminder вернет первый неотмененный экземпляр события, который начинается после after и напоминание
+// о котором не раньше notBefore, или nil, если такого нет. Серия разворачивается в часовом поясе loc, как при показе
+func (e *Event) NextReminder(loc *time.Location, after, notBefore time.Time) (*Event, error) {
+	local := e.InLocation(loc)
+	var end time.Time
+	if e.RRule != "" {
+		rule, err := ParseRRule(e.RRule)
+		if err != nil {
+			return nil, err
+		}
+		end = rule.End(local.StartAt, local.Duration)
+	}
+	// экземпляр начинается не раньше напоминания о нем
+	from := after
+	if notBefore.After(from) {
+		from = notBefore
+	}
+	// ищем по году, чтобы не разворачивать годы серии ради ближайшего экземпляра
+	for year := 0; year < reminderSearchYears; year++ {
+		to := from.AddDate(1, 0, 0)
+		occurrences, err := local.Occurrences(from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, occurrence := range occurrences {
+			if occurrence.StartAt.After(after) && !occurrence.NotifyAt(loc).Before(notBefore) {
+				return occurrence, nil
+			}
+		}
+		if !end.IsZero() && !to.Before(end) {
+			break
+		}
+		from = to
+	}
+	return nil, nil
+}
+
+// HasOccurrence проверит, что серия содержит неотмененный экземпляр, начинающийся в at
+func (e *Event) HasOccurrence(at time.Time) (bool, error) {
+	if e.RRule == "" {
+		return e.StartAt.Equal(at), nil
+	}
+
+	rule, err := ParseRRule(e.RRule)
+	if err != nil {
+		return false, err
+	}
+
+	var found bool
+	rule.Starts(e.StartAt, at.Add(time.Nanosecond), func(start time.Time) {
+		found = found || start.Equal(at)
+	})
+
+	return found && !e.IsExcluded(at), nil
+}
+
+// WithExDate вернет копию серии, в которой экземпляр at отменен
+func (e *Event) WithExDate(at time.Time) *Event {
+	series := *e
+	series.ExDates = append(append([]time.Time(nil), e.ExDates...), at)
+	return &series
+}
+
+// Truncate вернет копию серии, которая заканчивается перед экземпляром at
+func (e *Event) Truncate(at time.Time) (*Event, error) {
+	rule, err := ParseRRule(e.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	if rule.Count > 0 {
+		var count int
+		rule.Starts(e.StartAt, at, func(time.Time) {
+			count++
+		})
+		rule.Count = count
+	} else {
+		rule.Until = at.Add(-time.Second)
+	}
+
+	head := *e
+	head.RRule = rule.String()
+	return &head, nil
+}
+
+// IsExcluded вернет true, если экземпляр серии at отменен
+func (e *Event) IsExcluded(at time.Time) bool {
+	for _, exDate := range e.ExDates {
+		if exDate.Equal(at) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval промежуток времени [Start, End)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// MergeIntervals объединит пересекающиеся и смежные промежутки. Результат упорядочен по началу
+func MergeIntervals(intervals []Interval) []Interval {
+	sorted := append([]Interval(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	merged := make([]Interval, 0, len(sorted))
+	for _, interval := range sorted {
+		last := len(merged) - 1
+		if last >= 0 && !interval.Start.After(merged[last].End) {
+			if interval.End.After(merged[last].End) {
+				merged[last].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
@@ -0,0 +1,10 @@
+package models
+
+// OutboxMessage напоминание о событии, записанное в outbox в одной транзакции с событием.
+// Из outbox оно удаляется только после того, как брокер его принял
+type OutboxMessage struct {
+	ID        int64
+	EventUUID string `db:"event_uuid"`
+	// Payload JSON события на момент записи
+	Payload []byte
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// PageQuery выборка страницы разовых событий из хранилища
+type PageQuery struct {
+	User string
+	From time.Time
+	To   time.Time
+	// After ключ последнего события предыдущей страницы, нулевой для первой страницы
+	After PageKey
+	// Desc обратный порядок: от поздних событий к ранним
+	Desc  bool
+	Limit int
+}
+
+// Follows идет ли событие с ключом key после query.After в порядке выборки
+func (q *PageQuery) Follows(key PageKey) bool {
+	if q.After.IsZero() {
+		return true
+	}
+	if q.Desc {
+		return key.Less(q.After)
+	}
+	return q.After.Less(key)
+}
+
+// PageKey ключ сортировки событий в постраничной выдаче: начало, затем UUID
+type PageKey struct {
+	StartAt time.Time
+	UUID    string
+}
+
+// IsZero ключ не задан, выборка с начала
+func (k PageKey) IsZero() bool {
+	return k.StartAt.IsZero() && k.UUID == ""
+}
+
+// Less идет ли k раньше other по возрастанию. UUID сравниваются побайтово
+func (k PageKey) Less(other PageKey) bool {
+	if !k.StartAt.Equal(other.StartAt) {
+		return k.StartAt.Before(other.StartAt)
+	}
+	return k.UUID < other.UUID
+}
+
+// Key ключ сортировки события
+func (e *Event) Key() PageKey {
+	return PageKey{StartAt: e.StartAt, UUID: e.UUID}
+}
@@ -0,0 +1,277 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency частота повторения события
+type Frequency string
+
+// Поддерживаемые значения FREQ
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+	FreqYearly  Frequency = "YEARLY"
+)
+
+// ErrInvalidRRule правило повторения не удалось разобрать
+var ErrInvalidRRule = errors.New("invalid recurrence rule")
+
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var untilLayouts = []string{"20060102T150405Z", "20060102T150405", "20060102"}
+
+// RRule правило повторения события в духе RFC 5545.
+// Поддерживается подмножество FREQ, INTERVAL, BYDAY, COUNT и UNTIL
+type RRule struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	Count    int
+	Until    time.Time
+}
+
+// ParseRRule разбирает строку вида "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10"
+func ParseRRule(s string) (*RRule, error) {
+	r := &RRule{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(s), "RRULE:"), ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%w: bad part %q", ErrInvalidRRule, part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			switch r.Freq {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+			default:
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRRule, value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err != nil || r.Interval < 1 {
+				return nil, fmt.Errorf("%w: bad INTERVAL %q", ErrInvalidRRule, value)
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err != nil || r.Count < 1 {
+				return nil, fmt.Errorf("%w: bad COUNT %q", ErrInvalidRRule, value)
+			}
+		case "UNTIL":
+			r.Until, err = parseUntil(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: bad UNTIL %q", ErrInvalidRRule, value)
+			}
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := weekdayCodes[code]
+				if !ok {
+					return nil, fmt.Errorf("%w: bad BYDAY %q", ErrInvalidRRule, code)
+				}
+				r.ByDay = append(r.ByDay, day)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %q", ErrInvalidRRule, key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRRule)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL are mutually exclusive", ErrInvalidRRule)
+	}
+	if len(r.ByDay) > 0 && r.Freq != FreqDaily && r.Freq != FreqWeekly {
+		return nil, fmt.Errorf("%w: BYDAY is supported only for DAILY and WEEKLY", ErrInvalidRRule)
+	}
+
+	// дни недели держим в порядке от понедельника (WKST=MO)
+	sort.Slice(r.ByDay, func(i, j int) bool {
+		return weekdayOffset(r.ByDay[i]) < weekdayOffset(r.ByDay[j])
+	})
+
+	return r, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range untilLayouts {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if layout == "20060102" {
+			// дата без времени включает весь день
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		return t, nil
+	}
+	return time.Time{}, ErrInvalidRRule
+}
+
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, strings.ToUpper(day.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilLayouts[0]))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Starts вызывает fn для начала каждого экземпляра серии, начинающейся в dtstart,
+// по порядку и пока начало раньше to. DTSTART всегда считается первым экземпляром
+func (r *RRule) Starts(dtstart, to time.Time, fn func(start time.Time)) {
+	var n int
+	// emit вернет false, когда серия исчерпана
+	emit := func(t time.Time) bool {
+		if !t.Before(to) || (!r.Until.IsZero() && t.After(r.Until)) {
+			return false
+		}
+		fn(t)
+		n++
+		return r.Count == 0 || n < r.Count
+	}
+
+	if !emit(dtstart) {
+		return
+	}
+
+	interval := r.Interval
+	if interval < 1 {
+		interval = 1
+	}
+
+	// next вернет кандидатов i-го периода; пустой результат допустим
+	var next func(i int) []time.Time
+	switch r.Freq {
+	case FreqDaily:
+		next = func(i int) []time.Time {
+			t := dtstart.AddDate(0, 0, i*interval)
+			if len(r.ByDay) > 0 && !r.hasDay(t.Weekday()) {
+				return nil
+			}
+			return []time.Time{t}
+		}
+	case FreqWeekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{dtstart.Weekday()}
+		}
+		weekStart := dtstart.AddDate(0, 0, -weekdayOffset(dtstart.Weekday()))
+		next = func(i int) []time.Time {
+			ts := make([]time.Time, 0, len(days))
+			for _, day := range days {
+				ts = append(ts, weekStart.AddDate(0, 0, i*interval*7+weekdayOffset(day)))
+			}
+			return ts
+		}
+	case FreqMonthly:
+		next = func(i int) []time.Time {
+			return sameDay(dtstart, dtstart.Year(), int(dtstart.Month())+i*interval)
+		}
+	case FreqYearly:
+		next = func(i int) []time.Time {
+			return sameDay(dtstart, dtstart.Year()+i*interval, int(dtstart.Month()))
+		}
+	default:
+		return
+	}
+
+	for i := 0; ; i++ {
+		candidates := next(i)
+		for _, t := range candidates {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !emit(t) {
+				return
+			}
+		}
+		// периоды идут по возрастанию, поэтому за to можно не заглядывать
+		if len(candidates) == 0 && !firstOfPeriod(dtstart, r.Freq, i*interval).Before(to) {
+			return
+		}
+	}
+}
+
+// End вернет момент, после которого экземпляров серии гарантированно нет.
+// Для бесконечной серии вернет нулевое время
+func (r *RRule) End(dtstart time.Time, duration time.Duration) time.Time {
+	switch {
+	case !r.Until.IsZero():
+		return r.Until.Add(duration)
+	case r.Count > 0:
+		var last time.Time
+		r.Starts(dtstart, dtstart.AddDate(1000, 0, 0), func(start time.Time) {
+			last = start
+		})
+		return last.Add(duration)
+	}
+	return time.Time{}
+}
+
+func (r *RRule) hasDay(day time.Weekday) bool {
+	for _, d := range r.ByDay {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdayOffset номер дня от начала недели, неделя начинается в понедельник
+func weekdayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+// sameDay вернет день месяца dtstart в указанном месяце, если такой день в нем есть
+func sameDay(dtstart time.Time, year, month int) []time.Time {
+	t := time.Date(year, time.Month(month), dtstart.Day(),
+		dtstart.Hour(), dtstart.Minute(), dtstart.Second(), dtstart.Nanosecond(), dtstart.Location())
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, dtstart.Location())
+	if t.Month() != first.Month() {
+		return nil
+	}
+	return []time.Time{t}
+}
+
+func firstOfPeriod(dtstart time.Time, freq Frequency, n int) time.Time {
+	switch freq {
+	case FreqMonthly:
+		return time.Date(dtstart.Year(), dtstart.Month()+time.Month(n), 1, 0, 0, 0, 0, dtstart.Location())
+	case FreqYearly:
+		return time.Date(dtstart.Year()+n, time.January, 1, 0, 0, 0, 0, dtstart.Location())
+	}
+	return dtstart.AddDate(0, 0, n)
+}
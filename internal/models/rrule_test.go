@@ -0,0 +1,127 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRRule(t *testing.T) {
+	type testCase struct {
+		rule   string
+		exp    string
+		expErr bool
+	}
+
+	testCases := map[string]testCase{
+		"weekly with days":       {rule: "FREQ=WEEKLY;BYDAY=WE,MO;INTERVAL=2", exp: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		"with prefix":            {rule: "RRULE:FREQ=DAILY;COUNT=3", exp: "FREQ=DAILY;COUNT=3"},
+		"until date":             {rule: "FREQ=MONTHLY;UNTIL=20200331", exp: "FREQ=MONTHLY;UNTIL=20200331T235959Z"},
+		"no freq":                {rule: "COUNT=3", expErr: true},
+		"bad freq":               {rule: "FREQ=HOURLY", expErr: true},
+		"bad day":                {rule: "FREQ=WEEKLY;BYDAY=XX", expErr: true},
+		"count and until":        {rule: "FREQ=DAILY;COUNT=3;UNTIL=20200331", expErr: true},
+		"byday for monthly rule": {rule: "FREQ=MONTHLY;BYDAY=MO", expErr: true},
+	}
+
+	for k, v := range testCases {
+		t.Run(k, func(t *testing.T) {
+			rule, err := ParseRRule(v.rule)
+			if v.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, v.exp, rule.String())
+		})
+	}
+}
+
+func TestEvent_Occurrences(t *testing.T) {
+	type testCase struct {
+		event    Event
+		from, to time.Time
+		exp      []time.Time
+	}
+
+	// понедельник
+	start := time.Date(2020, time.March, 2, 10, 0, 0, 0, time.UTC)
+
+	testCases := make(map[string]testCase)
+
+	testCases["One-off event"] = testCase{
+		event: Event{StartAt: start, Duration: time.Hour},
+		from:  start.AddDate(0, 0, -1),
+		to:    start.AddDate(0, 0, 1),
+		exp:   []time.Time{start},
+	}
+	testCases["Daily with count"] = testCase{
+		event: Event{StartAt: start, Duration: time.Hour, RRule: "FREQ=DAILY;COUNT=3"},
+		from:  start,
+		to:    start.AddDate(0, 0, 7),
+		exp:   []time.Time{start, start.AddDate(0, 0, 1), start.AddDate(0, 0, 2)},
+	}
+	testCases["Weekly by days inside window"] = testCase{
+		event: Event{StartAt: start, Duration: time.Hour, RRule: "FREQ=WEEKLY;BYDAY=MO,TH"},
+		from:  start.AddDate(0, 0, 7),
+		to:    start.AddDate(0, 0, 14),
+		exp:   []time.Time{start.AddDate(0, 0, 7), start.AddDate(0, 0, 10)},
+	}
+	testCases["Every other week until"] = testCase{
+		event: Event{StartAt: start, Duration: time.Hour, RRule: "FREQ=WEEKLY;INTERVAL=2;UNTIL=20200320T000000Z"},
+		from:  start,
+		to:    start.AddDate(0, 1, 0),
+		exp:   []time.Time{start, start.AddDate(0, 0, 14)},
+	}
+	testCases["Monthly skips short months"] = testCase{
+		event: Event{StartAt: time.Date(2020, time.January, 31, 9, 0, 0, 0, time.UTC), Duration: time.Hour, RRule: "FREQ=MONTHLY;COUNT=3"},
+		from:  time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		to:    time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		exp: []time.Time{
+			time.Date(2020, time.January, 31, 9, 0, 0, 0, time.UTC),
+			time.Date(2020, time.March, 31, 9, 0, 0, 0, time.UTC),
+			time.Date(2020, time.May, 31, 9, 0, 0, 0, time.UTC),
+		},
+	}
+	testCases["Occurrence overlapping window start"] = testCase{
+		event: Event{StartAt: start, Duration: 3 * time.Hour, RRule: "FREQ=DAILY"},
+		from:  start.AddDate(0, 0, 1).Add(time.Hour),
+		to:    start.AddDate(0, 0, 1).Add(2 * time.Hour),
+		exp:   []time.Time{start.AddDate(0, 0, 1)},
+	}
+
+	for k, v := range testCases {
+		t.Run(k, func(t *testing.T) {
+			occurrences, err := v.event.Occurrences(v.from, v.to)
+			assert.NoError(t, err)
+
+			starts := make([]time.Time, 0, len(occurrences))
+			for _, o := range occurrences {
+				starts = append(starts, o.StartAt)
+			}
+			assert.Equal(t, v.exp, starts)
+		})
+	}
+}
+
+func TestEvent_InLocation(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	// выходные, на которые приходится переход на летнее время
+	weekend := Event{
+		StartAt:  time.Date(2020, time.March, 7, 0, 0, 0, 0, time.UTC),
+		Duration: 48 * time.Hour,
+		AllDay:   true,
+	}
+
+	local := weekend.InLocation(newYork)
+	assert.Equal(t, time.Date(2020, time.March, 7, 0, 0, 0, 0, newYork), local.StartAt)
+	assert.Equal(t, time.Date(2020, time.March, 9, 0, 0, 0, 0, newYork), local.EndAt())
+	assert.Equal(t, 2, local.Days())
+	assert.Equal(t, local, local.InLocation(newYork))
+
+	meeting := Event{StartAt: time.Date(2020, time.March, 7, 15, 0, 0, 0, time.UTC), Duration: time.Hour}
+	assert.True(t, meeting.StartAt.Equal(meeting.InLocation(newYork).StartAt))
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+)
+
+// Channel канал, по которому пользователю приходят напоминания
+type Channel string
+
+const (
+	// ChannelFile запись в файл рассыльщика, канал по умолчанию
+	ChannelFile Channel = "file"
+	// ChannelEmail письмо на User.Email
+	ChannelEmail Channel = "email"
+	// ChannelWebhook POST на User.WebhookURL
+	ChannelWebhook Channel = "webhook"
+)
+
+const (
+	// LocaleEnglish язык напоминаний по умолчанию
+	LocaleEnglish = "en"
+	// LocaleRussian ...
+	LocaleRussian = "ru"
+)
+
+// User профиль пользователя
+type User struct {
+	Name      string
+	Location  *time.Location // часовой пояс IANA
+	WeekStart time.Weekday   // первый день недели
+	// AllDayBusy занимают ли события на целый день время при проверке пересечений
+	AllDayBusy bool
+	Email      string
+	WebhookURL string
+	// Channels каналы напоминаний, пусто - только ChannelFile
+	Channels []Channel
+	// Locale язык напоминаний, пусто - LocaleEnglish
+	Locale string
+}
+
+// DefaultUser вернет профиль по умолчанию: UTC и неделя с понедельника
+func DefaultUser(name string) *User {
+	return &User{
+		Name:      name,
+		Location:  time.UTC,
+		WeekStart: time.Monday,
+	}
+}
+
+// Day вернет границы локального дня, в который попадает t
+func (u *User) Day(t time.Time) (time.Time, time.Time) {
+	local := t.In(u.Location)
+	from := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, u.Location)
+	return from, from.AddDate(0, 0, 1)
+}
+
+// Week вернет границы локальной недели, в которую попадает t, с учетом первого дня недели
+func (u *User) Week(t time.Time) (time.Time, time.Time) {
+	day, _ := u.Day(t)
+	from := day.AddDate(0, 0, -((int(day.Weekday())-int(u.WeekStart))+7)%7)
+	return from, from.AddDate(0, 0, 7)
+}
+
+// Month вернет границы локального месяца, в который попадает t
+func (u *User) Month(t time.Time) (time.Time, time.Time) {
+	local := t.In(u.Location)
+	from := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, u.Location)
+	return from, from.AddDate(0, 1, 0)
+}
+
+// Language вернет язык напоминаний пользователя с учетом языка по умолчанию
+func (u *User) Language() string {
+	if u.Locale == "" {
+		return LocaleEnglish
+	}
+	return u.Locale
+}
+
+// NotifyChannels вернет каналы напоминаний пользователя с учетом канала по умолчанию
+func (u *User) NotifyChannels() []Channel {
+	if len(u.Channels) == 0 {
+		return []Channel{ChannelFile}
+	}
+	return u.Channels
+}
@@ -0,0 +1,49 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// File дописывает напоминания в файл, по одному JSON в строке
+type File struct {
+	mu        sync.Mutex
+	file      *os.File
+	templates *Templates
+}
+
+// NewFile откроет файл path для дописывания, создав его при необходимости
+func NewFile(path string, templates *Templates) (*File, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &File{file: file, templates: templates}, nil
+}
+
+// Notify ...
+func (f *File) Notify(_ context.Context, user *models.User, e *models.Event) error {
+	notification, err := newNotification(f.templates, models.ChannelFile, user, e)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	// строка пишется одним вызовом, чтобы строки параллельных рассыльщиков не перемешались
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, err = f.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close ...
+func (f *File) Close() error {
+	return f.file.Close()
+}
@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// unit единица времени с формами слова: английские единственное и множественное число,
+// русские формы для 1, 2-4 и 5-20 после "через" и перед "назад"
+type unit struct {
+	size time.Duration
+	en   [2]string
+	ru   [3]string
+}
+
+var units = []unit{
+	{24 * time.Hour, [2]string{"day", "days"}, [3]string{"день", "дня", "дней"}},
+	{time.Hour, [2]string{"hour", "hours"}, [3]string{"час", "часа", "часов"}},
+	{time.Minute, [2]string{"minute", "minutes"}, [3]string{"минуту", "минуты", "минут"}},
+}
+
+// humanize скажет на языке locale, через сколько наступит момент: "in 15 minutes", "через 2 часа".
+// Время округляется до минут, а большие промежутки до часов и дней, чтобы опоздание
+// рассылки на несколько секунд не превращало "15 минут" в "14 минут"
+func humanize(locale string, d time.Duration) string {
+	past := d < 0
+	if past {
+		d = -d
+	}
+	d = d.Round(time.Minute)
+	if d == 0 {
+		if locale == models.LocaleRussian {
+			return "сейчас"
+		}
+		return "now"
+	}
+
+	u := units[len(units)-1]
+	for _, candidate := range units {
+		if d >= candidate.size {
+			u = candidate
+			break
+		}
+	}
+	n := int(d.Round(u.size) / u.size)
+
+	if locale == models.LocaleRussian {
+		amount := fmt.Sprintf("%d %s", n, u.ru[russianPlural(n)])
+		if past {
+			return amount + " назад"
+		}
+		return "через " + amount
+	}
+
+	word := u.en[1]
+	if n == 1 {
+		word = u.en[0]
+	}
+	if past {
+		return fmt.Sprintf("%d %s ago", n, word)
+	}
+	return fmt.Sprintf("in %d %s", n, word)
+}
+
+// russianPlural номер формы слова для числа n: 0 - "минуту", 1 - "минуты", 2 - "минут"
+func russianPlural(n int) int {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return 0
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return 1
+	}
+	return 2
+}
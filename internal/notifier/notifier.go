@@ -0,0 +1,101 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// ErrNotConfigured пользователь выбрал канал, который рассыльщику не настроили
+var ErrNotConfigured = errors.New("notification channel is not configured")
+
+// Notifier доставляет напоминание о событии пользователю по одному каналу
+type Notifier interface {
+	Notify(ctx context.Context, user *models.User, e *models.Event) error
+}
+
+// Router рассылает напоминание по каналам, выбранным пользователем
+type Router struct {
+	notifiers map[models.Channel]Notifier
+}
+
+// NewRouter создает рассылку по каналам notifiers
+func NewRouter(notifiers map[models.Channel]Notifier) *Router {
+	return &Router{
+		notifiers: notifiers,
+	}
+}
+
+// DeliveryError напоминание доставлено не во все каналы. Повтор рассылки нужен только
+// в неудавшиеся каналы, иначе удавшиеся получат напоминание еще раз
+type DeliveryError struct {
+	// Delivered каналы, в которые напоминание доставлено
+	Delivered []models.Channel
+	failed    []string
+}
+
+func (e *DeliveryError) Error() string {
+	return strings.Join(e.failed, "; ")
+}
+
+// Notify отправит напоминание во все каналы пользователя, даже если какой-то из них не сработал.
+// Ошибка *DeliveryError перечисляет неудавшиеся каналы и удавшиеся
+func (r *Router) Notify(ctx context.Context, user *models.User, e *models.Event) error {
+	result := &DeliveryError{}
+	for _, channel := range user.NotifyChannels() {
+		notifier, ok := r.notifiers[channel]
+		if !ok {
+			result.failed = append(result.failed, fmt.Sprintf("%s: %v", channel, ErrNotConfigured))
+			continue
+		}
+		if err := notifier.Notify(ctx, user, e); err != nil {
+			result.failed = append(result.failed, fmt.Sprintf("%s: %v", channel, err))
+			continue
+		}
+		result.Delivered = append(result.Delivered, channel)
+	}
+
+	if len(result.failed) > 0 {
+		return result
+	}
+	return nil
+}
+
+// Notification напоминание в JSON, так его получают webhook и файл
+type Notification struct {
+	User        string `json:"user"`
+	UUID        string `json:"uuid"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	// StartAt начало события в часовом поясе пользователя
+	StartAt time.Time `json:"startAt"`
+	EndAt   time.Time `json:"endAt"`
+	AllDay  bool      `json:"allDay,omitempty"`
+	// Text напоминание для людей по шаблону канала на языке пользователя
+	Text string `json:"text"`
+}
+
+// newNotification соберет напоминание с текстом по шаблону канала channel
+func newNotification(templates *Templates, channel models.Channel, user *models.User, e *models.Event) (*Notification, error) {
+	text, err := templates.Render(channel, PartText, user, e, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// событие на целый день начинается в местную полночь пользователя
+	local := e.InLocation(user.Location)
+	return &Notification{
+		User:        e.User,
+		UUID:        e.UUID,
+		Title:       e.Title,
+		Description: e.Description,
+		StartAt:     local.StartAt,
+		EndAt:       local.EndAt(),
+		AllDay:      e.AllDay,
+		Text:        text,
+	}, nil
+}
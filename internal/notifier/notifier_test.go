@@ -0,0 +1,123 @@
+package notifier_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/bobrovka/calendar/internal/notifier"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder запоминает, кому отправлены напоминания, и возвращает err
+type recorder struct {
+	users []string
+	err   error
+}
+
+func (r *recorder) Notify(_ context.Context, user *models.User, _ *models.Event) error {
+	r.users = append(r.users, user.Name)
+	return r.err
+}
+
+func TestRouter_Notify(t *testing.T) {
+	type testCase struct {
+		channels   []models.Channel
+		expFile    []string
+		expEmail   []string
+		expWebhook []string
+		expErr     string
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["default channel"] = testCase{
+		expFile: []string{"Kira"},
+	}
+	testCases["chosen channels"] = testCase{
+		channels:   []models.Channel{models.ChannelEmail, models.ChannelWebhook},
+		expEmail:   []string{"Kira"},
+		expWebhook: []string{"Kira"},
+	}
+	testCases["failed channel does not stop others"] = testCase{
+		channels:   []models.Channel{models.ChannelEmail, models.ChannelFile, models.ChannelWebhook},
+		expFile:    []string{"Kira"},
+		expEmail:   []string{"Kira"},
+		expWebhook: []string{"Kira"},
+		expErr:     "webhook: connection refused",
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			file, email, webhook := &recorder{}, &recorder{}, &recorder{err: errors.New("connection refused")}
+			if tc.expErr == "" {
+				webhook.err = nil
+			}
+			router := notifier.NewRouter(map[models.Channel]notifier.Notifier{
+				models.ChannelFile:    file,
+				models.ChannelEmail:   email,
+				models.ChannelWebhook: webhook,
+			})
+
+			user := &models.User{Name: "Kira", Location: time.UTC, Channels: tc.channels}
+			err := router.Notify(context.Background(), user, &models.Event{Title: "standup"})
+			if tc.expErr != "" {
+				assert.EqualError(t, err, tc.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expFile, file.users)
+			assert.Equal(t, tc.expEmail, email.users)
+			assert.Equal(t, tc.expWebhook, webhook.users)
+		})
+	}
+}
+
+func TestRouter_NotConfigured(t *testing.T) {
+	router := notifier.NewRouter(map[models.Channel]notifier.Notifier{})
+	user := &models.User{Name: "Kira", Location: time.UTC, Channels: []models.Channel{models.ChannelEmail}}
+	err := router.Notify(context.Background(), user, &models.Event{Title: "standup"})
+	assert.EqualError(t, err, "email: notification channel is not configured")
+}
+
+func TestFile_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+
+	file, err := notifier.NewFile(path, notifier.DefaultTemplates())
+	assert.NoError(t, err)
+	user := &models.User{Name: "Kira", Location: time.UTC}
+	start := time.Date(2030, time.March, 4, 9, 0, 0, 0, time.UTC)
+	assert.NoError(t, file.Notify(context.Background(), user, &models.Event{UUID: "1", Title: "standup", StartAt: start, Duration: time.Hour, User: "Kira"}))
+	assert.NoError(t, file.Notify(context.Background(), user, &models.Event{UUID: "2", Title: "review", StartAt: start, Duration: time.Hour, User: "Kira"}))
+	assert.NoError(t, file.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasPrefix(lines[0], `{"user":"Kira","uuid":"1","title":"standup","startAt":"2030-03-04T09:00:00Z","endAt":"2030-03-04T10:00:00Z","text":"standup starts in `), lines[0])
+		assert.True(t, strings.HasSuffix(lines[1], `, Mon, Mar 4 at 09:00 UTC"}`), lines[1])
+	}
+}
+
+func TestFile_NotifyAllDay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	file, err := notifier.NewFile(path, notifier.DefaultTemplates())
+	assert.NoError(t, err)
+
+	// событие на целый день хранится полуночью UTC, а западнее UTC это еще предыдущий день
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	user := &models.User{Name: "Kira", Location: newYork}
+	start := time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, file.Notify(context.Background(), user, &models.Event{UUID: "1", Title: "vacation", StartAt: start, Duration: 48 * time.Hour, AllDay: true, User: "Kira"}))
+	assert.NoError(t, file.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"startAt":"2030-03-04T00:00:00-05:00","endAt":"2030-03-06T00:00:00-05:00","allDay":true`)
+}
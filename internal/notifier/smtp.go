@@ -0,0 +1,155 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// SMTP отправляет напоминание письмом на User.Email через SMTP-сервер.
+// Письмо содержит текстовую и HTML-версии
+type SMTP struct {
+	addr      string
+	host      string
+	from      string
+	auth      smtp.Auth
+	templates *Templates
+}
+
+// NewSMTP создает отправку писем через сервер addr (host:port) от имени from.
+// Без username сервер должен принимать письма без аутентификации
+func NewSMTP(addr, from, username, password string, templates *Templates) (*SMTP, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SMTP{
+		addr:      addr,
+		host:      host,
+		from:      from,
+		templates: templates,
+	}
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s, nil
+}
+
+// Notify ...
+func (s *SMTP) Notify(ctx context.Context, user *models.User, e *models.Event) error {
+	if user.Email == "" {
+		return fmt.Errorf("user %s has no email", user.Name)
+	}
+	letter, err := s.letter(user, e)
+	if err != nil {
+		return err
+	}
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	// net/smtp не знает о контексте, поэтому срок переносится на соединение
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(user.Email); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(letter); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// letter письмо с заголовками и частями text/plain и text/html по шаблонам пользователя
+func (s *SMTP) letter(user *models.User, e *models.Event) ([]byte, error) {
+	now := time.Now()
+	parts := make(map[string]string)
+	for _, part := range []string{PartSubject, PartText, PartHTML} {
+		rendered, err := s.templates.Render(models.ChannelEmail, part, user, e, now)
+		if err != nil {
+			return nil, err
+		}
+		parts[part] = rendered
+	}
+
+	var b bytes.Buffer
+	body := multipart.NewWriter(&b)
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", user.Email)
+	// перевод строки в теме разорвал бы заголовок
+	subject := strings.Join(strings.Fields(parts[PartSubject]), " ")
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%s\r\n", body.Boundary())
+	b.WriteString("\r\n")
+
+	// почтовые программы показывают последнюю из частей, которую умеют
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", parts[PartText]},
+		{"text/html; charset=utf-8", parts[PartHTML]},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
@@ -0,0 +1,143 @@
+package notifier_test
+
+import (
+	"context"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/bobrovka/calendar/internal/notifier"
+	"github.com/stretchr/testify/assert"
+)
+
+// mail письмо, принятое тестовым SMTP-сервером
+type mail struct {
+	from string
+	to   []string
+	data string
+}
+
+// smtpServer поднимает на локальном порту SMTP-сервер, принимающий одно письмо без TLS и аутентификации
+func smtpServer(t *testing.T) (string, <-chan *mail) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan *mail, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		text := textproto.NewConn(conn)
+		m := &mail{}
+		_ = text.PrintfLine("220 localhost ESMTP test")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				_ = text.PrintfLine("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				m.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				_ = text.PrintfLine("250 OK")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				m.to = append(m.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				_ = text.PrintfLine("250 OK")
+			case command == "DATA":
+				_ = text.PrintfLine("354 go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				m.data = string(data)
+				_ = text.PrintfLine("250 queued")
+			case command == "QUIT":
+				_ = text.PrintfLine("221 bye")
+				received <- m
+				return
+			default:
+				_ = text.PrintfLine("502 not implemented")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestSMTP_Notify(t *testing.T) {
+	addr, received := smtpServer(t)
+	sender, err := notifier.NewSMTP(addr, "calendar@example.com", "", "", notifier.DefaultTemplates())
+	assert.NoError(t, err)
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+	user := &models.User{Name: "Kira", Location: moscow, Email: "kira@example.com", Locale: models.LocaleRussian}
+	event := &models.Event{
+		UUID:        "1",
+		Title:       "Планёрка",
+		Description: "<room 4>",
+		StartAt:     time.Date(2030, time.March, 4, 6, 0, 0, 0, time.UTC),
+		Duration:    time.Hour,
+		User:        "Kira",
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, sender.Notify(ctx, user, event))
+
+	var m *mail
+	select {
+	case m = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("no mail")
+	}
+	assert.Equal(t, "calendar@example.com", m.from)
+	assert.Equal(t, []string{"kira@example.com"}, m.to)
+
+	letter, err := netmail.ReadMessage(strings.NewReader(m.data))
+	assert.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(letter.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(subject, "Напоминание: Планёрка через "), subject)
+
+	_, params, err := mime.ParseMediaType(letter.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	parts := make(map[string]string)
+	reader := multipart.NewReader(letter.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		// quoted-printable части multipart.Reader декодирует сам
+		content, err := ioutil.ReadAll(part)
+		assert.NoError(t, err)
+		parts[part.Header.Get("Content-Type")] = string(content)
+	}
+
+	// время письма в часовом поясе пользователя, описание в HTML экранировано
+	assert.Contains(t, parts["text/plain; charset=utf-8"], ", 04.03.2030 в 09:00 Europe/Moscow.\n\n<room 4>\n")
+	assert.Contains(t, parts["text/html; charset=utf-8"], "<p><b>Планёрка</b> начнется через ")
+	assert.Contains(t, parts["text/html; charset=utf-8"], "<p>&lt;room 4&gt;</p>")
+}
+
+func TestSMTP_NoEmail(t *testing.T) {
+	sender, err := notifier.NewSMTP("127.0.0.1:25", "calendar@example.com", "", "", notifier.DefaultTemplates())
+	assert.NoError(t, err)
+	err = sender.Notify(context.Background(), &models.User{Name: "Kira", Location: time.UTC}, &models.Event{Title: "standup"})
+	assert.EqualError(t, err, "user Kira has no email")
+}
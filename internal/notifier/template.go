@@ -0,0 +1,176 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+const (
+	// PartSubject тема письма
+	PartSubject = "subject"
+	// PartText текст сообщения
+	PartText = "text"
+	// PartHTML текст письма в HTML
+	PartHTML = "html"
+)
+
+// defaultTemplates встроенные шаблоны, ключ - <канал>.<язык>.<часть>
+var defaultTemplates = map[string]string{
+	"email.en.subject": `Reminder: {{.Event.Title}} {{.In}}`,
+	"email.en.text": `{{.Event.Title}} starts {{.In}}, {{template "when.en" .}}.
+{{with .Event.Description}}
+{{.}}
+{{end}}`,
+	"email.en.html": `<p><b>{{.Event.Title}}</b> starts {{.In}}, {{template "when.en" .}}.</p>
+{{with .Event.Description}}<p>{{.}}</p>
+{{end}}`,
+	"webhook.en.text": `{{.Event.Title}} starts {{.In}}, {{template "when.en" .}}`,
+	"file.en.text":    `{{.Event.Title}} starts {{.In}}, {{template "when.en" .}}`,
+
+	"email.ru.subject": `Напоминание: {{.Event.Title}} {{.In}}`,
+	"email.ru.text": `{{.Event.Title}} начнется {{.In}}, {{template "when.ru" .}}.
+{{with .Event.Description}}
+{{.}}
+{{end}}`,
+	"email.ru.html": `<p><b>{{.Event.Title}}</b> начнется {{.In}}, {{template "when.ru" .}}.</p>
+{{with .Event.Description}}<p>{{.}}</p>
+{{end}}`,
+	"webhook.ru.text": `{{.Event.Title}} начнется {{.In}}, {{template "when.ru" .}}`,
+	"file.ru.text":    `{{.Event.Title}} начнется {{.In}}, {{template "when.ru" .}}`,
+}
+
+// commonTemplates вспомогательные шаблоны, доступные из всех остальных
+const commonTemplates = `
+{{- define "when.en"}}{{if .Event.AllDay}}{{.Start.Format "Mon, Jan 2"}}, all day{{else}}{{.Start.Format "Mon, Jan 2 at 15:04"}} {{.TimeZone}}{{end}}{{end}}
+{{- define "when.ru"}}{{if .Event.AllDay}}{{.Start.Format "02.01.2006"}}, весь день{{else}}{{.Start.Format "02.01.2006 в 15:04"}} {{.TimeZone}}{{end}}{{end}}`
+
+// MessageData данные, которые получают шаблоны
+type MessageData struct {
+	Event *models.Event
+	User  *models.User
+	// Start и End время события в часовом поясе пользователя
+	Start time.Time
+	End   time.Time
+	// TimeZone часовой пояс пользователя, например Europe/Moscow
+	TimeZone string
+	// In когда начнется событие на языке пользователя, например "in 15 minutes"
+	In string
+}
+
+// Templates шаблоны сообщений по каналам и языкам. HTML-части разбираются html/template,
+// остальные text/template. Если для языка пользователя шаблона нет, берется английский
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+// DefaultTemplates вернет встроенные шаблоны
+func DefaultTemplates() *Templates {
+	t, err := parseTemplates(defaultTemplates)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// LoadTemplates вернет встроенные шаблоны, замененные файлами <канал>.<язык>.<часть>.tmpl
+// из каталога dir, например email.ru.html.tmpl. Пустой dir - только встроенные
+func LoadTemplates(dir string) (*Templates, error) {
+	sources := make(map[string]string, len(defaultTemplates))
+	for name, source := range defaultTemplates {
+		sources[name] = source
+	}
+
+	if dir != "" {
+		files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+			if len(strings.Split(name, ".")) != 3 {
+				return nil, fmt.Errorf("template %s: name must be <channel>.<locale>.<part>.tmpl", file)
+			}
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			sources[name] = string(data)
+		}
+	}
+
+	return parseTemplates(sources)
+}
+
+func parseTemplates(sources map[string]string) (*Templates, error) {
+	t := &Templates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+	for name, source := range sources {
+		if strings.HasSuffix(name, "."+PartHTML) {
+			tmpl, err := htmltemplate.New(name).Parse(commonTemplates + source)
+			if err != nil {
+				return nil, err
+			}
+			t.html[name] = tmpl
+			continue
+		}
+		tmpl, err := texttemplate.New(name).Parse(commonTemplates + source)
+		if err != nil {
+			return nil, err
+		}
+		t.text[name] = tmpl
+	}
+	return t, nil
+}
+
+// Render заполнит шаблон части part канала channel на языке пользователя.
+// now - момент отправки, от него отсчитывается MessageData.In
+func (t *Templates) Render(channel models.Channel, part string, user *models.User, e *models.Event, now time.Time) (string, error) {
+	locale := user.Language()
+	name := fmt.Sprintf("%s.%s.%s", channel, locale, part)
+	if !t.has(name) {
+		locale = models.LocaleEnglish
+		name = fmt.Sprintf("%s.%s.%s", channel, locale, part)
+	}
+
+	// событие на целый день начинается в местную полночь пользователя
+	local := e.InLocation(user.Location)
+	data := &MessageData{
+		Event:    e,
+		User:     user,
+		Start:    local.StartAt,
+		End:      local.EndAt(),
+		TimeZone: user.Location.String(),
+		In:       humanize(locale, local.StartAt.Sub(now)),
+	}
+
+	var b bytes.Buffer
+	var err error
+	if tmpl, ok := t.html[name]; ok {
+		err = tmpl.Execute(&b, data)
+	} else if tmpl, ok := t.text[name]; ok {
+		err = tmpl.Execute(&b, data)
+	} else {
+		return "", fmt.Errorf("no template %s", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (t *Templates) has(name string) bool {
+	_, text := t.text[name]
+	_, html := t.html[name]
+	return text || html
+}
@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHumanize(t *testing.T) {
+	type testCase struct {
+		locale string
+		d      time.Duration
+		exp    string
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["minutes"] = testCase{models.LocaleEnglish, 15 * time.Minute, "in 15 minutes"}
+	testCases["sent a few seconds late"] = testCase{models.LocaleEnglish, 14*time.Minute + 57*time.Second, "in 15 minutes"}
+	testCases["one minute"] = testCase{models.LocaleEnglish, time.Minute, "in 1 minute"}
+	testCases["now"] = testCase{models.LocaleEnglish, 10 * time.Second, "now"}
+	testCases["hours"] = testCase{models.LocaleEnglish, 2 * time.Hour, "in 2 hours"}
+	testCases["days"] = testCase{models.LocaleEnglish, 72 * time.Hour, "in 3 days"}
+	testCases["past"] = testCase{models.LocaleEnglish, -5 * time.Minute, "5 minutes ago"}
+	testCases["ru one"] = testCase{models.LocaleRussian, 21 * time.Minute, "через 21 минуту"}
+	testCases["ru few"] = testCase{models.LocaleRussian, 3 * time.Hour, "через 3 часа"}
+	testCases["ru many"] = testCase{models.LocaleRussian, 15 * time.Minute, "через 15 минут"}
+	testCases["ru teens"] = testCase{models.LocaleRussian, 11 * 24 * time.Hour, "через 11 дней"}
+	testCases["ru past"] = testCase{models.LocaleRussian, -2 * 24 * time.Hour, "2 дня назад"}
+	testCases["ru now"] = testCase{models.LocaleRussian, 0, "сейчас"}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.exp, humanize(tc.locale, tc.d))
+		})
+	}
+}
+
+func TestTemplates_Render(t *testing.T) {
+	type testCase struct {
+		channel models.Channel
+		part    string
+		locale  string
+		// location часовой пояс пользователя, по умолчанию Europe/Moscow
+		location *time.Location
+		event    models.Event
+		exp      string
+	}
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+	chicago, err := time.LoadLocation("America/Chicago")
+	assert.NoError(t, err)
+	start := time.Date(2030, time.March, 4, 6, 0, 0, 0, time.UTC)
+	now := start.Add(-15 * time.Minute)
+	standup := models.Event{Title: "standup", StartAt: start, Duration: 15 * time.Minute}
+	// событие на целый день хранится полуночью UTC, в Чикаго 4 марта начнется в 06:00 UTC
+	vacation := models.Event{Title: "отпуск", StartAt: time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC), Duration: 24 * time.Hour, AllDay: true}
+
+	testCases := make(map[string]testCase)
+	testCases["english file"] = testCase{
+		channel: models.ChannelFile, part: PartText, locale: models.LocaleEnglish, event: standup,
+		exp: "standup starts in 15 minutes, Mon, Mar 4 at 09:00 Europe/Moscow",
+	}
+	testCases["default locale"] = testCase{
+		channel: models.ChannelWebhook, part: PartText, event: standup,
+		exp: "standup starts in 15 minutes, Mon, Mar 4 at 09:00 Europe/Moscow",
+	}
+	testCases["russian subject"] = testCase{
+		channel: models.ChannelEmail, part: PartSubject, locale: models.LocaleRussian, event: standup,
+		exp: "Напоминание: standup через 15 минут",
+	}
+	testCases["russian all day west of UTC"] = testCase{
+		channel: models.ChannelFile, part: PartText, locale: models.LocaleRussian, location: chicago, event: vacation,
+		exp: "отпуск начнется через 15 минут, 04.03.2030, весь день",
+	}
+	testCases["html is escaped"] = testCase{
+		channel: models.ChannelEmail, part: PartHTML, locale: models.LocaleEnglish,
+		event: models.Event{Title: "<b>", StartAt: start, Duration: time.Hour},
+		exp:   "<p><b>&lt;b&gt;</b> starts in 15 minutes, Mon, Mar 4 at 09:00 Europe/Moscow.</p>\n",
+	}
+
+	templates := DefaultTemplates()
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			user := &models.User{Name: "Kira", Location: moscow, Locale: tc.locale}
+			if tc.location != nil {
+				user.Location = tc.location
+			}
+			text, err := templates.Render(tc.channel, tc.part, user, &tc.event, now)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.exp, text)
+		})
+	}
+}
+
+func TestLoadTemplates(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "file.ru.text.tmpl"), []byte(`{{.Event.Title}}: {{.In}} ({{.User.Name}})`), 0644))
+
+	templates, err := LoadTemplates(dir)
+	assert.NoError(t, err)
+	user := &models.User{Name: "Kira", Location: time.UTC, Locale: models.LocaleRussian}
+	start := time.Date(2030, time.March, 4, 6, 0, 0, 0, time.UTC)
+	event := &models.Event{Title: "standup", StartAt: start, Duration: time.Hour}
+
+	text, err := templates.Render(models.ChannelFile, PartText, user, event, start.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "standup: через 1 час (Kira)", text)
+
+	// остальные шаблоны остаются встроенными
+	text, err = templates.Render(models.ChannelWebhook, PartText, user, event, start.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "standup начнется через 1 час, 04.03.2030 в 06:00 UTC", text)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "broken.tmpl"), []byte(`{{.Event.Title}}`), 0644))
+	_, err = LoadTemplates(dir)
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/egress"
+	"github.com/bobrovka/calendar/internal/models"
+)
+
+// Webhook отправляет напоминание POST-запросом с Notification в JSON на User.WebhookURL
+type Webhook struct {
+	client    *http.Client
+	templates *Templates
+}
+
+// NewWebhook создает отправку на webhook, ждущую ответа не дольше timeout.
+// Адрес проверяется по policy при каждом соединении, в том числе после перенаправления
+func NewWebhook(timeout time.Duration, templates *Templates, policy *egress.Policy) *Webhook {
+	transport := &http.Transport{
+		DialContext:         policy.DialContext(&net.Dialer{Timeout: timeout}),
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &Webhook{
+		client:    &http.Client{Timeout: timeout, Transport: transport},
+		templates: templates,
+	}
+}
+
+// Notify ...
+func (w *Webhook) Notify(ctx context.Context, user *models.User, e *models.Event) error {
+	if user.WebhookURL == "" {
+		return fmt.Errorf("user %s has no webhook url", user.Name)
+	}
+
+	notification, err := newNotification(w.templates, models.ChannelWebhook, user, e)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, user.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	// дочитанное тело позволяет переиспользовать соединение
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(response.Body, 1<<16))
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", response.Status)
+	}
+	return nil
+}
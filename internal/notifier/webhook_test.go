@@ -0,0 +1,99 @@
+package notifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/egress"
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/bobrovka/calendar/internal/notifier"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_Notify(t *testing.T) {
+	type testCase struct {
+		status int
+		expErr string
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["accepted"] = testCase{
+		status: http.StatusNoContent,
+	}
+	testCases["server error"] = testCase{
+		status: http.StatusBadGateway,
+		expErr: "webhook responded 502 Bad Gateway",
+	}
+
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	assert.NoError(t, err)
+	event := &models.Event{
+		UUID:     "1",
+		Title:    "standup",
+		StartAt:  time.Date(2030, time.March, 4, 6, 0, 0, 0, time.UTC),
+		Duration: 15 * time.Minute,
+		User:     "Kira",
+	}
+
+	// тестовый сервер слушает loopback, который по умолчанию запрещен
+	policy, err := egress.NewPolicy([]string{"127.0.0.1"})
+	assert.NoError(t, err)
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			received := make(chan *notifier.Notification, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				var n notifier.Notification
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+				received <- &n
+				w.WriteHeader(tc.status)
+			}))
+			defer server.Close()
+
+			user := &models.User{Name: "Kira", Location: moscow, WebhookURL: server.URL + "/hook", Locale: models.LocaleRussian}
+			err := notifier.NewWebhook(time.Second, notifier.DefaultTemplates(), policy).Notify(context.Background(), user, event)
+			if tc.expErr != "" {
+				assert.EqualError(t, err, tc.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			n := <-received
+			assert.Equal(t, "standup", n.Title)
+			assert.Equal(t, "2030-03-04T09:00:00+03:00", n.StartAt.Format(time.RFC3339))
+			assert.Equal(t, "2030-03-04T09:15:00+03:00", n.EndAt.Format(time.RFC3339))
+			assert.True(t, strings.HasSuffix(n.Text, ", 04.03.2030 в 09:00 Europe/Moscow"), n.Text)
+			assert.True(t, strings.HasPrefix(n.Text, "standup начнется через "), n.Text)
+		})
+	}
+}
+
+func TestWebhook_NotifyRejectsInternalAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	policy, err := egress.NewPolicy(nil)
+	assert.NoError(t, err)
+
+	// имя хоста прошло бы проверку профиля, но разрешается в loopback
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	assert.NoError(t, err)
+	user := &models.User{Name: "Kira", Location: time.UTC, WebhookURL: "http://localhost:" + port + "/hook"}
+	event := &models.Event{UUID: "1", Title: "standup", StartAt: time.Now().Add(time.Hour), Duration: time.Minute, User: "Kira"}
+
+	err = notifier.NewWebhook(time.Second, notifier.DefaultTemplates(), policy).Notify(context.Background(), user, event)
+	assert.True(t, errors.Is(err, egress.ErrForbiddenHost), "unexpected error %v", err)
+	assert.False(t, called)
+}
@@ -0,0 +1,193 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrAcknowledged сообщение уже подтверждено, отложено или переложено в очередь недоставленных
+var ErrAcknowledged = errors.New("delivery is already acknowledged")
+
+// Broker брокер в памяти процесса: одна очередь на буферизованном канале, подписчики
+// разбирают ее конкурентно. Нужен там, где нет RabbitMQ, например в тестах
+type Broker struct {
+	queue      chan *message
+	retryDelay time.Duration
+	mu         sync.Mutex
+	dead       []*DeadLetter
+}
+
+type message struct {
+	body    []byte
+	attempt int
+	done    []string
+}
+
+// NewBroker создает брокер с очередью на size сообщений. Publish ждет, пока в очереди не появится место.
+// Отложенное сообщение возвращается в очередь через retryDelay, с каждой попыткой пауза удваивается.
+// Если очередь так и не освободилась за ту же паузу, сообщение уходит в очередь недоставленных
+func NewBroker(size int, retryDelay time.Duration) *Broker {
+	return &Broker{
+		queue:      make(chan *message, size),
+		retryDelay: retryDelay,
+	}
+}
+
+// Publish ...
+func (b *Broker) Publish(ctx context.Context, body []byte) error {
+	// публикующий может переиспользовать буфер
+	return b.put(ctx, &message{body: append([]byte(nil), body...), attempt: 1})
+}
+
+func (b *Broker) put(ctx context.Context, m *message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case b.queue <- m:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscribe ...
+func (b *Broker) Subscribe(ctx context.Context, workers int, handler Handler) error {
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case m := <-b.queue:
+					handler(ctx, &delivery{broker: b, message: m})
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+// bury положит сообщение в очередь недоставленных
+func (b *Broker) bury(letter *DeadLetter) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.dead = append(b.dead, letter)
+}
+
+// Len число сообщений, ждущих в очереди
+func (b *Broker) Len() int {
+	return len(b.queue)
+}
+
+// DeadLetters ...
+func (b *Broker) DeadLetters(_ context.Context, limit int) ([]*DeadLetter, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if limit > len(b.dead) {
+		limit = len(b.dead)
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	var result []*DeadLetter
+	for _, d := range b.dead[:limit] {
+		letter := *d
+		result = append(result, &letter)
+	}
+	return result, nil
+}
+
+// Replay ...
+func (b *Broker) Replay(ctx context.Context, limit int) (int, error) {
+	replayed := 0
+	for ; replayed < limit; replayed++ {
+		b.mu.Lock()
+		if len(b.dead) == 0 {
+			b.mu.Unlock()
+			break
+		}
+		d := b.dead[0]
+		b.dead = b.dead[1:]
+		b.mu.Unlock()
+
+		if err := b.put(ctx, &message{body: d.Body, attempt: 1}); err != nil {
+			b.mu.Lock()
+			b.dead = append([]*DeadLetter{d}, b.dead...)
+			b.mu.Unlock()
+			return replayed, err
+		}
+	}
+	return replayed, nil
+}
+
+type delivery struct {
+	mu      sync.Mutex
+	done    bool
+	broker  *Broker
+	message *message
+}
+
+// Body ...
+func (d *delivery) Body() []byte {
+	return d.message.body
+}
+
+// Attempt ...
+func (d *delivery) Attempt() int {
+	return d.message.attempt
+}
+
+// Done ...
+func (d *delivery) Done() []string {
+	return d.message.done
+}
+
+// Ack ...
+func (d *delivery) Ack() error {
+	return d.settle()
+}
+
+// Retry ...
+func (d *delivery) Retry(done []string) error {
+	if err := d.settle(); err != nil {
+		return err
+	}
+	retry := &message{body: d.message.body, attempt: d.message.attempt + 1, done: done}
+	delay := d.broker.retryDelay << uint(d.message.attempt-1)
+	time.AfterFunc(delay, func() {
+		// таймер не должен висеть на полной очереди вечно
+		ctx, cancel := context.WithTimeout(context.Background(), delay)
+		defer cancel()
+		if err := d.broker.put(ctx, retry); err != nil {
+			d.broker.bury(&DeadLetter{Body: retry.body, Attempts: retry.attempt, Reason: "retry: queue is full"})
+		}
+	})
+	return nil
+}
+
+// DeadLetter ...
+func (d *delivery) DeadLetter(reason string) error {
+	if err := d.settle(); err != nil {
+		return err
+	}
+	d.broker.bury(&DeadLetter{Body: d.message.body, Attempts: d.message.attempt, Reason: reason})
+	return nil
+}
+
+func (d *delivery) settle() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.done {
+		return ErrAcknowledged
+	}
+	d.done = true
+	return nil
+}
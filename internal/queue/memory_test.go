@@ -0,0 +1,114 @@
+package queue_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	broker := queue.NewBroker(10, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	body := []byte("first")
+	assert.NoError(t, broker.Publish(ctx, body))
+	body[0] = 'F'
+	assert.NoError(t, broker.Publish(ctx, []byte("second")))
+	assert.NoError(t, broker.Publish(ctx, []byte("poison")))
+
+	type received struct {
+		body    string
+		attempt int
+	}
+	deliveries := make(chan received, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- broker.Subscribe(ctx, 2, func(ctx context.Context, d queue.Delivery) {
+			switch {
+			case string(d.Body()) == "poison":
+				assert.NoError(t, d.DeadLetter("invalid message"))
+			case string(d.Body()) == "second" && d.Attempt() < 3:
+				// second доставляется с третьей попытки
+				assert.NoError(t, d.Retry(nil))
+			default:
+				assert.NoError(t, d.Ack())
+				assert.Equal(t, queue.ErrAcknowledged, d.Ack())
+			}
+			deliveries <- received{string(d.Body()), d.Attempt()}
+		})
+	}()
+
+	var got []received
+	for len(got) < 5 {
+		select {
+		case r := <-deliveries:
+			got = append(got, r)
+		case <-time.After(time.Second):
+			t.Fatal("no delivery")
+		}
+	}
+	assert.ElementsMatch(t, []received{{"first", 1}, {"second", 1}, {"second", 2}, {"second", 3}, {"poison", 1}}, got)
+	assert.Equal(t, 0, broker.Len())
+
+	dead, err := broker.DeadLetters(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []*queue.DeadLetter{{Body: []byte("poison"), Attempts: 1, Reason: "invalid message"}}, dead)
+
+	// разбор очереди недоставленных не забирает из нее сообщения, а повтор забирает
+	replayed, err := broker.Replay(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	select {
+	case r := <-deliveries:
+		assert.Equal(t, received{"poison", 1}, r)
+	case <-time.After(time.Second):
+		t.Fatal("replayed message is not delivered")
+	}
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Equal(t, context.Canceled, broker.Publish(ctx, []byte("late")))
+}
+
+func TestBroker_RetryIntoFullQueue(t *testing.T) {
+	broker := queue.NewBroker(1, time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, broker.Publish(ctx, []byte("retried")))
+
+	// обработчик откладывает сообщение и занимает освободившееся место, так что повтору некуда вернуться
+	done := make(chan error, 1)
+	go func() {
+		done <- broker.Subscribe(ctx, 1, func(ctx context.Context, d queue.Delivery) {
+			if string(d.Body()) != "retried" {
+				return
+			}
+			assert.NoError(t, d.Retry(nil))
+			assert.NoError(t, broker.Publish(ctx, []byte("blocker")))
+			<-ctx.Done()
+		})
+	}()
+
+	deadline := time.After(time.Second)
+	for {
+		dead, err := broker.DeadLetters(ctx, 10)
+		assert.NoError(t, err)
+		if len(dead) > 0 {
+			assert.Equal(t, []*queue.DeadLetter{{Body: []byte("retried"), Attempts: 2, Reason: "retry: queue is full"}}, dead)
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("retry is not dead-lettered")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	assert.NoError(t, <-done)
+
+	dead, err := broker.DeadLetters(context.Background(), -1)
+	assert.NoError(t, err)
+	assert.Empty(t, dead)
+}
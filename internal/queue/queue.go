@@ -0,0 +1,57 @@
+package queue
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnroutable брокер вернул сообщение: его некуда положить, повтор вернется так же
+var ErrUnroutable = errors.New("message is unroutable")
+
+// Publisher публикует напоминания в очередь. Publish возвращает nil только после того,
+// как брокер принял сообщение, ошибка значит, что его нужно отправить еще раз
+type Publisher interface {
+	Publish(ctx context.Context, body []byte) error
+}
+
+// Delivery полученное из очереди сообщение, обработчик должен подтвердить его,
+// отложить для повтора или переложить в очередь недоставленных
+type Delivery interface {
+	Body() []byte
+	// Attempt номер попытки доставки, начиная с 1
+	Attempt() int
+	// Done части обработки, выполненные в прошлых попытках
+	Done() []string
+	// Ack подтвердит обработку, сообщение удаляется из очереди
+	Ack() error
+	// Retry вернет сообщение в очередь после паузы, растущей с номером попытки.
+	// done части обработки, выполненные до сих пор, следующая попытка получит их в Done
+	Retry(done []string) error
+	// DeadLetter переложит сообщение в очередь недоставленных, reason объяснит оператору причину
+	DeadLetter(reason string) error
+}
+
+// Handler обрабатывает сообщение очереди
+type Handler func(ctx context.Context, d Delivery)
+
+// Subscriber получает напоминания из очереди
+type Subscriber interface {
+	// Subscribe передает сообщения в handler из workers горутин, пока не отменен ctx,
+	// и возвращается, когда обработчики завершились
+	Subscribe(ctx context.Context, workers int, handler Handler) error
+}
+
+// DeadLetter сообщение из очереди недоставленных
+type DeadLetter struct {
+	Body     []byte
+	Attempts int
+	Reason   string
+}
+
+// DeadLetterQueue очередь недоставленных сообщений, которую разбирает оператор
+type DeadLetterQueue interface {
+	// DeadLetters вернет до limit сообщений, оставив их в очереди
+	DeadLetters(ctx context.Context, limit int) ([]*DeadLetter, error)
+	// Replay вернет до limit сообщений в основную очередь с новым счетом попыток
+	Replay(ctx context.Context, limit int) (int, error)
+}
@@ -5,30 +5,64 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/bobrovka/calendar/internal/queue"
 	"github.com/cenkalti/backoff"
 	"github.com/streadway/amqp"
 )
 
+const (
+	// confirmTimeout сколько ждать подтверждения публикации от брокера
+	confirmTimeout = 5 * time.Second
+	// notifyBuffer запас для подтверждений и возвратов, пришедших после таймаута
+	notifyBuffer = 64
+)
+
+var (
+	// ErrNotConnected канал к брокеру еще не открыт
+	ErrNotConnected = errors.New("producer is not connected")
+	// ErrNacked брокер не смог принять сообщение
+	ErrNacked = errors.New("message is nacked by broker")
+	// ErrConfirmTimeout подтверждение не пришло за confirmTimeout, сообщение могло и дойти
+	ErrConfirmTimeout = errors.New("publish confirmation timed out")
+	// ErrChannelClosed канал закрылся, не дождавшись подтверждения
+	ErrChannelClosed = errors.New("channel closed before confirmation")
+)
+
+// channel часть канала AMQP, через которую идут публикации
+type channel interface {
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
 type ProducerMQ struct {
-	conn         *amqp.Connection
-	channel      *amqp.Channel
-	uri          string
-	done         chan error
-	exchangeName string
-	exchangeType string
-	queue        string
-	routingKey   string
+	// mu публикации идут по одной, чтобы сопоставлять подтверждения с сообщениями
+	mu             sync.Mutex
+	conn           *amqp.Connection
+	channel        channel
+	confirms       chan amqp.Confirmation
+	returns        chan amqp.Return
+	deliveryTag    uint64 // номер последней публикации в канале
+	confirmTimeout time.Duration
+	uri            string
+	done           chan error
+	exchangeName   string
+	exchangeType   string
+	queue          string
+	routingKey     string
 }
 
 func NewProducerMQ(uri, exchangeName, exchangeType, queue, routingKey string) *ProducerMQ {
 	return &ProducerMQ{
-		uri:          uri,
-		exchangeName: exchangeName,
-		exchangeType: exchangeType,
-		routingKey:   routingKey,
-		done:         make(chan error),
+		confirmTimeout: confirmTimeout,
+		uri:            uri,
+		exchangeName:   exchangeName,
+		exchangeType:   exchangeType,
+		routingKey:     routingKey,
+		done:           make(chan error),
 	}
 }
 
@@ -59,6 +93,9 @@ func (p *ProducerMQ) reConnect() error {
 }
 
 func (p *ProducerMQ) connect() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	var err error
 
 	p.conn, err = amqp.Dial(p.uri)
@@ -77,12 +114,13 @@ func (p *ProducerMQ) connect() error {
 		}
 	}()
 
-	p.channel, err = p.conn.Channel()
+	ch, err := p.conn.Channel()
 	if err != nil {
 		return fmt.Errorf("Channel: %s", err)
 	}
+	p.channel = ch
 
-	if err = p.channel.ExchangeDeclare(
+	if err = ch.ExchangeDeclare(
 		p.exchangeName,
 		p.exchangeType,
 		true,
@@ -94,19 +132,92 @@ func (p *ProducerMQ) connect() error {
 		return fmt.Errorf("Exchange Declare: %s", err)
 	}
 
+	// в режиме подтверждений брокер отвечает ack или nack на каждую публикацию,
+	// номера публикаций в новом канале начинаются с единицы
+	if err = ch.Confirm(false); err != nil {
+		return fmt.Errorf("Confirm: %s", err)
+	}
+	p.confirms = ch.NotifyPublish(make(chan amqp.Confirmation, notifyBuffer))
+	p.returns = ch.NotifyReturn(make(chan amqp.Return, notifyBuffer))
+	p.deliveryTag = 0
+
 	return nil
 }
 
-func (p *ProducerMQ) Publish(msg []byte) error {
-	return p.channel.Publish(
+// Publish опубликует сообщение и дождется подтверждения брокера. Ошибка значит, что сообщение
+// могло не дойти до очереди и его нужно отправить еще раз. Если к ключу маршрутизации
+// не привязана ни одна очередь, вернет queue.ErrUnroutable
+func (p *ProducerMQ) Publish(ctx context.Context, msg []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.channel == nil {
+		return ErrNotConnected
+	}
+
+	p.deliveryTag++
+	tag := p.deliveryTag
+	// по MessageId возврат сопоставляется с публикацией
+	messageID := strconv.FormatUint(tag, 10)
+	err := p.channel.Publish(
 		p.exchangeName, // exchange
 		p.routingKey,   // routing key
-		false,          // mandatory
+		true,           // mandatory: вернуть сообщение, если его некуда положить
 		false,          // immediate
 		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        msg,
+			ContentType:  "application/json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    messageID,
+			Body:         msg,
 		})
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(p.confirmTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case confirmation, ok := <-p.confirms:
+			if !ok {
+				return ErrChannelClosed
+			}
+			// запоздавшее подтверждение публикации, которая уже завершилась по таймауту
+			if confirmation.DeliveryTag < tag {
+				continue
+			}
+			if !confirmation.Ack {
+				return ErrNacked
+			}
+			// брокер присылает basic.return раньше ack, но каналы читаются в случайном порядке
+			if p.returned(messageID) {
+				return queue.ErrUnroutable
+			}
+			return nil
+		case <-timer.C:
+			return ErrConfirmTimeout
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// returned проверит, не вернул ли брокер публикацию messageID, заодно отбросив старые возвраты
+func (p *ProducerMQ) returned(messageID string) bool {
+	for {
+		select {
+		case r, ok := <-p.returns:
+			if !ok {
+				return false
+			}
+			if r.MessageId == messageID {
+				return true
+			}
+		default:
+			return false
+		}
+	}
 }
 
 func (p *ProducerMQ) KeepConnection() error {
@@ -0,0 +1,120 @@
+package producer
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/queue"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeChannel вместо брокера отвечает на публикацию с номером tag через respond
+type fakeChannel struct {
+	producer  *ProducerMQ
+	published int
+	respond   func(p *ProducerMQ, tag uint64, msg amqp.Publishing)
+}
+
+func (c *fakeChannel) Publish(_, _ string, _, _ bool, msg amqp.Publishing) error {
+	c.published++
+	if c.respond != nil {
+		c.respond(c.producer, uint64(c.published), msg)
+	}
+	return nil
+}
+
+func (c *fakeChannel) Close() error {
+	return nil
+}
+
+func newTestProducer(respond func(p *ProducerMQ, tag uint64, msg amqp.Publishing)) *ProducerMQ {
+	p := NewProducerMQ("", "events", "direct", "event.queue", "event")
+	p.confirmTimeout = 50 * time.Millisecond
+	p.confirms = make(chan amqp.Confirmation, notifyBuffer)
+	p.returns = make(chan amqp.Return, notifyBuffer)
+	p.channel = &fakeChannel{producer: p, respond: respond}
+	return p
+}
+
+func ack(p *ProducerMQ, tag uint64, _ amqp.Publishing) {
+	p.confirms <- amqp.Confirmation{DeliveryTag: tag, Ack: true}
+}
+
+func TestProducerMQ_Publish(t *testing.T) {
+	type testCase struct {
+		respond func(p *ProducerMQ, tag uint64, msg amqp.Publishing)
+		expErr  error
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["acked"] = testCase{
+		respond: ack,
+	}
+	testCases["nacked"] = testCase{
+		respond: func(p *ProducerMQ, tag uint64, _ amqp.Publishing) {
+			p.confirms <- amqp.Confirmation{DeliveryTag: tag, Ack: false}
+		},
+		expErr: ErrNacked,
+	}
+	testCases["unroutable"] = testCase{
+		respond: func(p *ProducerMQ, tag uint64, msg amqp.Publishing) {
+			// брокер возвращает сообщение и все равно подтверждает его
+			p.returns <- amqp.Return{MessageId: msg.MessageId}
+			p.confirms <- amqp.Confirmation{DeliveryTag: tag, Ack: true}
+		},
+		expErr: queue.ErrUnroutable,
+	}
+	testCases["old return of another message"] = testCase{
+		respond: func(p *ProducerMQ, tag uint64, _ amqp.Publishing) {
+			p.returns <- amqp.Return{MessageId: strconv.FormatUint(tag+100, 10)}
+			p.confirms <- amqp.Confirmation{DeliveryTag: tag, Ack: true}
+		},
+	}
+	testCases["no confirmation"] = testCase{
+		expErr: ErrConfirmTimeout,
+	}
+	testCases["channel closed"] = testCase{
+		respond: func(p *ProducerMQ, _ uint64, _ amqp.Publishing) {
+			close(p.confirms)
+		},
+		expErr: ErrChannelClosed,
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			p := newTestProducer(tc.respond)
+			assert.Equal(t, tc.expErr, p.Publish(context.Background(), []byte(`{}`)))
+		})
+	}
+}
+
+func TestProducerMQ_PublishLateConfirmation(t *testing.T) {
+	// первая публикация подтверждается только вместе со второй
+	p := newTestProducer(func(p *ProducerMQ, tag uint64, _ amqp.Publishing) {
+		if tag == 2 {
+			p.confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+			p.confirms <- amqp.Confirmation{DeliveryTag: 2, Ack: false}
+		}
+	})
+
+	assert.Equal(t, ErrConfirmTimeout, p.Publish(context.Background(), []byte(`{}`)))
+	// запоздавший ack первой публикации не выдается за подтверждение второй
+	assert.Equal(t, ErrNacked, p.Publish(context.Background(), []byte(`{}`)))
+}
+
+func TestProducerMQ_PublishCanceled(t *testing.T) {
+	p := newTestProducer(nil)
+	p.confirmTimeout = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, p.Publish(ctx, []byte(`{}`)))
+}
+
+func TestProducerMQ_PublishNotConnected(t *testing.T) {
+	p := NewProducerMQ("", "events", "direct", "event.queue", "event")
+	assert.Equal(t, ErrNotConnected, p.Publish(context.Background(), []byte(`{}`)))
+}
@@ -2,69 +2,117 @@ package scheduler
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"sync"
 	"time"
 
-	"github.com/bobrovka/calendar/internal/scheduler/producer"
+	"github.com/bobrovka/calendar/internal/queue"
 	"go.uber.org/zap"
 )
 
+const (
+	// notificationBatch сколько напоминаний отправляется за один проход
+	notificationBatch = 100
+	// notificationLease на сколько напоминания скрываются от других планировщиков, пока отправляются
+	notificationLease = time.Minute
+	// publishAttempts сколько раз пробовать опубликовать напоминание, прежде чем отложить его
+	publishAttempts = 3
+	// publishRetryPause пауза перед повтором, растет с каждой попыткой
+	publishRetryPause = 500 * time.Millisecond
+)
+
 type Scheduler struct {
-	producer *producer.ProducerMQ
-	wg       *sync.WaitGroup
-	finished chan error
-	storage  EventStorage
-	logger   *zap.SugaredLogger
+	publisher queue.Publisher
+	wg        *sync.WaitGroup
+	finished  chan struct{}
+	storage   EventStorage
+	logger    *zap.SugaredLogger
 }
 
-func NewScheduler(producer *producer.ProducerMQ, storage EventStorage, logger *zap.SugaredLogger) *Scheduler {
+// NewScheduler создает планировщик, отправляющий напоминания из storage через publisher.
+// Соединением с брокером планировщик не управляет
+func NewScheduler(publisher queue.Publisher, storage EventStorage, logger *zap.SugaredLogger) *Scheduler {
 	return &Scheduler{
-		producer: producer,
-		wg:       &sync.WaitGroup{},
-		finished: make(chan error),
-		storage:  storage,
-		logger:   logger,
+		publisher: publisher,
+		wg:        &sync.WaitGroup{},
+		finished:  make(chan struct{}),
+		storage:   storage,
+		logger:    logger,
 	}
 }
 
+// sendNotifications опубликует напоминания, срок которых наступил, и удалит из outbox только те,
+// что брокер подтвердил. Неподтвержденные остаются в outbox и будут выданы снова после аренды,
+// так что напоминание доставляется хотя бы один раз
 func (s *Scheduler) sendNotifications() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	events, err := s.storage.PopNotifications(ctx)
+	leasedAt := time.Now()
+	messages, err := s.storage.LeaseNotifications(ctx, notificationBatch, notificationLease)
 	if err != nil {
 		s.logger.Warnw("error get notifications", "MethodName", "sendNotifications", "err", err)
 		return err
 	}
 
-	for _, e := range events {
-		body, err := json.Marshal(e)
-		if err != nil {
-			s.logger.Warnw("error marshal event", "MethodName", "sendNotifications", "err", err)
-			return err
+	// остаток пачки отправит следующий проход, пока аренда не истекла и его не взял другой планировщик
+	publishCtx, publishCancel := context.WithTimeout(context.Background(), notificationLease/2)
+	defer publishCancel()
+
+	sent := make([]int64, 0, len(messages))
+	var publishErr error
+	for _, m := range messages {
+		if time.Since(leasedAt) > notificationLease/2 {
+			break
 		}
 
-		err = s.producer.Publish(body)
+		publishErr = s.publish(publishCtx, m.Payload)
+		if publishErr != nil {
+			s.logger.Warnw("error publish event, notification is held back until its lease expires",
+				"MethodName", "sendNotifications", "uuid", m.EventUUID, "err", publishErr)
+			break
+		}
+		sent = append(sent, m.ID)
+	}
+
+	if len(sent) > 0 {
+		// публикация с подтверждениями могла занять дольше таймаута выборки
+		ackCtx, ackCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer ackCancel()
+
+		err = s.storage.AckNotifications(ackCtx, sent)
 		if err != nil {
-			s.logger.Warnw("error publish event", "MethodName", "sendNotifications", "err", err)
+			// напоминания отправятся еще раз, получатель может увидеть дубль
+			s.logger.Warnw("error ack notifications", "MethodName", "sendNotifications", "err", err)
 			return err
 		}
 	}
 
-	return nil
+	return publishErr
 }
 
-func (s *Scheduler) Run() error {
-	go func() {
-		err := s.producer.KeepConnection()
-		if err != nil {
-			s.logger.Warnw("connection ends", "err", err)
+// publish повторит публикацию, если брокер ее не подтвердил. Возвращенное брокером сообщение
+// не повторяется: пока к ключу не привязана очередь, повтор вернется так же
+func (s *Scheduler) publish(ctx context.Context, payload []byte) error {
+	var err error
+	for attempt := 1; attempt <= publishAttempts; attempt++ {
+		err = s.publisher.Publish(ctx, payload)
+		if err == nil || errors.Is(err, queue.ErrUnroutable) || ctx.Err() != nil {
+			return err
+		}
+		if attempt < publishAttempts {
+			select {
+			case <-time.After(time.Duration(attempt) * publishRetryPause):
+			case <-ctx.Done():
+				return err
+			}
 		}
-		s.finished <- err
-		close(s.finished)
-	}()
+	}
+	return err
+}
 
+// Run отправляет напоминания каждые 5 секунд, пока не вызван Stop
+func (s *Scheduler) Run() error {
 	s.wg.Add(1)
 	defer s.wg.Done()
 
@@ -73,16 +121,16 @@ func (s *Scheduler) Run() error {
 
 	for {
 		select {
-		case err := <-s.finished:
-			return err
+		case <-s.finished:
+			return nil
 		case <-ticker.C:
 			s.sendNotifications()
 		}
 	}
 }
 
-func (s *Scheduler) Stop() error {
-	err := s.producer.GracefulStop()
+// Stop дождется окончания текущего прохода и остановит Run
+func (s *Scheduler) Stop() {
+	close(s.finished)
 	s.wg.Wait()
-	return err
 }
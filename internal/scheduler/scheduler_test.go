@@ -0,0 +1,211 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/egress"
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/bobrovka/calendar/internal/notifier"
+	"github.com/bobrovka/calendar/internal/queue"
+	"github.com/bobrovka/calendar/internal/scheduler/producer"
+	"github.com/bobrovka/calendar/internal/sender"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestScheduler_NotificationPipeline(t *testing.T) {
+	ctx := context.Background()
+	storage := memory.NewStorageMemory()
+	// webhook тестового сервера на loopback
+	policy, err := egress.NewPolicy([]string{"127.0.0.1"})
+	assert.NoError(t, err)
+	calendar, err := app.NewCalendar(storage, nil, app.WithEgressPolicy(policy))
+	assert.NoError(t, err)
+
+	hooks := make(chan *notifier.Notification, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notifier.Notification
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		hooks <- &n
+	}))
+	defer server.Close()
+	assert.NoError(t, calendar.UpdateProfile(ctx, &models.User{
+		Name:       "Kira",
+		Location:   time.UTC,
+		WebhookURL: server.URL,
+		Channels:   []models.Channel{models.ChannelWebhook},
+	}))
+
+	soon := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	event := func(user, title string, start time.Time) *models.Event {
+		return &models.Event{Title: title, StartAt: start, Duration: time.Hour, NotifyBefore: 15 * time.Minute, User: user}
+	}
+	_, err = calendar.CreateNewEvent(ctx, event("Kira", "standup", soon))
+	assert.NoError(t, err)
+	_, err = calendar.CreateNewEvent(ctx, event("Kira", "later", soon.Add(time.Hour)))
+	assert.NoError(t, err)
+	// у Лены нет профиля, ей напоминания пишутся в файл
+	_, err = calendar.CreateNewEvent(ctx, event("Lena", "review", soon))
+	assert.NoError(t, err)
+
+	broker := queue.NewBroker(10, time.Millisecond)
+	s := NewScheduler(broker, storage, zap.NewNop().Sugar())
+	assert.NoError(t, s.sendNotifications())
+	assert.Equal(t, 2, broker.Len())
+
+	// отправленное напоминание удалено из outbox, повторного не будет
+	assert.NoError(t, s.sendNotifications())
+	assert.Equal(t, 2, broker.Len())
+
+	path := filepath.Join(t.TempDir(), "notifications.jsonl")
+	templates := notifier.DefaultTemplates()
+	file, err := notifier.NewFile(path, templates)
+	assert.NoError(t, err)
+	router := notifier.NewRouter(map[models.Channel]notifier.Notifier{
+		models.ChannelFile:    file,
+		models.ChannelWebhook: notifier.NewWebhook(time.Second, templates, policy),
+	})
+
+	core, logs := observer.New(zap.InfoLevel)
+	senderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		done <- sender.NewSender(broker, storage, router, 3, zap.New(core)).Run(senderCtx, 2)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for logs.Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	assert.NoError(t, <-done)
+	assert.NoError(t, file.Close())
+
+	assert.Equal(t, 2, logs.Len())
+	assert.Equal(t, 0, broker.Len())
+	if assert.Len(t, hooks, 1) {
+		assert.Equal(t, "standup", (<-hooks).Title)
+	}
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"user":"Lena","uuid":`)
+	assert.Contains(t, string(data), `"text":"review starts in 10 minutes, `)
+}
+
+// fakePublisher подтверждает публикации, кроме напоминаний о событиях из failures:
+// на них возвращает ошибку столько раз, сколько указано
+type fakePublisher struct {
+	err       error
+	failures  map[string]int
+	published []string
+}
+
+func (p *fakePublisher) Publish(_ context.Context, body []byte) error {
+	var e models.Event
+	if err := json.Unmarshal(body, &e); err != nil {
+		return err
+	}
+	p.published = append(p.published, e.Title)
+	if p.failures[e.Title] > 0 {
+		p.failures[e.Title]--
+		return p.err
+	}
+	return nil
+}
+
+// ackRecorder запоминает, о каких событиях напоминания выданы и какие из них удалены из outbox
+type ackRecorder struct {
+	*memory.StorageMemory
+	leased map[int64]string
+	acked  []string
+}
+
+func (s *ackRecorder) LeaseNotifications(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxMessage, error) {
+	messages, err := s.StorageMemory.LeaseNotifications(ctx, limit, lease)
+	for _, m := range messages {
+		s.leased[m.ID] = m.EventUUID
+	}
+	return messages, err
+}
+
+func (s *ackRecorder) AckNotifications(ctx context.Context, ids []int64) error {
+	for _, id := range ids {
+		s.acked = append(s.acked, s.leased[id])
+	}
+	return s.StorageMemory.AckNotifications(ctx, ids)
+}
+
+func TestScheduler_HoldsBackUnconfirmed(t *testing.T) {
+	type testCase struct {
+		err          error
+		failures     int
+		expPublished []string
+		expAcked     []string
+		expErr       bool
+	}
+
+	testCases := make(map[string]testCase)
+	testCases["nacked"] = testCase{
+		err:          producer.ErrNacked,
+		failures:     publishAttempts,
+		expPublished: []string{"first", "second", "second", "second"},
+		expAcked:     []string{"first"},
+		expErr:       true,
+	}
+	testCases["unroutable is not retried"] = testCase{
+		err:          queue.ErrUnroutable,
+		failures:     1,
+		expPublished: []string{"first", "second"},
+		expAcked:     []string{"first"},
+		expErr:       true,
+	}
+	testCases["confirmed on retry"] = testCase{
+		err:          producer.ErrConfirmTimeout,
+		failures:     1,
+		expPublished: []string{"first", "second", "second", "third"},
+		expAcked:     []string{"first", "second", "third"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := &ackRecorder{StorageMemory: memory.NewStorageMemory(), leased: make(map[int64]string)}
+			soon := time.Now().Add(10 * time.Minute)
+			titles := make(map[string]string)
+			for _, title := range []string{"first", "second", "third"} {
+				uuid, err := storage.CreateEvent(ctx, &models.Event{Title: title, StartAt: soon, Duration: time.Minute, NotifyBefore: 15 * time.Minute, User: "Kira"})
+				assert.NoError(t, err)
+				titles[uuid] = title
+				soon = soon.Add(time.Minute)
+			}
+
+			publisher := &fakePublisher{err: tc.err, failures: map[string]int{"second": tc.failures}}
+			s := NewScheduler(publisher, storage, zap.NewNop().Sugar())
+			err := s.sendNotifications()
+			if tc.expErr {
+				assert.True(t, errors.Is(err, tc.err), err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expPublished, publisher.published)
+
+			// неподтвержденные напоминания остаются в outbox и будут выданы снова после аренды
+			acked := make([]string, 0, len(storage.acked))
+			for _, uuid := range storage.acked {
+				acked = append(acked, titles[uuid])
+			}
+			assert.Equal(t, tc.expAcked, acked)
+		})
+	}
+}
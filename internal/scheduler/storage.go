@@ -2,11 +2,16 @@ package scheduler
 
 import (
 	"context"
+	"time"
 
 	"github.com/bobrovka/calendar/internal/models"
 )
 
 // EventStorage хранилище событий
 type EventStorage interface {
-	PopNotifications(ctx context.Context) ([]*models.Event, error)
+	// LeaseNotifications вернет до limit напоминаний, срок которых наступил, и на время lease скроет их
+	// от других вызовов. Неподтвержденные за это время напоминания будут выданы снова
+	LeaseNotifications(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxMessage, error)
+	// AckNotifications удалит из outbox напоминания, которые принял брокер
+	AckNotifications(ctx context.Context, ids []int64) error
 }
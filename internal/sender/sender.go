@@ -0,0 +1,122 @@
+package sender
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/bobrovka/calendar/internal/notifier"
+	"github.com/bobrovka/calendar/internal/queue"
+	"go.uber.org/zap"
+)
+
+// notifyTimeout сколько ждать доставки одного напоминания по всем каналам
+const notifyTimeout = 30 * time.Second
+
+// UserStorage профили пользователей: из них берутся каналы напоминаний и часовой пояс
+type UserStorage interface {
+	GetUser(ctx context.Context, name string) (*models.User, error)
+}
+
+// Sender рассылает напоминания, пришедшие из очереди. Неудавшаяся рассылка повторяется,
+// пока не кончатся попытки, после чего напоминание уходит в очередь недоставленных
+type Sender struct {
+	subscriber  queue.Subscriber
+	users       UserStorage
+	notifier    notifier.Notifier
+	maxAttempts int
+	logger      *zap.Logger
+}
+
+// NewSender создает рассыльщика напоминаний из subscriber через notifier, делающего до maxAttempts попыток
+func NewSender(subscriber queue.Subscriber, users UserStorage, notifier notifier.Notifier, maxAttempts int, logger *zap.Logger) *Sender {
+	return &Sender{
+		subscriber:  subscriber,
+		users:       users,
+		notifier:    notifier,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+	}
+}
+
+// Run обрабатывает напоминания в workers горутин, пока не отменен ctx
+func (s *Sender) Run(ctx context.Context, workers int) error {
+	return s.subscriber.Subscribe(ctx, workers, s.handle)
+}
+
+func (s *Sender) handle(ctx context.Context, d queue.Delivery) {
+	var e models.Event
+	err := json.Unmarshal(d.Body(), &e)
+	if err != nil {
+		// повтор не исправит сообщение, его разбирает оператор
+		s.logger.Warn(fmt.Sprintf("got invalid message %s", d.Body()))
+		s.settle(d.DeadLetter(fmt.Sprintf("invalid message: %v", err)))
+		return
+	}
+
+	err = s.notify(ctx, &e, d.Done())
+	if err == nil {
+		s.logger.Info("notification sent", zap.String("user", e.User), zap.String("uuid", e.UUID), zap.Int("attempt", d.Attempt()))
+		s.settle(d.Ack())
+		return
+	}
+
+	if d.Attempt() >= s.maxAttempts {
+		s.logger.Error("notification is not delivered, attempts are over",
+			zap.String("uuid", e.UUID), zap.Int("attempt", d.Attempt()), zap.Error(err))
+		s.settle(d.DeadLetter(fmt.Sprintf("%d attempts failed, last error: %v", d.Attempt(), err)))
+		return
+	}
+	s.logger.Warn("notification is not delivered, will retry",
+		zap.String("uuid", e.UUID), zap.Int("attempt", d.Attempt()), zap.Error(err))
+
+	// повтор отправит напоминание только в каналы, куда оно еще не доставлено
+	done := d.Done()
+	var partial *notifier.DeliveryError
+	if errors.As(err, &partial) {
+		for _, channel := range partial.Delivered {
+			done = append(done, string(channel))
+		}
+	}
+	s.settle(d.Retry(done))
+}
+
+// settle залогирует ошибку подтверждения, сообщение тогда придет еще раз
+func (s *Sender) settle(err error) {
+	if err != nil {
+		s.logger.Warn("cannot settle message", zap.Error(err))
+	}
+}
+
+// notify отправит напоминание по каналам, выбранным получателем, кроме уже доставленных в done
+func (s *Sender) notify(ctx context.Context, e *models.Event, done []string) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+
+	user, err := s.users.GetUser(ctx, e.User)
+	if err == app.ErrNotFound {
+		user = models.DefaultUser(e.User)
+	} else if err != nil {
+		return fmt.Errorf("cannot get profile: %w", err)
+	}
+
+	delivered := make(map[models.Channel]bool, len(done))
+	for _, channel := range done {
+		delivered[models.Channel(channel)] = true
+	}
+	var pending []models.Channel
+	for _, channel := range user.NotifyChannels() {
+		if !delivered[channel] {
+			pending = append(pending, channel)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	user.Channels = pending
+	return s.notifier.Notify(ctx, user, e)
+}
@@ -0,0 +1,165 @@
+package sender
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/bobrovka/calendar/internal/notifier"
+	"github.com/bobrovka/calendar/internal/queue"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// flakyNotifier не может доставить первые failures напоминаний
+type flakyNotifier struct {
+	mu        sync.Mutex
+	failures  int
+	sent      int
+	delivered chan struct{}
+}
+
+func (n *flakyNotifier) Notify(_ context.Context, user *models.User, _ *models.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent++
+	if n.sent <= n.failures {
+		return errors.New("smtp is down")
+	}
+	n.delivered <- struct{}{}
+	return nil
+}
+
+func TestSender_RetryPolicy(t *testing.T) {
+	type testCase struct {
+		body     string
+		failures int
+		expSent  int
+		expDead  []*queue.DeadLetter
+	}
+
+	event := `{"UUID":"1","Title":"standup","User":"Kira"}`
+	testCases := make(map[string]testCase)
+	testCases["delivered first time"] = testCase{
+		body:    event,
+		expSent: 1,
+	}
+	testCases["delivered after retries"] = testCase{
+		body:     event,
+		failures: 2,
+		expSent:  3,
+	}
+	testCases["attempts are over"] = testCase{
+		body:     event,
+		failures: 10,
+		expSent:  3,
+		expDead:  []*queue.DeadLetter{{Body: []byte(event), Attempts: 3, Reason: "3 attempts failed, last error: smtp is down"}},
+	}
+	testCases["poison message"] = testCase{
+		body:    "not json",
+		expDead: []*queue.DeadLetter{{Body: []byte("not json"), Attempts: 1, Reason: "invalid message: invalid character 'o' in literal null (expecting 'u')"}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			broker := queue.NewBroker(10, time.Millisecond)
+			n := &flakyNotifier{failures: tc.failures, delivered: make(chan struct{}, 1)}
+			s := NewSender(broker, memory.NewStorageMemory(), n, 3, zap.NewNop())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- s.Run(ctx, 2)
+			}()
+			assert.NoError(t, broker.Publish(ctx, []byte(tc.body)))
+
+			// рассылка закончена, когда напоминание доставлено или легло в очередь недоставленных
+			deadline := time.After(time.Second)
+			for {
+				dead, err := broker.DeadLetters(ctx, 10)
+				assert.NoError(t, err)
+				if len(dead) > 0 || len(n.delivered) > 0 {
+					break
+				}
+				select {
+				case <-deadline:
+					t.Fatal("message is not settled")
+				case <-time.After(5 * time.Millisecond):
+				}
+			}
+			cancel()
+			assert.NoError(t, <-done)
+
+			dead, err := broker.DeadLetters(context.Background(), 10)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expDead, dead)
+			assert.Equal(t, tc.expSent, n.sent)
+		})
+	}
+}
+
+// channelNotifier считает напоминания в канал и не может доставить первые failures
+type channelNotifier struct {
+	mu       sync.Mutex
+	failures int
+	sent     int
+}
+
+func (n *channelNotifier) Notify(context.Context, *models.User, *models.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent++
+	if n.sent <= n.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func (n *channelNotifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.sent
+}
+
+func TestSender_RetriesOnlyFailedChannels(t *testing.T) {
+	users := memory.NewStorageMemory()
+	assert.NoError(t, users.SaveUser(context.Background(), &models.User{
+		Name:     "Kira",
+		Location: time.UTC,
+		Channels: []models.Channel{models.ChannelFile, models.ChannelWebhook},
+	}))
+
+	file, webhook := &channelNotifier{}, &channelNotifier{failures: 2}
+	router := notifier.NewRouter(map[models.Channel]notifier.Notifier{
+		models.ChannelFile:    file,
+		models.ChannelWebhook: webhook,
+	})
+	broker := queue.NewBroker(10, time.Millisecond)
+	s := NewSender(broker, users, router, 5, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(ctx, 1)
+	}()
+	assert.NoError(t, broker.Publish(ctx, []byte(`{"UUID":"1","Title":"standup","User":"Kira"}`)))
+
+	deadline := time.After(time.Second)
+	for webhook.count() < 3 {
+		select {
+		case <-deadline:
+			t.Fatal("message is not delivered")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	assert.NoError(t, <-done)
+
+	// файл получил напоминание в первой попытке, повторы шли только в webhook
+	assert.Equal(t, 1, file.count())
+	assert.Equal(t, 3, webhook.count())
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/ical"
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain is the ErrorInfo domain of errors raised by the service
+const errorDomain = "calendar"
+
+// typeTimeBusy is the ErrorInfo and PreconditionFailure type of time conflicts
+const typeTimeBusy = "TIME_BUSY"
+
+// invalidArguments are application errors caused by bad request values
+var invalidArguments = []error{
+	app.ErrInvalidOccurrence,
+	app.ErrInvalidTimeZone,
+	app.ErrInvalidChannel,
+	app.ErrInvalidEmail,
+	app.ErrInvalidWebhook,
+	app.ErrInvalidLocale,
+	app.ErrInvalidScope,
+	app.ErrInvalidInterval,
+	app.ErrIntervalTooLong,
+	app.ErrInvalidSlotQuery,
+	app.ErrInvalidPageSize,
+	app.ErrInvalidPageToken,
+	models.ErrInvalidRRule,
+	ical.ErrNoCalendar,
+}
+
+// statusError translates an application error to a gRPC status error.
+// Unexpected errors become Internal without details, they are logged by the caller
+func statusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var busy *app.BusyError
+	var invalid *app.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		return validationStatus("", invalid)
+	case errors.As(err, &busy):
+		return busyStatus(busy.Conflicts)
+	case errors.Is(err, app.ErrTimeBusy):
+		// storage found the overlap itself, conflicting events are unknown
+		return busyStatus(nil)
+	case errors.Is(err, app.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, app.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, app.ErrWatchLagging):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	for _, invalid := range invalidArguments {
+		if errors.Is(err, invalid) {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+// eventError is statusError for methods taking an event in the request,
+// validation errors name fields of that event
+func eventError(err error) error {
+	var invalid *app.ValidationError
+	if errors.As(err, &invalid) {
+		return validationStatus("event.", invalid)
+	}
+	return statusError(err)
+}
+
+// validationStatus is InvalidArgument with a field violation for every bad field
+func validationStatus(prefix string, invalid *app.ValidationError) error {
+	request := &errdetails.BadRequest{}
+	for _, field := range invalid.Fields {
+		request.FieldViolations = append(request.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       prefix + field.Field,
+			Description: field.Err.Error(),
+		})
+	}
+	return withDetails(status.New(codes.InvalidArgument, invalid.Error()), request)
+}
+
+// invalidArgument reports a request field which could not be converted
+func invalidArgument(field string, err error) error {
+	return withDetails(status.New(codes.InvalidArgument, err.Error()), &errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: field, Description: err.Error()},
+		},
+	})
+}
+
+// busyStatus is FailedPrecondition listing event occurrences which take the time,
+// each violation has the event UUID as subject and its RFC 3339 interval start/end as description
+func busyStatus(conflicts []app.Conflict) error {
+	failure := &errdetails.PreconditionFailure{}
+	for _, conflict := range conflicts {
+		failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{
+			Type:        typeTimeBusy,
+			Subject:     conflict.UUID,
+			Description: conflict.StartAt.Format(time.RFC3339) + "/" + conflict.EndAt.Format(time.RFC3339),
+		})
+	}
+
+	return withDetails(status.New(codes.FailedPrecondition, app.ErrTimeBusy.Error()),
+		&errdetails.ErrorInfo{Type: typeTimeBusy, Domain: errorDomain},
+		failure,
+	)
+}
+
+func withDetails(st *status.Status, details ...proto.Message) error {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
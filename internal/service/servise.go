@@ -2,15 +2,42 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/ical"
 	"github.com/bobrovka/calendar/internal/models"
 	"github.com/bobrovka/calendar/pkg/calendar/api"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"go.uber.org/zap"
 )
 
+// dateLayout is format of all-day event dates
+const dateLayout = "2006-01-02"
+
+var importStatuses = map[app.ImportStatus]api.ImportStatus{
+	app.ImportCreated:  api.ImportStatus_CREATED,
+	app.ImportUpdated:  api.ImportStatus_UPDATED,
+	app.ImportConflict: api.ImportStatus_CONFLICT,
+	app.ImportInvalid:  api.ImportStatus_INVALID,
+}
+
+var changeTypes = map[models.ChangeKind]api.ChangeType{
+	models.ChangeCreated: api.ChangeType_CHANGE_CREATED,
+	models.ChangeUpdated: api.ChangeType_CHANGE_UPDATED,
+	models.ChangeDeleted: api.ChangeType_CHANGE_DELETED,
+}
+
+var scopes = map[api.Scope]app.Scope{
+	api.Scope_SERIES:     app.ScopeSeries,
+	api.Scope_OCCURRENCE: app.ScopeOccurrence,
+	api.Scope_FOLLOWING:  app.ScopeFollowing,
+}
+
 // EventService is implementation for grpc event service
 type EventService struct {
 	app    app.App
@@ -30,76 +57,161 @@ func (es *EventService) ListEvents(ctx context.Context, request *api.ListRequest
 	day, err := ptypes.Timestamp(request.GetDate())
 	if err != nil {
 		es.logger.Errorw("error time conversion", "methodName", "ListEvents", "err", err)
-		return nil, err
+		return nil, invalidArgument("date", err)
 	}
 
+	user := userOrCaller(ctx, request.GetUser())
 	var events []*models.Event
 	switch request.GetPeriod() {
 	case api.Period_DAY:
-		events, err = es.app.ListDayEvents(ctx, request.User, day)
+		events, err = es.app.ListDayEvents(ctx, user, day)
 		if err != nil {
 			es.logger.Errorw("error ListDayEvents", "methodName", "ListEvents", "err", err)
-			return nil, err
+			return nil, statusError(err)
 		}
 	case api.Period_WEEK:
-		events, err = es.app.ListWeekEvents(ctx, request.User, day)
+		events, err = es.app.ListWeekEvents(ctx, user, day)
 		if err != nil {
 			es.logger.Errorw("error ListWeekEvents", "methodName", "ListEvents", "err", err)
-			return nil, err
+			return nil, statusError(err)
 		}
 	case api.Period_MONTH:
-		events, err = es.app.ListMonthEvents(ctx, request.User, day)
+		events, err = es.app.ListMonthEvents(ctx, user, day)
 		if err != nil {
 			es.logger.Errorw("error ListMonthEvents", "methodName", "ListEvents", "err", err)
-			return nil, err
+			return nil, statusError(err)
 		}
+	default:
+		// a period from a newer client must not look like an empty calendar
+		err = fmt.Errorf("unknown period %d", request.GetPeriod())
+		es.logger.Errorw("error period", "methodName", "ListEvents", "err", err)
+		return nil, invalidArgument("period", err)
 	}
 
-	result := make([]*api.Event, 0, len(events))
-	for _, event := range events {
-		startAt, err := ptypes.TimestampProto(event.StartAt)
-		if err != nil {
-			es.logger.Errorw("error time conversion", "methodName", "ListEvents", "err", err)
-			return nil, err
-		}
+	profile, err := es.app.GetProfile(ctx, user)
+	if err != nil {
+		es.logger.Errorw("error GetProfile", "methodName", "ListEvents", "err", err)
+		return nil, statusError(err)
+	}
 
-		result = append(result, &api.Event{
-			Uuid:         event.UUID,
-			Title:        event.Title,
-			StartAt:      startAt,
-			Duration:     ptypes.DurationProto(event.Duration),
-			Description:  event.Description,
-			User:         event.User,
-			NotifyBefore: ptypes.DurationProto(event.NotifyBefore),
-		})
+	result, err := eventsProto(events, profile.Location)
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "ListEvents", "err", err)
+		return nil, statusError(err)
 	}
 
 	es.logger.Infow("Success ListEvents")
 	return &api.ListResponse{
-		Events: result,
+		Events:   result,
+		TimeZone: profile.Location.String(),
+	}, nil
+}
+
+// ListEventsRange method
+func (es *EventService) ListEventsRange(ctx context.Context, request *api.ListRangeRequest) (*api.ListRangeResponse, error) {
+	from, err := ptypes.Timestamp(request.GetFrom())
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "ListEventsRange", "err", err)
+		return nil, invalidArgument("from", err)
+	}
+	to, err := ptypes.Timestamp(request.GetTo())
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "ListEventsRange", "err", err)
+		return nil, invalidArgument("to", err)
+	}
+
+	user := userOrCaller(ctx, request.GetUser())
+	page, err := es.app.ListEventsRange(ctx, &app.RangeQuery{
+		User:      user,
+		From:      from,
+		To:        to,
+		PageSize:  int(request.GetPageSize()),
+		PageToken: request.GetPageToken(),
+		Desc:      request.GetOrder() == api.SortOrder_START_DESC,
+	})
+	if err != nil {
+		es.logger.Errorw("error ListEventsRange", "methodName", "ListEventsRange", "err", err)
+		return nil, statusError(err)
+	}
+
+	profile, err := es.app.GetProfile(ctx, user)
+	if err != nil {
+		es.logger.Errorw("error GetProfile", "methodName", "ListEventsRange", "err", err)
+		return nil, statusError(err)
+	}
+
+	result, err := eventsProto(page.Events, profile.Location)
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "ListEventsRange", "err", err)
+		return nil, statusError(err)
+	}
+
+	es.logger.Infow("Success ListEventsRange")
+	return &api.ListRangeResponse{
+		Events:        result,
+		NextPageToken: page.NextPageToken,
+		TimeZone:      profile.Location.String(),
 	}, nil
 }
 
+// WatchEvents method
+func (es *EventService) WatchEvents(request *api.WatchRequest, stream api.Events_WatchEventsServer) error {
+	ctx := stream.Context()
+	from, err := ptypes.Timestamp(request.GetFrom())
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "WatchEvents", "err", err)
+		return invalidArgument("from", err)
+	}
+	to, err := ptypes.Timestamp(request.GetTo())
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "WatchEvents", "err", err)
+		return invalidArgument("to", err)
+	}
+
+	user := userOrCaller(ctx, request.GetUser())
+	profile, err := es.app.GetProfile(ctx, user)
+	if err != nil {
+		es.logger.Errorw("error GetProfile", "methodName", "WatchEvents", "err", err)
+		return statusError(err)
+	}
+
+	err = es.app.WatchEvents(ctx, user, from, to, func(update *app.EventUpdate) error {
+		events, err := eventsProto(update.Events, profile.Location)
+		if err != nil {
+			return err
+		}
+
+		response := &api.WatchResponse{Events: events}
+		if update.Change == nil {
+			response.Type = api.ChangeType_CHANGE_SNAPSHOT
+			response.TimeZone = profile.Location.String()
+		} else {
+			response.Type = changeTypes[update.Change.Kind]
+			response.Uuid = update.Change.UUID
+		}
+		return stream.Send(response)
+	})
+	if err != nil {
+		es.logger.Infow("WatchEvents ended", "err", err)
+		return statusError(err)
+	}
+	return nil
+}
+
 // CreateEvent method
 func (es *EventService) CreateEvent(ctx context.Context, request *api.CreateRequest) (*api.CreateResponse, error) {
 	newEvent := request.GetEvent()
 
-	startAt, err := ptypes.Timestamp(newEvent.GetStartAt())
+	startAt, duration, err := eventTime(newEvent)
 	if err != nil {
 		es.logger.Errorw("error time conversion", "methodName", "CreateEvent", "err", err)
-		return nil, err
-	}
-
-	duration, err := ptypes.Duration(newEvent.GetDuration())
-	if err != nil {
-		es.logger.Errorw("error duration conversion", "methodName", "CreateEvent", "err", err)
-		return nil, err
+		return nil, invalidArgument("event", err)
 	}
 
 	notifyBefore, err := ptypes.Duration(newEvent.GetNotifyBefore())
 	if err != nil {
 		es.logger.Errorw("error duration conversion", "methodName", "CreateEvent", "err", err)
-		return nil, err
+		return nil, invalidArgument("event.notifyBefore", err)
 	}
 
 	e := &models.Event{
@@ -107,14 +219,18 @@ func (es *EventService) CreateEvent(ctx context.Context, request *api.CreateRequ
 		StartAt:      startAt,
 		Duration:     duration,
 		Description:  newEvent.GetDescription(),
-		User:         newEvent.GetUser(),
+		User:         userOrCaller(ctx, newEvent.GetUser()),
 		NotifyBefore: notifyBefore,
+		RRule:        newEvent.GetRrule(),
+		AllDay:       newEvent.GetAllDay(),
+		Transparency: models.Transparency(newEvent.GetTransparency()),
+		Status:       models.Status(newEvent.GetStatus()),
 	}
 
 	uuid, err := es.app.CreateNewEvent(ctx, e)
 	if err != nil {
 		es.logger.Errorw("error CreateNewEvent", "methodName", "CreateEvent", "err", err)
-		return nil, err
+		return nil, eventError(err)
 	}
 
 	es.logger.Infow("Success CreateEvent", "UUID", uuid)
@@ -128,22 +244,28 @@ func (es *EventService) UpdateEvent(ctx context.Context, request *api.UpdateRequ
 	uuid := request.GetUuid()
 	updatedEvent := request.GetEvent()
 
-	startAt, err := ptypes.Timestamp(updatedEvent.GetStartAt())
+	startAt, duration, err := eventTime(updatedEvent)
 	if err != nil {
 		es.logger.Errorw("error time conversion", "methodName", "UpdateEvent", "err", err)
-		return nil, err
+		return nil, invalidArgument("event", err)
 	}
 
-	duration, err := ptypes.Duration(updatedEvent.GetDuration())
+	notifyBefore, err := ptypes.Duration(updatedEvent.GetNotifyBefore())
 	if err != nil {
 		es.logger.Errorw("error duration conversion", "methodName", "UpdateEvent", "err", err)
-		return nil, err
+		return nil, invalidArgument("event.notifyBefore", err)
 	}
 
-	notifyBefore, err := ptypes.Duration(updatedEvent.GetNotifyBefore())
+	occurrence, err := optionalTimestamp(request.GetOccurrence())
 	if err != nil {
-		es.logger.Errorw("error duration conversion", "methodName", "UpdateEvent", "err", err)
-		return nil, err
+		es.logger.Errorw("error time conversion", "methodName", "UpdateEvent", "err", err)
+		return nil, invalidArgument("occurrence", err)
+	}
+
+	scope, err := scopeOf(request.GetScope())
+	if err != nil {
+		es.logger.Errorw("error scope conversion", "methodName", "UpdateEvent", "err", err)
+		return nil, invalidArgument("scope", err)
 	}
 
 	err = es.app.ChangeEvent(ctx, uuid, &models.Event{
@@ -151,12 +273,16 @@ func (es *EventService) UpdateEvent(ctx context.Context, request *api.UpdateRequ
 		StartAt:      startAt,
 		Duration:     duration,
 		Description:  updatedEvent.GetDescription(),
-		User:         updatedEvent.GetUser(),
+		User:         userOrCaller(ctx, updatedEvent.GetUser()),
 		NotifyBefore: notifyBefore,
-	})
+		RRule:        updatedEvent.GetRrule(),
+		AllDay:       updatedEvent.GetAllDay(),
+		Transparency: models.Transparency(updatedEvent.GetTransparency()),
+		Status:       models.Status(updatedEvent.GetStatus()),
+	}, scope, occurrence)
 	if err != nil {
 		es.logger.Errorw("error ChangeEvent", "methodName", "UpdateEvent", "err", err)
-		return nil, err
+		return nil, eventError(err)
 	}
 
 	es.logger.Infow("Success ChangeEvent", "UUID", uuid)
@@ -167,12 +293,414 @@ func (es *EventService) UpdateEvent(ctx context.Context, request *api.UpdateRequ
 func (es *EventService) DeleteEvent(ctx context.Context, request *api.DeleteRequest) (*empty.Empty, error) {
 	uuid := request.GetUuid()
 
-	err := es.app.RemoveEvent(ctx, uuid)
+	occurrence, err := optionalTimestamp(request.GetOccurrence())
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "DeleteEvent", "err", err)
+		return nil, invalidArgument("occurrence", err)
+	}
+
+	scope, err := scopeOf(request.GetScope())
+	if err != nil {
+		es.logger.Errorw("error scope conversion", "methodName", "DeleteEvent", "err", err)
+		return nil, invalidArgument("scope", err)
+	}
+
+	err = es.app.RemoveEvent(ctx, uuid, scope, occurrence)
 	if err != nil {
 		es.logger.Errorw("error RemoveEvent", "methodName", "DeleteEvent", "err", err)
-		return nil, err
+		return nil, statusError(err)
 	}
 
 	es.logger.Infow("Success DeleteEvent", "UUID", uuid)
 	return &empty.Empty{}, nil
 }
+
+// GetProfile method
+func (es *EventService) GetProfile(ctx context.Context, request *api.GetProfileRequest) (*api.Profile, error) {
+	profile, err := es.app.GetProfile(ctx, userOrCaller(ctx, request.GetUser()))
+	if err != nil {
+		es.logger.Errorw("error GetProfile", "methodName", "GetProfile", "err", err)
+		return nil, statusError(err)
+	}
+
+	es.logger.Infow("Success GetProfile", "user", profile.Name)
+	return &api.Profile{
+		User:       profile.Name,
+		TimeZone:   profile.Location.String(),
+		WeekStart:  weekdayProto(profile.WeekStart),
+		AllDayBusy: profile.AllDayBusy,
+		Email:      profile.Email,
+		WebhookUrl: profile.WebhookURL,
+		Channels:   channelsProto(profile.Channels),
+		Locale:     profile.Locale,
+	}, nil
+}
+
+// UpdateProfile method
+func (es *EventService) UpdateProfile(ctx context.Context, request *api.Profile) (*empty.Empty, error) {
+	loc, err := time.LoadLocation(request.GetTimeZone())
+	if err != nil {
+		es.logger.Errorw("error time zone", "methodName", "UpdateProfile", "err", err)
+		return nil, invalidArgument("timeZone", app.ErrInvalidTimeZone)
+	}
+
+	err = es.app.UpdateProfile(ctx, &models.User{
+		Name:       userOrCaller(ctx, request.GetUser()),
+		Location:   loc,
+		WeekStart:  weekday(request.GetWeekStart()),
+		AllDayBusy: request.GetAllDayBusy(),
+		Email:      request.GetEmail(),
+		WebhookURL: request.GetWebhookUrl(),
+		Channels:   channels(request.GetChannels()),
+		Locale:     request.GetLocale(),
+	})
+	if err != nil {
+		es.logger.Errorw("error UpdateProfile", "methodName", "UpdateProfile", "err", err)
+		return nil, statusError(err)
+	}
+
+	es.logger.Infow("Success UpdateProfile", "user", request.GetUser())
+	return &empty.Empty{}, nil
+}
+
+// FreeBusy method
+func (es *EventService) FreeBusy(ctx context.Context, request *api.FreeBusyRequest) (*api.FreeBusyResponse, error) {
+	from, err := ptypes.Timestamp(request.GetFrom())
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "FreeBusy", "err", err)
+		return nil, invalidArgument("from", err)
+	}
+
+	to, err := ptypes.Timestamp(request.GetTo())
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "FreeBusy", "err", err)
+		return nil, invalidArgument("to", err)
+	}
+
+	busy, err := es.app.FreeBusy(ctx, request.GetUsers(), from, to)
+	if err != nil {
+		es.logger.Errorw("error FreeBusy", "methodName", "FreeBusy", "err", err)
+		return nil, statusError(err)
+	}
+
+	result := make([]*api.UserBusy, 0, len(request.GetUsers()))
+	for _, user := range request.GetUsers() {
+		intervals := make([]*api.Interval, 0, len(busy[user]))
+		for _, interval := range busy[user] {
+			converted, err := intervalProto(interval)
+			if err != nil {
+				es.logger.Errorw("error time conversion", "methodName", "FreeBusy", "err", err)
+				return nil, statusError(err)
+			}
+			intervals = append(intervals, converted)
+		}
+		result = append(result, &api.UserBusy{User: user, Busy: intervals})
+	}
+
+	es.logger.Infow("Success FreeBusy", "users", request.GetUsers())
+	return &api.FreeBusyResponse{
+		Users: result,
+	}, nil
+}
+
+// FindSlot method
+func (es *EventService) FindSlot(ctx context.Context, request *api.FindSlotRequest) (*api.FindSlotResponse, error) {
+	duration, err := ptypes.Duration(request.GetDuration())
+	if err != nil {
+		es.logger.Errorw("error duration conversion", "methodName", "FindSlot", "err", err)
+		return nil, invalidArgument("duration", err)
+	}
+
+	from, err := ptypes.Timestamp(request.GetFrom())
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "FindSlot", "err", err)
+		return nil, invalidArgument("from", err)
+	}
+
+	to, err := ptypes.Timestamp(request.GetTo())
+	if err != nil {
+		es.logger.Errorw("error time conversion", "methodName", "FindSlot", "err", err)
+		return nil, invalidArgument("to", err)
+	}
+
+	query := &app.SlotQuery{
+		Users:    request.GetUsers(),
+		Duration: duration,
+		From:     from,
+		To:       to,
+		Limit:    int(request.GetLimit()),
+	}
+	if hours := request.GetWorkingHours(); hours != nil {
+		query.WorkDayStart, err = ptypes.Duration(hours.GetStart())
+		if err != nil {
+			es.logger.Errorw("error duration conversion", "methodName", "FindSlot", "err", err)
+			return nil, invalidArgument("workingHours.start", err)
+		}
+
+		query.WorkDayEnd, err = ptypes.Duration(hours.GetEnd())
+		if err != nil {
+			es.logger.Errorw("error duration conversion", "methodName", "FindSlot", "err", err)
+			return nil, invalidArgument("workingHours.end", err)
+		}
+	}
+
+	slots, err := es.app.FindSlots(ctx, query)
+	if err != nil {
+		es.logger.Errorw("error FindSlots", "methodName", "FindSlot", "err", err)
+		return nil, statusError(err)
+	}
+
+	result := make([]*api.Interval, 0, len(slots))
+	for _, slot := range slots {
+		interval, err := intervalProto(slot)
+		if err != nil {
+			es.logger.Errorw("error time conversion", "methodName", "FindSlot", "err", err)
+			return nil, statusError(err)
+		}
+		result = append(result, interval)
+	}
+
+	es.logger.Infow("Success FindSlot", "users", request.GetUsers(), "found", len(result))
+	return &api.FindSlotResponse{
+		Slots: result,
+	}, nil
+}
+
+// ExportICS method
+func (es *EventService) ExportICS(ctx context.Context, request *api.ExportRequest) (*api.ExportResponse, error) {
+	user := userOrCaller(ctx, request.GetUser())
+	profile, err := es.app.GetProfile(ctx, user)
+	if err != nil {
+		es.logger.Errorw("error GetProfile", "methodName", "ExportICS", "err", err)
+		return nil, statusError(err)
+	}
+
+	events, err := es.app.ExportEvents(ctx, user)
+	if err != nil {
+		es.logger.Errorw("error ExportEvents", "methodName", "ExportICS", "err", err)
+		return nil, statusError(err)
+	}
+
+	var calendar strings.Builder
+	err = ical.Encode(&calendar, &ical.Calendar{
+		Name:     profile.Name,
+		Location: profile.Location,
+		Stamp:    time.Now(),
+		Events:   events,
+	})
+	if err != nil {
+		es.logger.Errorw("error ical encoding", "methodName", "ExportICS", "err", err)
+		return nil, statusError(err)
+	}
+
+	es.logger.Infow("Success ExportICS", "user", user, "events", len(events))
+	return &api.ExportResponse{
+		Calendar: calendar.String(),
+	}, nil
+}
+
+// ImportICS method
+func (es *EventService) ImportICS(ctx context.Context, request *api.ImportRequest) (*api.ImportResponse, error) {
+	user := userOrCaller(ctx, request.GetUser())
+	profile, err := es.app.GetProfile(ctx, user)
+	if err != nil {
+		es.logger.Errorw("error GetProfile", "methodName", "ImportICS", "err", err)
+		return nil, statusError(err)
+	}
+
+	imported, err := ical.Decode(strings.NewReader(request.GetCalendar()), profile.Location)
+	if err != nil {
+		es.logger.Errorw("error ical decoding", "methodName", "ImportICS", "err", err)
+		return nil, invalidArgument("calendar", err)
+	}
+
+	events := make([]*models.Event, 0, len(imported))
+	for _, item := range imported {
+		if item.Err == nil {
+			events = append(events, item.Event)
+		}
+	}
+
+	saved, err := es.app.ImportEvents(ctx, user, events)
+	if err != nil {
+		es.logger.Errorw("error ImportEvents", "methodName", "ImportICS", "err", err)
+		return nil, statusError(err)
+	}
+
+	results := make([]*api.ImportResult, 0, len(imported))
+	for _, item := range imported {
+		result := &api.ImportResult{Uid: item.UID}
+		if item.Err != nil {
+			result.Status = api.ImportStatus_INVALID
+			result.Error = item.Err.Error()
+		} else {
+			// saved results come in the order of the decoded events
+			result.Uuid = saved[0].UUID
+			result.Status = importStatuses[saved[0].Status]
+			if saved[0].Err != nil {
+				result.Error = saved[0].Err.Error()
+			}
+			saved = saved[1:]
+		}
+		results = append(results, result)
+	}
+
+	es.logger.Infow("Success ImportICS", "user", user, "events", len(results))
+	return &api.ImportResponse{
+		Results: results,
+	}, nil
+}
+
+// scopeOf converts the request scope. An unknown value is rejected:
+// the zero app.Scope is the whole series, so it must not be the fallback
+func scopeOf(scope api.Scope) (app.Scope, error) {
+	result, ok := scopes[scope]
+	if !ok {
+		return 0, fmt.Errorf("%w: %d", app.ErrInvalidScope, scope)
+	}
+	return result, nil
+}
+
+// userOrCaller returns user named in the request, an authenticated caller may omit it
+func userOrCaller(ctx context.Context, user string) string {
+	if caller, ok := app.CallerFromContext(ctx); ok && user == "" {
+		return caller
+	}
+	return user
+}
+
+// eventTime converts event start and duration, all-day events are given by dates
+// and stored as UTC midnight of the start date with whole days duration
+func eventTime(event *api.Event) (time.Time, time.Duration, error) {
+	if !event.GetAllDay() {
+		startAt, err := ptypes.Timestamp(event.GetStartAt())
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		duration, err := ptypes.Duration(event.GetDuration())
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+		return startAt, duration, nil
+	}
+
+	startDate, err := time.Parse(dateLayout, event.GetStartDate())
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	endDate := startDate.AddDate(0, 0, 1)
+	if event.GetEndDate() != "" {
+		endDate, err = time.Parse(dateLayout, event.GetEndDate())
+		if err != nil {
+			return time.Time{}, 0, err
+		}
+	}
+	if !endDate.After(startDate) {
+		return time.Time{}, 0, fmt.Errorf("end date %s is not after start date %s", event.GetEndDate(), event.GetStartDate())
+	}
+	return startDate, endDate.Sub(startDate), nil
+}
+
+// eventsProto converts event occurrences, local times are rendered in loc
+func eventsProto(events []*models.Event, loc *time.Location) ([]*api.Event, error) {
+	result := make([]*api.Event, 0, len(events))
+	for _, event := range events {
+		startAt, err := ptypes.TimestampProto(event.StartAt)
+		if err != nil {
+			return nil, err
+		}
+
+		var startDate, endDate string
+		if event.AllDay {
+			startDate = event.StartAt.Format(dateLayout)
+			endDate = event.StartAt.AddDate(0, 0, event.Days()).Format(dateLayout)
+		}
+
+		recurrenceAt, err := optionalTimestampProto(event.RecurrenceAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &api.Event{
+			Uuid:         event.UUID,
+			Title:        event.Title,
+			StartAt:      startAt,
+			Duration:     ptypes.DurationProto(event.Duration),
+			Description:  event.Description,
+			User:         event.User,
+			NotifyBefore: ptypes.DurationProto(event.NotifyBefore),
+			Rrule:        event.RRule,
+			SeriesUuid:   event.SeriesUUID,
+			RecurrenceAt: recurrenceAt,
+			StartAtLocal: event.StartAt.In(loc).Format(time.RFC3339),
+			AllDay:       event.AllDay,
+			StartDate:    startDate,
+			EndDate:      endDate,
+			Transparency: api.Transparency(event.Transparency),
+			Status:       api.Status(event.Status),
+		})
+	}
+	return result, nil
+}
+
+// weekday converts ISO day number to time.Weekday, unspecified day is Monday
+func weekday(day api.Weekday) time.Weekday {
+	if day == api.Weekday_WEEKDAY_UNSPECIFIED {
+		return time.Monday
+	}
+	return time.Weekday(day % 7)
+}
+
+func weekdayProto(day time.Weekday) api.Weekday {
+	if day == time.Sunday {
+		return api.Weekday_SUNDAY
+	}
+	return api.Weekday(day)
+}
+
+// channels converts notification channels, unknown values are rejected by the application
+func channels(list []api.NotificationChannel) []models.Channel {
+	result := make([]models.Channel, 0, len(list))
+	for _, channel := range list {
+		result = append(result, models.Channel(strings.ToLower(channel.String())))
+	}
+	return result
+}
+
+func channelsProto(list []models.Channel) []api.NotificationChannel {
+	result := make([]api.NotificationChannel, 0, len(list))
+	for _, channel := range list {
+		result = append(result, api.NotificationChannel(api.NotificationChannel_value[strings.ToUpper(string(channel))]))
+	}
+	return result
+}
+
+// optionalTimestamp converts timestamp which client may omit, nil becomes zero time
+func optionalTimestamp(ts *timestamp.Timestamp) (time.Time, error) {
+	if ts == nil {
+		return time.Time{}, nil
+	}
+	return ptypes.Timestamp(ts)
+}
+
+// optionalTimestampProto converts time which may be unset, zero time becomes nil
+func optionalTimestampProto(t time.Time) (*timestamp.Timestamp, error) {
+	if t.IsZero() {
+		return nil, nil
+	}
+	return ptypes.TimestampProto(t)
+}
+
+func intervalProto(interval models.Interval) (*api.Interval, error) {
+	start, err := ptypes.TimestampProto(interval.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := ptypes.TimestampProto(interval.End)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.Interval{Start: start, End: end}, nil
+}
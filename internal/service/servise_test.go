@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/bobrovka/calendar/pkg/calendar/api"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEventService_UnknownScope(t *testing.T) {
+	type testCase struct {
+		call func(es *EventService, uuid string) error
+	}
+
+	start := time.Date(2030, time.March, 4, 9, 0, 0, 0, time.UTC)
+	startProto, err := ptypes.TimestampProto(start)
+	assert.NoError(t, err)
+	occurrence, err := ptypes.TimestampProto(start.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+
+	testCases := make(map[string]testCase)
+	testCases["delete"] = testCase{
+		call: func(es *EventService, uuid string) error {
+			_, err := es.DeleteEvent(context.Background(), &api.DeleteRequest{Uuid: uuid, Scope: api.Scope(42), Occurrence: occurrence})
+			return err
+		},
+	}
+	testCases["update"] = testCase{
+		call: func(es *EventService, uuid string) error {
+			_, err := es.UpdateEvent(context.Background(), &api.UpdateRequest{
+				Uuid:       uuid,
+				Event:      &api.Event{Title: "retro", StartAt: startProto, Duration: ptypes.DurationProto(time.Hour), User: "Kira"},
+				Scope:      api.Scope(42),
+				Occurrence: occurrence,
+			})
+			return err
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := memory.NewStorageMemory()
+			calendar, err := app.NewCalendar(storage, nil)
+			assert.NoError(t, err)
+			uuid, err := calendar.CreateNewEvent(ctx, &models.Event{Title: "standup", StartAt: start, Duration: time.Hour, User: "Kira", RRule: "FREQ=DAILY"})
+			assert.NoError(t, err)
+
+			// a scope from a newer client is not the zero value, which is the whole series
+			err = tc.call(NewEventService(calendar, zap.NewNop().Sugar()), uuid)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), err)
+
+			series, err := storage.GetEvent(ctx, uuid)
+			if assert.NoError(t, err) {
+				assert.Equal(t, "standup", series.Title)
+				assert.Equal(t, "FREQ=DAILY", series.RRule)
+				assert.Empty(t, series.ExDates)
+			}
+		})
+	}
+}
+
+func TestEventService_UnknownPeriod(t *testing.T) {
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+	date, err := ptypes.TimestampProto(time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	es := NewEventService(calendar, zap.NewNop().Sugar())
+	_, err = es.ListEvents(context.Background(), &api.ListRequest{User: "Kira", Date: date, Period: api.Period(42)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), err)
+
+	response, err := es.ListEvents(context.Background(), &api.ListRequest{User: "Kira", Date: date, Period: api.Period_DAY})
+	assert.NoError(t, err)
+	assert.Empty(t, response.Events)
+}
@@ -0,0 +1,577 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/google/uuid"
+)
+
+// txKey ключ контекста, которым помечены вызовы внутри InTransaction
+type txKey struct{}
+
+// userEvents события одного пользователя
+type userEvents struct {
+	single      []*models.Event // разовые события по возрастанию начала
+	series      []*models.Event
+	maxDuration time.Duration // самое длинное разовое событие, нужно для поиска пересечений
+}
+
+// outboxEntry напоминание в outbox
+type outboxEntry struct {
+	message     *models.OutboxMessage
+	deliverAt   time.Time
+	leasedUntil time.Time
+}
+
+// StorageMemory хранилище событий в памяти, для тестов и локального запуска
+type StorageMemory struct {
+	mu       sync.RWMutex
+	txMu     sync.Mutex
+	events   map[string]*models.Event
+	byUser   map[string]*userEvents
+	users    map[string]*models.User
+	outbox   map[int64]*outboxEntry
+	outboxID int64
+}
+
+// NewStorageMemory создает пустое хранилище
+func NewStorageMemory() *StorageMemory {
+	return &StorageMemory{
+		events: make(map[string]*models.Event),
+		byUser: make(map[string]*userEvents),
+		users:  make(map[string]*models.User),
+		outbox: make(map[int64]*outboxEntry),
+	}
+}
+
+// ListEvents вернет разовые события, пересекающиеся с [from, to), и серии, начавшиеся до to
+func (s *StorageMemory) ListEvents(_ context.Context, user string, from, to time.Time) ([]*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.find(user, from, to), nil
+}
+
+// ListEventsPage вернет страницу разовых событий со временем начала
+func (s *StorageMemory) ListEventsPage(_ context.Context, query *models.PageQuery) ([]*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ue, ok := s.byUser[query.User]
+	if !ok {
+		return nil, nil
+	}
+
+	var result []*models.Event
+	i := sort.Search(len(ue.single), func(i int) bool {
+		return !ue.single[i].StartAt.Before(query.From.Add(-ue.maxDuration))
+	})
+	for ; i < len(ue.single) && ue.single[i].StartAt.Before(query.To); i++ {
+		e := ue.single[i]
+		if e.AllDay || !e.Overlaps(query.From, query.To) {
+			continue
+		}
+		if query.Follows(e.Key()) {
+			result = append(result, copyEvent(e))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if query.Desc {
+			return result[j].Key().Less(result[i].Key())
+		}
+		return result[i].Key().Less(result[j].Key())
+	})
+	if len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// ListSeriesAndAllDay вернет серии и события на целый день, которые вернул бы ListEvents
+func (s *StorageMemory) ListSeriesAndAllDay(_ context.Context, user string, from, to time.Time) ([]*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*models.Event
+	for _, e := range s.find(user, from, to) {
+		if e.RRule != "" || e.AllDay {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// GetEvent вернет событие или app.ErrNotFound
+func (s *StorageMemory) GetEvent(_ context.Context, id string) (*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.events[id]
+	if !ok {
+		return nil, app.ErrNotFound
+	}
+	return copyEvent(e), nil
+}
+
+// ListOverrides вернет перенесенные экземпляры серии
+func (s *StorageMemory) ListOverrides(_ context.Context, seriesID string) ([]*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*models.Event
+	for _, e := range s.events {
+		if e.SeriesUUID == seriesID {
+			result = append(result, copyEvent(e))
+		}
+	}
+	return result, nil
+}
+
+// GetEventByExternalUID вернет событие пользователя с iCalendar UID или app.ErrNotFound
+func (s *StorageMemory) GetEventByExternalUID(_ context.Context, user, uid string) (*models.Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, e := range s.events {
+		if e.User == user && e.ExternalUID == uid {
+			return copyEvent(e), nil
+		}
+	}
+	return nil, app.ErrNotFound
+}
+
+// CreateEvent сохранит событие
+func (s *StorageMemory) CreateEvent(_ context.Context, event *models.Event) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.insert(event), nil
+}
+
+// UpdateEvent заменит событие
+func (s *StorageMemory) UpdateEvent(_ context.Context, id string, event *models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.events[id]
+	if !ok {
+		return nil
+	}
+
+	updated := copyEvent(event)
+	updated.UUID = id
+	updated.ExDates = old.ExDates
+	updated.SeriesUUID, updated.RecurrenceAt = old.SeriesUUID, old.RecurrenceAt
+	updated.ExternalUID = old.ExternalUID
+
+	// remove удаляет и напоминание, поэтому неотправленное сохраняется заранее
+	var pending []*outboxEntry
+	for _, entry := range s.outbox {
+		if entry.message.EventUUID == id {
+			pending = append(pending, entry)
+		}
+	}
+	s.remove(id)
+	s.put(updated)
+
+	if updated.RRule != "" {
+		s.scheduleSeries(updated, pending)
+		return nil
+	}
+
+	deliverAt := s.notifyAt(updated)
+	if deliverAt.Equal(s.notifyAt(old)) {
+		// время напоминания прежнее: неотправленное получит новые данные события, отправленное не повторится
+		payload, err := json.Marshal(updated)
+		if err != nil {
+			return err
+		}
+		for _, entry := range pending {
+			entry.message.Payload = payload
+			s.outbox[entry.message.ID] = entry
+		}
+		return nil
+	}
+
+	// о прошедшем времени напоминать поздно
+	if deliverAt.After(time.Now()) {
+		s.enqueue(updated)
+	}
+	return nil
+}
+
+// DeleteEvent удалит событие вместе с перенесенными экземплярами серии
+func (s *StorageMemory) DeleteEvent(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(id)
+	for _, e := range s.events {
+		if e.SeriesUUID == id {
+			s.remove(e.UUID)
+		}
+	}
+	return nil
+}
+
+// CancelOccurrence отменит экземпляр серии
+func (s *StorageMemory) CancelOccurrence(_ context.Context, id string, occurrence time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.exclude(id, occurrence)
+	s.reschedule(id)
+	return nil
+}
+
+// OverrideOccurrence заменит экземпляр серии отдельным событием
+func (s *StorageMemory) OverrideOccurrence(_ context.Context, id string, occurrence time.Time, override *models.Event) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.exclude(id, occurrence)
+	s.reschedule(id)
+	return s.insert(override), nil
+}
+
+// SplitSeries обрежет серию и создаст ее продолжение
+func (s *StorageMemory) SplitSeries(_ context.Context, id string, at time.Time, rrule string, tail *models.Event) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// исключения и замененные экземпляры начиная с at переходят к продолжению серии, а без него удаляются
+	var moved []time.Time
+	if series, ok := s.events[id]; ok {
+		series.RRule = rrule
+		exDates := series.ExDates[:0:0]
+		for _, exDate := range series.ExDates {
+			if exDate.Before(at) {
+				exDates = append(exDates, exDate)
+			} else {
+				moved = append(moved, exDate)
+			}
+		}
+		series.ExDates = exDates
+	}
+
+	var tailID string
+	if tail != nil {
+		tailID = s.insert(tail)
+		for _, exDate := range moved {
+			s.exclude(tailID, exDate)
+		}
+		s.reschedule(tailID)
+	}
+	s.reschedule(id)
+
+	for _, e := range s.events {
+		if e.SeriesUUID != id || e.RecurrenceAt.Before(at) {
+			continue
+		}
+		if tail == nil {
+			s.remove(e.UUID)
+		} else {
+			e.SeriesUUID = tailID
+		}
+	}
+
+	return tailID, nil
+}
+
+// GetUser вернет профиль пользователя или app.ErrNotFound
+func (s *StorageMemory) GetUser(_ context.Context, name string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[name]
+	if !ok {
+		return nil, app.ErrNotFound
+	}
+	profile := *user
+	return &profile, nil
+}
+
+// SaveUser сохранит профиль пользователя
+func (s *StorageMemory) SaveUser(_ context.Context, user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile := *user
+	s.users[user.Name] = &profile
+
+	// с часовым поясом сдвигаются местные полночи, от которых отсчитываются напоминания о событиях на целый день
+	for _, entry := range s.outbox {
+		if e, ok := s.events[entry.message.EventUUID]; ok && e.User == user.Name && e.AllDay && e.RRule == "" {
+			entry.deliverAt = s.notifyAt(e)
+		}
+	}
+	// серии разворачиваются в часовом поясе пользователя, поэтому ближайший экземпляр ищется заново
+	if ue, ok := s.byUser[user.Name]; ok {
+		for _, e := range ue.series {
+			s.reschedule(e.UUID)
+		}
+	}
+	return nil
+}
+
+// InTransaction выполнит fn, не пуская параллельно другие транзакции
+func (s *StorageMemory) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+// LeaseNotifications вернет напоминания, срок которых наступил, и скроет их на lease
+func (s *StorageMemory) LeaseNotifications(_ context.Context, limit int, lease time.Duration) ([]*models.OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []*outboxEntry
+	for _, entry := range s.outbox {
+		if !entry.deliverAt.After(now) && !entry.leasedUntil.After(now) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].message.ID < due[j].message.ID
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	messages := make([]*models.OutboxMessage, 0, len(due))
+	for _, entry := range due {
+		entry.leasedUntil = now.Add(lease)
+		m := *entry.message
+		messages = append(messages, &m)
+	}
+	return messages, nil
+}
+
+// AckNotifications удалит отправленные напоминания. Вместо напоминания об экземпляре серии
+// в outbox записывается напоминание о следующем
+func (s *StorageMemory) AckNotifications(_ context.Context, ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		entry, ok := s.outbox[id]
+		if !ok {
+			continue
+		}
+		delete(s.outbox, id)
+
+		series, ok := s.events[entry.message.EventUUID]
+		if !ok || series.RRule == "" || s.hasPending(series.UUID) {
+			continue
+		}
+		var delivered models.Event
+		if err := json.Unmarshal(entry.message.Payload, &delivered); err != nil {
+			return err
+		}
+		after := time.Now()
+		if delivered.StartAt.After(after) {
+			after = delivered.StartAt
+		}
+		s.enqueueNext(series, after, time.Time{})
+	}
+	return nil
+}
+
+// notifyAt вернет время напоминания о событии: о событии на целый день напоминают
+// от местной полуночи пользователя, без профиля - от полуночи UTC, как StoragePg
+func (s *StorageMemory) notifyAt(e *models.Event) time.Time {
+	return e.NotifyAt(s.location(e.User))
+}
+
+// location вернет часовой пояс пользователя, без профиля - UTC
+func (s *StorageMemory) location(user string) *time.Location {
+	if profile, ok := s.users[user]; ok {
+		return profile.Location
+	}
+	return time.UTC
+}
+
+// find вернет серии пользователя, начавшиеся до to, и разовые события, пересекающиеся с [from, to).
+// События на целый день берутся с запасом на часовые пояса
+func (s *StorageMemory) find(user string, from, to time.Time) []*models.Event {
+	ue, ok := s.byUser[user]
+	if !ok {
+		return nil
+	}
+
+	var result []*models.Event
+	// события на целый день хранятся в UTC, а показываются в местные сутки, поэтому окно для них шире
+	lo := from.Add(-ue.maxDuration - models.FloatingSlack)
+	hi := to.Add(models.FloatingSlack)
+	i := sort.Search(len(ue.single), func(i int) bool {
+		return !ue.single[i].StartAt.Before(lo)
+	})
+	for ; i < len(ue.single) && ue.single[i].StartAt.Before(hi); i++ {
+		e := ue.single[i]
+		if e.Overlaps(from, to) || e.AllDay && e.Overlaps(from.Add(-models.FloatingSlack), hi) {
+			result = append(result, copyEvent(e))
+		}
+	}
+
+	for _, e := range ue.series {
+		if e.StartAt.Before(to) || e.AllDay && e.StartAt.Before(hi) {
+			result = append(result, copyEvent(e))
+		}
+	}
+
+	return result
+}
+
+func (s *StorageMemory) insert(event *models.Event) string {
+	e := copyEvent(event)
+	e.UUID = uuid.New().String()
+	s.put(e)
+	if e.RRule != "" {
+		s.enqueueNext(e, time.Now(), time.Time{})
+		return e.UUID
+	}
+	// о начавшемся событии не напоминают
+	if s.notifyAt(e).Add(e.NotifyBefore).After(time.Now()) {
+		s.enqueue(e)
+	}
+	return e.UUID
+}
+
+// enqueueNext запишет в outbox напоминание о ближайшем экземпляре серии, начинающемся после after,
+// напоминание о котором не раньше notBefore. В outbox у серии одно напоминание, следующее
+// записывается после отправки
+func (s *StorageMemory) enqueueNext(series *models.Event, after, notBefore time.Time) {
+	occurrence, err := series.NextReminder(s.location(series.User), after, notBefore)
+	if err != nil || occurrence == nil {
+		return
+	}
+	s.enqueue(occurrence)
+}
+
+// reschedule заменит напоминание об экземпляре серии после ее изменения
+func (s *StorageMemory) reschedule(id string) {
+	var pending []*outboxEntry
+	for outboxID, entry := range s.outbox {
+		if entry.message.EventUUID == id {
+			pending = append(pending, entry)
+			delete(s.outbox, outboxID)
+		}
+	}
+	if series, ok := s.events[id]; ok && series.RRule != "" {
+		s.scheduleSeries(series, pending)
+	}
+}
+
+// scheduleSeries запишет напоминание о ближайшем экземпляре серии вместо неотправленных pending.
+// Как для разового события, о прошедшем времени напоминать поздно, но просроченное
+// неотправленное напоминание остается в силе
+func (s *StorageMemory) scheduleSeries(series *models.Event, pending []*outboxEntry) {
+	now := time.Now()
+	notBefore := now
+	for _, entry := range pending {
+		if entry.deliverAt.Before(notBefore) {
+			notBefore = entry.deliverAt
+		}
+	}
+	s.enqueueNext(series, now, notBefore)
+}
+
+func (s *StorageMemory) hasPending(id string) bool {
+	for _, entry := range s.outbox {
+		if entry.message.EventUUID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue запишет напоминание о событии в outbox, как StoragePg в транзакции записи события
+func (s *StorageMemory) enqueue(e *models.Event) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+
+	s.outboxID++
+	s.outbox[s.outboxID] = &outboxEntry{
+		message:   &models.OutboxMessage{ID: s.outboxID, EventUUID: e.UUID, Payload: payload},
+		deliverAt: s.notifyAt(e),
+	}
+}
+
+func (s *StorageMemory) put(e *models.Event) {
+	s.events[e.UUID] = e
+
+	ue, ok := s.byUser[e.User]
+	if !ok {
+		ue = &userEvents{}
+		s.byUser[e.User] = ue
+	}
+
+	if e.RRule != "" {
+		ue.series = append(ue.series, e)
+		return
+	}
+
+	if e.Duration > ue.maxDuration {
+		ue.maxDuration = e.Duration
+	}
+	i := sort.Search(len(ue.single), func(i int) bool {
+		return e.StartAt.Before(ue.single[i].StartAt)
+	})
+	ue.single = append(ue.single, nil)
+	copy(ue.single[i+1:], ue.single[i:])
+	ue.single[i] = e
+}
+
+func (s *StorageMemory) remove(id string) {
+	e, ok := s.events[id]
+	if !ok {
+		return
+	}
+	delete(s.events, id)
+	for outboxID, entry := range s.outbox {
+		if entry.message.EventUUID == id {
+			delete(s.outbox, outboxID)
+		}
+	}
+
+	ue := s.byUser[e.User]
+	ue.single = without(ue.single, e)
+	ue.series = without(ue.series, e)
+}
+
+func (s *StorageMemory) exclude(id string, occurrence time.Time) {
+	if series, ok := s.events[id]; ok && !series.IsExcluded(occurrence) {
+		series.ExDates = append(series.ExDates, occurrence)
+	}
+}
+
+func without(events []*models.Event, e *models.Event) []*models.Event {
+	for i := range events {
+		if events[i] == e {
+			return append(events[:i], events[i+1:]...)
+		}
+	}
+	return events
+}
+
+func copyEvent(e *models.Event) *models.Event {
+	c := *e
+	c.ExDates = append([]time.Time(nil), e.ExDates...)
+	return &c
+}
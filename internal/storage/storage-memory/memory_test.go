@@ -0,0 +1,322 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageMemory_ListEventsOverlapping(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorageMemory()
+
+	day := time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC)
+	events := map[string]*models.Event{
+		"overnight":      {Title: "overnight", StartAt: day.Add(-time.Hour), Duration: 3 * time.Hour, User: "Kira"},
+		"at midnight":    {Title: "at midnight", StartAt: day, Duration: time.Hour, User: "Kira"},
+		"vacation":       {Title: "vacation", StartAt: day.AddDate(0, 0, -3), Duration: 7 * 24 * time.Hour, User: "Kira"},
+		"ends at start":  {Title: "ends at start", StartAt: day.Add(-time.Hour), Duration: time.Hour, User: "Kira"},
+		"starts at end":  {Title: "starts at end", StartAt: day.AddDate(0, 0, 1), Duration: time.Hour, User: "Kira"},
+		"other user":     {Title: "other user", StartAt: day.Add(time.Hour), Duration: time.Hour, User: "Ivan"},
+		"zero at midday": {Title: "zero at midday", StartAt: day.Add(12 * time.Hour), User: "Kira"},
+		// дата в UTC, в восточных часовых поясах ее сутки начинаются внутри окна
+		"all day next":  {Title: "all day next", StartAt: day.AddDate(0, 0, 1), Duration: 24 * time.Hour, User: "Kira", AllDay: true},
+		"all day later": {Title: "all day later", StartAt: day.AddDate(0, 0, 2), Duration: 24 * time.Hour, User: "Kira", AllDay: true},
+	}
+	for _, e := range events {
+		_, err := storage.CreateEvent(ctx, e)
+		assert.NoError(t, err)
+	}
+
+	result, err := storage.ListEvents(ctx, "Kira", day, day.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+
+	titles := make([]string, 0, len(result))
+	for _, e := range result {
+		titles = append(titles, e.Title)
+	}
+	assert.ElementsMatch(t, []string{"overnight", "at midnight", "vacation", "zero at midday", "all day next"}, titles)
+}
+
+func TestStorageMemory_ListEventsPage(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorageMemory()
+
+	day := time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC)
+	for _, e := range []*models.Event{
+		{Title: "first", StartAt: day.Add(9 * time.Hour), Duration: time.Hour, User: "Kira"},
+		{Title: "second", StartAt: day.Add(10 * time.Hour), Duration: time.Hour, User: "Kira"},
+		{Title: "third", StartAt: day.Add(11 * time.Hour), Duration: time.Hour, User: "Kira"},
+		{Title: "series", StartAt: day.Add(8 * time.Hour), Duration: time.Hour, User: "Kira", RRule: "FREQ=DAILY"},
+		{Title: "all day", StartAt: day, Duration: 24 * time.Hour, User: "Kira", AllDay: true},
+	} {
+		_, err := storage.CreateEvent(ctx, e)
+		assert.NoError(t, err)
+	}
+
+	query := &models.PageQuery{User: "Kira", From: day, To: day.AddDate(0, 0, 1), Limit: 2}
+	page, err := storage.ListEventsPage(ctx, query)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 2) {
+		assert.Equal(t, "first", page[0].Title)
+		assert.Equal(t, "second", page[1].Title)
+	}
+
+	query.After = page[1].Key()
+	page, err = storage.ListEventsPage(ctx, query)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, "third", page[0].Title)
+	}
+
+	query.After, query.Desc = models.PageKey{}, true
+	page, err = storage.ListEventsPage(ctx, query)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 2) {
+		assert.Equal(t, "third", page[0].Title)
+		assert.Equal(t, "second", page[1].Title)
+	}
+
+	query.After = page[1].Key()
+	page, err = storage.ListEventsPage(ctx, query)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, "first", page[0].Title)
+	}
+
+	rest, err := storage.ListSeriesAndAllDay(ctx, "Kira", day, day.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Len(t, rest, 2)
+}
+
+func TestStorageMemory_SplitSeries(t *testing.T) {
+	type testCase struct {
+		tail           *models.Event
+		expExDates     []time.Time
+		expTailExDates []time.Time
+		expMoved       bool
+	}
+
+	day := time.Date(2020, time.March, 2, 9, 0, 0, 0, time.UTC)
+	at := day.AddDate(0, 0, 3)
+
+	testCases := make(map[string]testCase)
+	testCases["with tail"] = testCase{
+		tail:           &models.Event{Title: "standup", StartAt: at, Duration: time.Hour, User: "Kira", RRule: "FREQ=DAILY"},
+		expExDates:     []time.Time{day.AddDate(0, 0, 1), day.AddDate(0, 0, 2)},
+		expTailExDates: []time.Time{day.AddDate(0, 0, 4), day.AddDate(0, 0, 5)},
+		expMoved:       true,
+	}
+	testCases["without tail"] = testCase{
+		expExDates: []time.Time{day.AddDate(0, 0, 1), day.AddDate(0, 0, 2)},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			storage := NewStorageMemory()
+
+			id, err := storage.CreateEvent(ctx, &models.Event{Title: "standup", StartAt: day, Duration: time.Hour, User: "Kira", RRule: "FREQ=DAILY"})
+			assert.NoError(t, err)
+			assert.NoError(t, storage.CancelOccurrence(ctx, id, day.AddDate(0, 0, 1)))
+			assert.NoError(t, storage.CancelOccurrence(ctx, id, day.AddDate(0, 0, 4)))
+			// замененный экземпляр тоже исключен из серии
+			before := day.AddDate(0, 0, 2)
+			earlyID, err := storage.OverrideOccurrence(ctx, id, before, &models.Event{Title: "moved", StartAt: before.Add(time.Hour), Duration: time.Hour, User: "Kira", SeriesUUID: id, RecurrenceAt: before})
+			assert.NoError(t, err)
+			after := day.AddDate(0, 0, 5)
+			lateID, err := storage.OverrideOccurrence(ctx, id, after, &models.Event{Title: "moved", StartAt: after.Add(time.Hour), Duration: time.Hour, User: "Kira", SeriesUUID: id, RecurrenceAt: after})
+			assert.NoError(t, err)
+
+			tailID, err := storage.SplitSeries(ctx, id, at, "FREQ=DAILY;COUNT=3", tc.tail)
+			assert.NoError(t, err)
+
+			series, err := storage.GetEvent(ctx, id)
+			assert.NoError(t, err)
+			assert.Equal(t, "FREQ=DAILY;COUNT=3", series.RRule)
+			assert.Equal(t, tc.expExDates, series.ExDates)
+
+			early, err := storage.GetEvent(ctx, earlyID)
+			assert.NoError(t, err)
+			assert.Equal(t, id, early.SeriesUUID)
+
+			late, err := storage.GetEvent(ctx, lateID)
+			if !tc.expMoved {
+				assert.Equal(t, "", tailID)
+				assert.Equal(t, app.ErrNotFound, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tailID, late.SeriesUUID)
+
+			tail, err := storage.GetEvent(ctx, tailID)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expTailExDates, tail.ExDates)
+		})
+	}
+}
+
+func TestStorageMemory_NotificationOutbox(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorageMemory()
+
+	soon := &models.Event{Title: "soon", StartAt: time.Now().Add(time.Minute), Duration: time.Hour, NotifyBefore: 5 * time.Minute, User: "Kira"}
+	later := &models.Event{Title: "later", StartAt: time.Now().Add(24 * time.Hour), Duration: time.Hour, NotifyBefore: 5 * time.Minute, User: "Kira"}
+	soonID, err := storage.CreateEvent(ctx, soon)
+	assert.NoError(t, err)
+	laterID, err := storage.CreateEvent(ctx, later)
+	assert.NoError(t, err)
+
+	messages, err := storage.LeaseNotifications(ctx, 10, time.Hour)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, soonID, messages[0].EventUUID)
+		assert.Contains(t, string(messages[0].Payload), `"Title":"soon"`)
+	}
+
+	// пока аренда не истекла, напоминание не выдается повторно, но и не пропадает
+	again, err := storage.LeaseNotifications(ctx, 10, time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, again)
+	assert.Len(t, storage.outbox, 2)
+
+	assert.NoError(t, storage.AckNotifications(ctx, []int64{messages[0].ID}))
+	assert.Len(t, storage.outbox, 1)
+
+	// правка события после отправки напоминания не повторяет его
+	soon.Title = "soon, room 4"
+	assert.NoError(t, storage.UpdateEvent(ctx, soonID, soon))
+	assert.Len(t, storage.outbox, 1)
+
+	// неотправленное напоминание с прежним временем получает новые данные события
+	later.Title = "later, room 4"
+	assert.NoError(t, storage.UpdateEvent(ctx, laterID, later))
+	if assert.Len(t, storage.outbox, 1) {
+		for _, entry := range storage.outbox {
+			assert.Contains(t, string(entry.message.Payload), `"Title":"later, room 4"`)
+		}
+	}
+
+	// перенесенное событие напомнит о себе в новое время, удаленное - нет
+	later.StartAt = time.Now().Add(time.Hour)
+	assert.NoError(t, storage.UpdateEvent(ctx, laterID, later))
+	assert.NoError(t, storage.DeleteEvent(ctx, soonID))
+	if assert.Len(t, storage.outbox, 1) {
+		for _, entry := range storage.outbox {
+			assert.Equal(t, laterID, entry.message.EventUUID)
+			assert.Equal(t, later.StartAt.Add(-later.NotifyBefore), entry.deliverAt)
+		}
+	}
+
+	// о прошедшем событии не напоминают ни при переносе, ни при создании
+	later.StartAt = time.Now().Add(-time.Hour)
+	assert.NoError(t, storage.UpdateEvent(ctx, laterID, later))
+	_, err = storage.CreateEvent(ctx, &models.Event{Title: "past", StartAt: time.Now().Add(-24 * time.Hour), Duration: time.Hour, User: "Kira"})
+	assert.NoError(t, err)
+	assert.Empty(t, storage.outbox)
+}
+
+func TestStorageMemory_NotificationOutboxSeries(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorageMemory()
+
+	start := time.Now().Add(time.Minute)
+	daily := &models.Event{Title: "standup", StartAt: start, Duration: 15 * time.Minute, NotifyBefore: 5 * time.Minute, RRule: "FREQ=DAILY", User: "Kira"}
+	id, err := storage.CreateEvent(ctx, daily)
+	assert.NoError(t, err)
+
+	day := func(n int) time.Time {
+		return start.AddDate(0, 0, n-1)
+	}
+	// у серии в outbox одно напоминание, о ближайшем экземпляре
+	pending := func() *outboxEntry {
+		if !assert.Len(t, storage.outbox, 1) {
+			return nil
+		}
+		for _, entry := range storage.outbox {
+			assert.Equal(t, id, entry.message.EventUUID)
+			return entry
+		}
+		return nil
+	}
+
+	messages, err := storage.LeaseNotifications(ctx, 10, time.Hour)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 1) {
+		var occurrence models.Event
+		assert.NoError(t, json.Unmarshal(messages[0].Payload, &occurrence))
+		assert.True(t, day(1).Equal(occurrence.StartAt))
+	}
+
+	// после отправки напоминания о первом дне ждет напоминание о втором
+	assert.NoError(t, storage.AckNotifications(ctx, []int64{messages[0].ID}))
+	if entry := pending(); entry != nil {
+		assert.True(t, day(2).Add(-daily.NotifyBefore).Equal(entry.deliverAt))
+	}
+
+	// об отмененном экземпляре не напоминают, о замененном напоминает замена
+	assert.NoError(t, storage.CancelOccurrence(ctx, id, day(2)))
+	if entry := pending(); entry != nil {
+		assert.True(t, day(3).Add(-daily.NotifyBefore).Equal(entry.deliverAt))
+	}
+	override := *daily
+	override.RRule, override.StartAt = "", day(3).Add(time.Hour)
+	overrideID, err := storage.OverrideOccurrence(ctx, id, day(3), &override)
+	assert.NoError(t, err)
+	assert.Len(t, storage.outbox, 2)
+	for _, entry := range storage.outbox {
+		if entry.message.EventUUID == overrideID {
+			assert.True(t, override.StartAt.Add(-daily.NotifyBefore).Equal(entry.deliverAt))
+		} else {
+			assert.True(t, day(4).Add(-daily.NotifyBefore).Equal(entry.deliverAt))
+		}
+	}
+
+	// серия, первый экземпляр которой прошел, напоминает о следующем
+	storage = NewStorageMemory()
+	daily.StartAt = start.AddDate(0, 0, -10)
+	id, err = storage.CreateEvent(ctx, daily)
+	assert.NoError(t, err)
+	if entry := pending(); entry != nil {
+		assert.True(t, day(1).Add(-daily.NotifyBefore).Equal(entry.deliverAt))
+	}
+}
+
+func TestStorageMemory_NotificationOutboxAllDay(t *testing.T) {
+	ctx := context.Background()
+	storage := NewStorageMemory()
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+	assert.NoError(t, storage.SaveUser(ctx, &models.User{Name: "Kira", Location: newYork}))
+
+	// дата хранится полуночью UTC, а напоминают за час до полуночи в Нью-Йорке
+	year, month, date := time.Now().AddDate(0, 0, 7).Date()
+	vacation := &models.Event{Title: "vacation", StartAt: time.Date(year, month, date, 0, 0, 0, 0, time.UTC), Duration: 24 * time.Hour, NotifyBefore: time.Hour, User: "Kira", AllDay: true}
+	_, err = storage.CreateEvent(ctx, vacation)
+	assert.NoError(t, err)
+	// у Лены нет профиля, ее сутки идут по UTC
+	_, err = storage.CreateEvent(ctx, &models.Event{Title: "holiday", StartAt: vacation.StartAt, Duration: 24 * time.Hour, NotifyBefore: time.Hour, User: "Lena", AllDay: true})
+	assert.NoError(t, err)
+
+	deliverAt := func(user string) time.Time {
+		for _, entry := range storage.outbox {
+			if storage.events[entry.message.EventUUID].User == user {
+				return entry.deliverAt
+			}
+		}
+		return time.Time{}
+	}
+	assert.True(t, time.Date(year, month, date, 0, 0, 0, 0, newYork).Add(-time.Hour).Equal(deliverAt("Kira")))
+	assert.True(t, vacation.StartAt.Add(-time.Hour).Equal(deliverAt("Lena")))
+
+	// смена часового пояса переносит напоминание на новую местную полночь
+	assert.NoError(t, storage.SaveUser(ctx, &models.User{Name: "Kira", Location: tokyo}))
+	assert.True(t, time.Date(year, month, date, 0, 0, 0, 0, tokyo).Add(-time.Hour).Equal(deliverAt("Kira")))
+}
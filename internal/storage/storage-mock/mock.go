@@ -24,6 +24,61 @@ func (m *StorageMock) ListEvents(ctx context.Context, user string, from, to time
 	return args.Get(0).([]*models.Event), err
 }
 
+// ListEventsPage мокирует метод
+func (m *StorageMock) ListEventsPage(ctx context.Context, query *models.PageQuery) ([]*models.Event, error) {
+	args := m.Called(ctx, query)
+	err := args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]*models.Event), err
+}
+
+// ListSeriesAndAllDay мокирует метод
+func (m *StorageMock) ListSeriesAndAllDay(ctx context.Context, user string, from, to time.Time) ([]*models.Event, error) {
+	args := m.Called(ctx, user, from, to)
+	err := args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]*models.Event), err
+}
+
+// GetEvent мокирует метод
+func (m *StorageMock) GetEvent(ctx context.Context, id string) (*models.Event, error) {
+	args := m.Called(ctx, id)
+	err := args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).(*models.Event), err
+}
+
+// ListOverrides мокирует метод
+func (m *StorageMock) ListOverrides(ctx context.Context, seriesID string) ([]*models.Event, error) {
+	args := m.Called(ctx, seriesID)
+	err := args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]*models.Event), err
+}
+
+// GetEventByExternalUID мокирует метод
+func (m *StorageMock) GetEventByExternalUID(ctx context.Context, user, uid string) (*models.Event, error) {
+	args := m.Called(ctx, user, uid)
+	err := args.Error(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).(*models.Event), err
+}
+
 // CreateEvent мокирует метод
 func (m *StorageMock) CreateEvent(ctx context.Context, event *models.Event) (string, error) {
 	args := m.Called(ctx, event)
@@ -47,12 +102,52 @@ func (m *StorageMock) DeleteEvent(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
-func (m *StorageMock) PopNotifications(ctx context.Context) ([]*models.Event, error) {
-	args := m.Called(ctx)
+// CancelOccurrence мокирует метод
+func (m *StorageMock) CancelOccurrence(ctx context.Context, id string, occurrence time.Time) error {
+	args := m.Called(ctx, id, occurrence)
+	return args.Error(0)
+}
+
+// OverrideOccurrence мокирует метод
+func (m *StorageMock) OverrideOccurrence(ctx context.Context, id string, occurrence time.Time, override *models.Event) (string, error) {
+	args := m.Called(ctx, id, occurrence, override)
+	err := args.Error(1)
+	if err != nil {
+		return "", err
+	}
+
+	return args.String(0), err
+}
+
+// SplitSeries мокирует метод
+func (m *StorageMock) SplitSeries(ctx context.Context, id string, at time.Time, rrule string, tail *models.Event) (string, error) {
+	args := m.Called(ctx, id, at, rrule, tail)
+	err := args.Error(1)
+	if err != nil {
+		return "", err
+	}
+
+	return args.String(0), err
+}
+
+// GetUser мокирует метод
+func (m *StorageMock) GetUser(ctx context.Context, name string) (*models.User, error) {
+	args := m.Called(ctx, name)
 	err := args.Error(1)
 	if err != nil {
 		return nil, err
 	}
 
-	return args.Get(0).([]*models.Event), err
+	return args.Get(0).(*models.User), err
+}
+
+// SaveUser мокирует метод
+func (m *StorageMock) SaveUser(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+// InTransaction выполняет fn без транзакции
+func (m *StorageMock) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
 }
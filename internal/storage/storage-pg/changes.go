@@ -0,0 +1,119 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/models"
+	"github.com/cenkalti/backoff/v3"
+	"github.com/jackc/pgx/v4"
+	"go.uber.org/zap"
+)
+
+// changesChannel канал NOTIFY, через который экземпляры сервиса узнают об изменениях друг друга
+const changesChannel = "calendar_changes"
+
+// ChangeFeed шина изменений для нескольких экземпляров сервиса. Изменения публикуются через NOTIFY
+// в транзакции записи, а Listen передает в шину процесса изменения всех экземпляров, включая этот.
+// NOTIFY доставляется только вместе с фиксацией транзакции, поэтому подписчики не увидят изменение
+// раньше записи и не потеряют записанное
+type ChangeFeed struct {
+	bus     *app.Bus
+	storage *StoragePg
+	logger  *zap.SugaredLogger
+}
+
+// NewChangeFeed создает шину изменений поверх базы хранилища
+func NewChangeFeed(storage *StoragePg, logger *zap.SugaredLogger) *ChangeFeed {
+	return &ChangeFeed{
+		bus:     app.NewBus(),
+		storage: storage,
+		logger:  logger,
+	}
+}
+
+// Publish ...
+func (f *ChangeFeed) Publish(ctx context.Context, change *models.Change) error {
+	payload, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	_, err = f.storage.conn(ctx).ExecContext(ctx, `SELECT pg_notify($1, $2)`, changesChannel, string(payload))
+	return err
+}
+
+// Subscribe ...
+func (f *ChangeFeed) Subscribe(user string) (<-chan *models.Change, func()) {
+	return f.bus.Subscribe(user)
+}
+
+// Close отключит подписчиков шины процесса перед остановкой сервиса
+func (f *ChangeFeed) Close() {
+	f.bus.Close()
+}
+
+// Listen передает в шину процесса изменения всех экземпляров, пока не отменен ctx.
+// Соединение для LISTEN отдельное от пула и восстанавливается после обрыва, а подписчики
+// при этом отключаются: изменения за время обрыва потеряны и нужен новый снимок
+func (f *ChangeFeed) Listen(ctx context.Context) error {
+	be := backoff.NewExponentialBackOff()
+	be.MaxElapsedTime = 0
+	be.MaxInterval = 15 * time.Second
+	b := backoff.WithContext(be, ctx)
+
+	reconnect := false
+	for {
+		err := f.listen(ctx, func() {
+			b.Reset()
+			if reconnect {
+				f.bus.DisconnectAll()
+			}
+			reconnect = true
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		d := b.NextBackOff()
+		if d == backoff.Stop {
+			return ctx.Err()
+		}
+		f.logger.Errorw("change feed connection lost", "err", err, "retryIn", d)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+		}
+	}
+}
+
+func (f *ChangeFeed) listen(ctx context.Context, listening func()) error {
+	conn, err := pgx.Connect(ctx, f.storage.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	_, err = conn.Exec(ctx, `LISTEN `+changesChannel)
+	if err != nil {
+		return err
+	}
+	listening()
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var change models.Change
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			f.logger.Errorw("cannot decode change", "payload", notification.Payload, "err", err)
+			continue
+		}
+		_ = f.bus.Publish(ctx, &change)
+	}
+}
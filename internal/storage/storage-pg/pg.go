@@ -2,57 +2,163 @@ package pg
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	app "github.com/bobrovka/calendar/internal/calendar-app"
 	"github.com/bobrovka/calendar/internal/models"
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jmoiron/sqlx"
 )
 
+const (
+	// код нарушения ограничения-исключения events_no_overlap
+	exclusionViolation = "23P01"
+	// коды ошибок, после которых сериализуемую транзакцию можно повторить
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+
+	// maxTxAttempts сколько раз повторять транзакцию при конфликте сериализации
+	maxTxAttempts = 5
+)
+
 // insert into events(uuid, title,start_at,duration,user_name,notify_at,notified,descr) VALUES ('1','tit','2020-01-01',2131312,'Kira','2020-01-02',false,'some description');
 type event struct {
-	UUID        string
-	Title       string
-	StartAt     time.Time `db:"start_at"`
-	Duration    time.Duration
-	Description string    `db:"descr"`
-	User        string    `db:"user_name"`
-	NotifyAt    time.Time `db:"notify_at"`
+	UUID         string
+	Title        string
+	StartAt      time.Time `db:"start_at"`
+	Duration     time.Duration
+	Description  string     `db:"descr"`
+	User         string     `db:"user_name"`
+	NotifyAt     time.Time  `db:"notify_at"`
+	NotifyBefore int64      `db:"notify_before"`
+	RRule        string     `db:"rrule"`
+	SeriesUUID   *string    `db:"series_uuid"`
+	RecurrenceAt *time.Time `db:"recurrence_at"`
+	AllDay       bool       `db:"all_day"`
+	Transparency int        `db:"transparency"`
+	Status       int        `db:"status"`
+	ExternalUID  string     `db:"external_uid"`
 }
 
+// eventColumns колонки таблицы events. Колонки timestamp хранятся без пояса, а pgx записывает в них
+// местное время как есть, поэтому все моменты времени передаются в базу в UTC
+const eventColumns = "uuid, title, start_at, duration, descr, user_name, notify_at, rrule, series_uuid, recurrence_at, all_day, transparency, status, external_uid, notify_before"
+
+// txKey ключ контекста, в котором InTransaction передает транзакцию
+type txKey struct{}
+
 // StoragePg ...
 type StoragePg struct {
-	db *sqlx.DB
+	db  *sqlx.DB
+	dsn string
 }
 
 // NewStoragePg ...
 func NewStoragePg(user, password, host string, port int, name string) (*StoragePg, error) {
-	db, err := sqlx.Connect("pgx", fmt.Sprintf(
+	dsn := fmt.Sprintf(
 		"postgresql://%s:%s@%s:%d/%s?sslmode=disable",
 		user,
 		password,
 		host,
 		port,
 		name,
-	))
+	)
+	return NewStoragePgDSN(dsn)
+}
+
+// NewStoragePgDSN подключается к базе по готовой строке подключения
+func NewStoragePgDSN(dsn string) (*StoragePg, error) {
+	db, err := sqlx.Connect("pgx", dsn)
 	if err != nil {
 		return nil, err
 	}
 
 	return &StoragePg{
-		db: db,
+		db:  db,
+		dsn: dsn,
 	}, nil
 }
 
+// listEventsQuery выбирает разовые события на время, пересекающиеся с окном.
+// Пересечение ищется по gist-индексу busy_range из 14_add_events_window_indexes, поэтому выборка не проходит
+// историю до окна. У событий нулевой длины busy_range пуст, они находятся по началу через индекс постраничной выдачи
+const listEventsQuery = `SELECT ` + eventColumns + `
+	FROM events
+	WHERE user_name=$1 AND rrule='' AND NOT all_day
+		AND (busy_range && tsrange($2, $3) OR start_at>=$2 AND start_at<$3)`
+
 // ListEvents ...
 func (pg *StoragePg) ListEvents(ctx context.Context, user string, from time.Time, to time.Time) ([]*models.Event, error) {
-	rows, err := pg.db.QueryxContext(ctx, `SELECT uuid, title, start_at, duration, descr, user_name, notify_at
+	events, err := pg.queryEvents(ctx, listEventsQuery, user, from.UTC(), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	floating, err := pg.ListSeriesAndAllDay(ctx, user, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(events, floating...), nil
+}
+
+// ListEventsPage ...
+func (pg *StoragePg) ListEventsPage(ctx context.Context, query *models.PageQuery) ([]*models.Event, error) {
+	// uuid сравнивается побайтово, как PageKey.Less, и так же, как в индексе для этой выборки
+	order, cmp := "ASC", ">"
+	if query.Desc {
+		order, cmp = "DESC", "<"
+	}
+
+	args := []interface{}{query.User, query.From.UTC(), query.To.UTC(), query.Limit}
+	after := ""
+	if !query.After.IsZero() {
+		after = ` AND (start_at, uuid COLLATE "C") ` + cmp + ` ($5, $6)`
+		args = append(args, query.After.StartAt.UTC(), query.After.UUID)
+	}
+
+	return pg.queryEvents(ctx, `SELECT `+eventColumns+`
+	FROM events
+	WHERE user_name=$1 AND rrule='' AND NOT all_day
+		AND start_at<$3 AND (end_at>$2 OR start_at=$2)`+after+`
+	ORDER BY start_at `+order+`, uuid COLLATE "C" `+order+`
+	LIMIT $4`, args...)
+}
+
+// listSeriesAndAllDayQuery выбирает серии и события на целый день, пересекающиеся с окном.
+// Серии ищутся по gist-индексу из 15_add_events_series_end, закончившиеся до окна не читаются
+const listSeriesAndAllDayQuery = `SELECT ` + eventColumns + `
+	FROM events
+	WHERE user_name=$1 AND rrule<>'' AND tsrange(start_at, series_end) && tsrange($3, $4)
+		AND (start_at<$2 OR all_day)
+	UNION ALL
+	SELECT ` + eventColumns + `
 	FROM events
-	WHERE user_name=$1 AND $2<start_at AND start_at<$3`, user, from, to)
+	WHERE user_name=$1 AND rrule='' AND all_day AND busy_range && tsrange($3, $4)`
+
+// ListSeriesAndAllDay ...
+func (pg *StoragePg) ListSeriesAndAllDay(ctx context.Context, user string, from, to time.Time) ([]*models.Event, error) {
+	// события на целый день хранятся датами в UTC и попадают в местные сутки пользователя,
+	// поэтому окно для них шире на models.FloatingSlack. Серии разворачиваются по местному времени,
+	// и экземпляр может сдвинуться при переводе часов, поэтому закончившиеся отсеиваются с тем же запасом
+	return pg.queryEvents(ctx, listSeriesAndAllDayQuery,
+		user, to.UTC(), from.Add(-models.FloatingSlack).UTC(), to.Add(models.FloatingSlack).UTC())
+}
+
+// queryEvents выполнит выборку событий и дополнит серии исключениями
+func (pg *StoragePg) queryEvents(ctx context.Context, query string, args ...interface{}) ([]*models.Event, error) {
+	rows, err := pg.conn(ctx).QueryxContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	var events []*models.Event
 	for rows.Next() {
@@ -64,46 +170,167 @@ func (pg *StoragePg) ListEvents(ctx context.Context, user string, from time.Time
 
 		events = append(events, toEventModel(&e))
 	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	// в транзакции следующий запрос можно выполнить только после закрытия выборки
+	rows.Close()
+
+	err = loadExDates(ctx, pg.conn(ctx), events)
+	if err != nil {
+		return nil, err
+	}
 
 	return events, nil
 }
 
-// CreateEvent ...
-func (pg *StoragePg) CreateEvent(ctx context.Context, event *models.Event) (string, error) {
-	uuid, err := uuid.NewUUID()
+// GetEvent ...
+func (pg *StoragePg) GetEvent(ctx context.Context, uuid string) (*models.Event, error) {
+	return getEvent(ctx, pg.conn(ctx), uuid)
+}
+
+func getEvent(ctx context.Context, db sqlx.QueryerContext, uuid string) (*models.Event, error) {
+	var e event
+	err := db.QueryRowxContext(ctx, `SELECT `+eventColumns+`
+	FROM events
+	WHERE uuid=$1`, uuid).StructScan(&e)
+	if err == sql.ErrNoRows {
+		return nil, app.ErrNotFound
+	}
 	if err != nil {
-		return "", nil
+		return nil, err
 	}
 
-	_, err = pg.db.ExecContext(ctx, `INSERT INTO events 
-	VALUES ($1, $2, $3, $4, $5, $6, $7)`, uuid.String(), event.Title, event.StartAt, event.Duration, event.Description, event.User, event.StartAt.Add(-event.NotifyBefore))
+	result := toEventModel(&e)
+	err = loadExDates(ctx, db, []*models.Event{result})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ListOverrides ...
+func (pg *StoragePg) ListOverrides(ctx context.Context, seriesID string) ([]*models.Event, error) {
+	return pg.queryEvents(ctx, `SELECT `+eventColumns+`
+	FROM events
+	WHERE series_uuid=$1`, seriesID)
+}
+
+// GetEventByExternalUID ...
+func (pg *StoragePg) GetEventByExternalUID(ctx context.Context, user, uid string) (*models.Event, error) {
+	var e event
+	err := pg.conn(ctx).QueryRowxContext(ctx, `SELECT `+eventColumns+`
+	FROM events
+	WHERE user_name=$1 AND external_uid=$2`, user, uid).StructScan(&e)
+	if err == sql.ErrNoRows {
+		return nil, app.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := toEventModel(&e)
+	err = loadExDates(ctx, pg.conn(ctx), []*models.Event{result})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CreateEvent ...
+func (pg *StoragePg) CreateEvent(ctx context.Context, event *models.Event) (string, error) {
+	var id string
+	err := pg.withTx(ctx, func(tx sqlx.ExtContext) error {
+		var err error
+		id, err = insertEvent(ctx, tx, event)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
 
-	return uuid.String(), nil
+	return id, nil
 }
 
 // UpdateEvent ...
 func (pg *StoragePg) UpdateEvent(ctx context.Context, uuid string, event *models.Event) error {
-	_, err := pg.db.ExecContext(ctx, `UPDATE events 
+	return pg.withTx(ctx, func(tx sqlx.ExtContext) error {
+		return updateEvent(ctx, tx, uuid, event)
+	})
+}
+
+func updateEvent(ctx context.Context, db sqlx.ExtContext, uuid string, event *models.Event) error {
+	notifyAt, err := notifyTime(ctx, db, event)
+	if err != nil {
+		return err
+	}
+	end, err := seriesEnd(event)
+	if err != nil {
+		return err
+	}
+	var rescheduled bool
+	err = db.QueryRowxContext(ctx, `SELECT notify_at IS DISTINCT FROM $2 FROM events WHERE uuid=$1`, uuid, notifyAt).Scan(&rescheduled)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `UPDATE events 
 	SET title=$1, 
 	start_at=$2, 
 	duration=$3, 
 	descr=$4, 
 	user_name=$5, 
-	notify_at=$6 
-	WHERE uuid=$7`, event.Title, event.StartAt, event.Duration, event.Description, event.User, event.StartAt.Add(-event.NotifyBefore), uuid)
+	notify_at=$6, 
+	rrule=$7, 
+	all_day=$8, 
+	transparency=$9, 
+	status=$10, 
+	notify_before=$11, 
+	series_end=$12 
+	WHERE uuid=$13`, event.Title, event.StartAt.UTC(), event.Duration, event.Description, event.User, notifyAt, event.RRule, event.AllDay, int(event.Transparency), int(event.Status), int64(event.NotifyBefore), end, uuid)
+	if err != nil {
+		return mapError(err)
+	}
+
+	if event.RRule != "" {
+		return rescheduleSeries(ctx, db, uuid)
+	}
+
+	updated := *event
+	updated.UUID = uuid
+	payload, err := json.Marshal(&updated)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if !rescheduled {
+		// время напоминания прежнее: неотправленное напоминание получит новые данные события,
+		// а уже отправленное не повторится
+		_, err = db.ExecContext(ctx, `UPDATE notification_outbox SET payload=$2 WHERE event_uuid=$1`, uuid, string(payload))
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `DELETE FROM notification_outbox WHERE event_uuid=$1`, uuid)
+	if err != nil {
+		return err
+	}
+
+	// о прошедшем времени напоминать поздно, иначе правка прошлого события разослала бы его заново
+	if !notifyAt.After(time.Now()) {
+		return nil
+	}
+	return enqueueNotification(ctx, db, &updated, notifyAt)
 }
 
 // DeleteEvent ...
 func (pg *StoragePg) DeleteEvent(ctx context.Context, uuid string) error {
-	_, err := pg.db.ExecContext(ctx, `DELETE FROM events 
+	// исключения и перенесенные экземпляры серии удалятся каскадно
+	_, err := pg.conn(ctx).ExecContext(ctx, `DELETE FROM events 
 	WHERE uuid=$1`, uuid)
 	if err != nil {
 		return err
@@ -112,51 +339,538 @@ func (pg *StoragePg) DeleteEvent(ctx context.Context, uuid string) error {
 	return nil
 }
 
-func (pg *StoragePg) PopNotifications(ctx context.Context) ([]*models.Event, error) {
-	tx, err := pg.db.BeginTxx(ctx, nil)
+// CancelOccurrence ...
+func (pg *StoragePg) CancelOccurrence(ctx context.Context, uuid string, occurrence time.Time) error {
+	return pg.withTx(ctx, func(tx sqlx.ExtContext) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO event_exdates (event_uuid, occurrence_at)
+		VALUES ($1, $2) ON CONFLICT DO NOTHING`, uuid, occurrence.UTC())
+		if err != nil {
+			return err
+		}
+
+		return rescheduleSeries(ctx, tx, uuid)
+	})
+}
+
+// OverrideOccurrence ...
+func (pg *StoragePg) OverrideOccurrence(ctx context.Context, uuid string, occurrence time.Time, override *models.Event) (string, error) {
+	var id string
+	err := pg.withTx(ctx, func(tx sqlx.ExtContext) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO event_exdates (event_uuid, occurrence_at)
+		VALUES ($1, $2) ON CONFLICT DO NOTHING`, uuid, occurrence.UTC())
+		if err != nil {
+			return err
+		}
+		err = rescheduleSeries(ctx, tx, uuid)
+		if err != nil {
+			return err
+		}
+
+		id, err = insertEvent(ctx, tx, override)
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	rows, err := tx.QueryxContext(ctx, `SELECT uuid, title, start_at, duration, descr, user_name 
-	FROM events
-	WHERE notified != true AND notify_at<now()`)
+	return id, nil
+}
+
+// SplitSeries ...
+func (pg *StoragePg) SplitSeries(ctx context.Context, uuid string, at time.Time, rrule string, tail *models.Event) (string, error) {
+	var id string
+	err := pg.withTx(ctx, func(tx sqlx.ExtContext) error {
+		// обрезанная серия заканчивается не позже экземпляра, начавшегося бы в at
+		_, err := tx.ExecContext(ctx, `UPDATE events
+		SET rrule=$1, series_end=LEAST(series_end, $3::timestamp + (duration / 1000) * interval '1 microsecond')
+		WHERE uuid=$2`, rrule, uuid, at.UTC())
+		if err != nil {
+			return err
+		}
+
+		// исключения после точки разделения больше не относятся к серии: они переходят к продолжению,
+		// а если его нет, удаляются
+		queries := []string{
+			`DELETE FROM event_exdates WHERE event_uuid=$1 AND occurrence_at>=$2`,
+			`DELETE FROM events WHERE series_uuid=$1 AND recurrence_at>=$2`,
+		}
+		args := []interface{}{uuid, at.UTC()}
+		if tail != nil {
+			id, err = insertEvent(ctx, tx, tail)
+			if err != nil {
+				return err
+			}
+			queries = []string{
+				`UPDATE event_exdates SET event_uuid=$3 WHERE event_uuid=$1 AND occurrence_at>=$2`,
+				`UPDATE events SET series_uuid=$3 WHERE series_uuid=$1 AND recurrence_at>=$2`,
+			}
+			args = append(args, id)
+		}
+
+		for _, query := range queries {
+			_, err = tx.ExecContext(ctx, query, args...)
+			if err != nil {
+				return err
+			}
+		}
+
+		// напоминание могло остаться об экземпляре после at, а продолжение получило исключения
+		err = rescheduleSeries(ctx, tx, uuid)
+		if err != nil || tail == nil {
+			return err
+		}
+		return rescheduleSeries(ctx, tx, id)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// InTransaction выполнит fn в сериализуемой транзакции и повторит ее при конфликте
+// с параллельной транзакцией. Так проверка свободного времени и запись события атомарны
+// даже при нескольких инстансах календаря
+func (pg *StoragePg) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		err = pg.serializable(ctx, fn)
+		if !isRetryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (pg *StoragePg) serializable(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := pg.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return err
+	}
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
 	if err != nil {
 		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUser ...
+func (pg *StoragePg) GetUser(ctx context.Context, name string) (*models.User, error) {
+	var timeZone, channels string
+	var weekStart int
+	user := &models.User{Name: name}
+	err := pg.conn(ctx).QueryRowxContext(ctx, `SELECT time_zone, week_start, all_day_busy, email, webhook_url, channels, locale
+	FROM users
+	WHERE name=$1`, name).Scan(&timeZone, &weekStart, &user.AllDayBusy, &user.Email, &user.WebhookURL, &channels, &user.Locale)
+	if err == sql.ErrNoRows {
+		return nil, app.ErrNotFound
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	var events []*models.Event
+	user.Location, err = time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, err
+	}
+	user.WeekStart = time.Weekday(weekStart)
+	if channels != "" {
+		for _, channel := range strings.Split(channels, ",") {
+			user.Channels = append(user.Channels, models.Channel(channel))
+		}
+	}
+
+	return user, nil
+}
+
+// SaveUser ...
+func (pg *StoragePg) SaveUser(ctx context.Context, user *models.User) error {
+	channels := make([]string, 0, len(user.Channels))
+	for _, channel := range user.Channels {
+		channels = append(channels, string(channel))
+	}
+
+	return pg.withTx(ctx, func(tx sqlx.ExtContext) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO users (name, time_zone, week_start, all_day_busy, email, webhook_url, channels, locale)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (name) DO UPDATE SET time_zone=EXCLUDED.time_zone, week_start=EXCLUDED.week_start, all_day_busy=EXCLUDED.all_day_busy,
+			email=EXCLUDED.email, webhook_url=EXCLUDED.webhook_url, channels=EXCLUDED.channels, locale=EXCLUDED.locale`,
+			user.Name, user.Location.String(), int(user.WeekStart), user.AllDayBusy, user.Email, user.WebhookURL, strings.Join(channels, ","), user.Locale)
+		if err != nil {
+			return err
+		}
+
+		// с часовым поясом сдвигаются местные полночи, от которых отсчитываются напоминания о событиях на целый день
+		_, err = tx.ExecContext(ctx, `UPDATE events
+		SET notify_at = (start_at AT TIME ZONE $2 AT TIME ZONE 'UTC') - notify_before / 1000 * interval '1 microsecond'
+		WHERE user_name=$1 AND all_day`, user.Name, user.Location.String())
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE notification_outbox o SET deliver_at = e.notify_at
+		FROM events e
+		WHERE o.event_uuid = e.uuid AND e.user_name=$1 AND e.all_day AND e.rrule=''`, user.Name)
+		if err != nil {
+			return err
+		}
+
+		// серии разворачиваются в часовом поясе пользователя, поэтому ближайший экземпляр ищется заново
+		var series []string
+		err = sqlx.SelectContext(ctx, tx, &series, `SELECT uuid FROM events WHERE user_name=$1 AND rrule<>''`, user.Name)
+		if err != nil {
+			return err
+		}
+		for _, uuid := range series {
+			err = rescheduleSeries(ctx, tx, uuid)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LeaseNotifications ...
+func (pg *StoragePg) LeaseNotifications(ctx context.Context, limit int, lease time.Duration) ([]*models.OutboxMessage, error) {
+	now := time.Now().UTC()
+	// SKIP LOCKED не дает двум планировщикам взять одни и те же напоминания
+	rows, err := pg.db.QueryxContext(ctx, `UPDATE notification_outbox
+	SET leased_until=$2, attempts=attempts+1
+	WHERE id IN (
+		SELECT id FROM notification_outbox
+		WHERE deliver_at<=$1 AND (leased_until IS NULL OR leased_until<=$1)
+		ORDER BY deliver_at, id
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED)
+	RETURNING id, event_uuid, payload`, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.OutboxMessage
 	for rows.Next() {
-		var e models.Event
-		err = rows.StructScan(&e)
+		var m models.OutboxMessage
+		err = rows.StructScan(&m)
 		if err != nil {
-			fmt.Println("is it? ", err)
-			tx.Rollback()
 			return nil, err
 		}
 
-		events = append(events, &e)
+		messages = append(messages, &m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	for _, e := range events {
-		_, err := tx.ExecContext(ctx, "UPDATE events SET notified=true WHERE uuid=$1", e.UUID)
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+	return messages, nil
+}
+
+// AckNotifications удалит отправленные напоминания. Вместо напоминания об экземпляре серии
+// в outbox записывается напоминание о следующем
+func (pg *StoragePg) AckNotifications(ctx context.Context, ids []int64) error {
+	return pg.withTx(ctx, func(tx sqlx.ExtContext) error {
+		var acked []models.OutboxMessage
+		err := sqlx.SelectContext(ctx, tx, &acked, `DELETE FROM notification_outbox WHERE id = ANY($1)
+		RETURNING id, event_uuid, payload`, ids)
 		if err != nil {
-			tx.Rollback()
-			return nil, err
+			return err
+		}
+
+		for _, m := range acked {
+			// напоминание о серии уже заменено, например после ее правки
+			var pending bool
+			err = tx.QueryRowxContext(ctx, `SELECT EXISTS (SELECT 1 FROM notification_outbox WHERE event_uuid=$1)`, m.EventUUID).Scan(&pending)
+			if err != nil {
+				return err
+			}
+			if pending {
+				continue
+			}
+
+			series, err := getEvent(ctx, tx, m.EventUUID)
+			if err == app.ErrNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if series.RRule == "" {
+				continue
+			}
+
+			var delivered models.Event
+			err = json.Unmarshal(m.Payload, &delivered)
+			if err != nil {
+				return err
+			}
+			after := time.Now()
+			if delivered.StartAt.After(after) {
+				after = delivered.StartAt
+			}
+			err = enqueueNextOccurrence(ctx, tx, series, after, time.Time{})
+			if err != nil {
+				return err
+			}
 		}
+		return nil
+	})
+}
+
+// conn вернет транзакцию из контекста или пул соединений
+func (pg *StoragePg) conn(ctx context.Context) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return pg.db
+}
+
+// withTx выполнит fn в транзакции из контекста или в новой, если ее нет
+func (pg *StoragePg) withTx(ctx context.Context, fn func(tx sqlx.ExtContext) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(tx)
+	}
+
+	tx, err := pg.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
 	}
 
-	err = tx.Commit()
+	err = fn(tx)
 	if err != nil {
 		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// mapError переведет нарушение ограничения events_no_overlap в app.ErrTimeBusy
+func mapError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == exclusionViolation {
+		return app.ErrTimeBusy
+	}
+	return err
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
+
+func insertEvent(ctx context.Context, db sqlx.ExtContext, event *models.Event) (string, error) {
+	uuid, err := uuid.NewUUID()
+	if err != nil {
+		return "", err
+	}
+
+	notifyAt, err := notifyTime(ctx, db, event)
+	if err != nil {
+		return "", err
+	}
+
+	end, err := seriesEnd(event)
+	if err != nil {
+		return "", err
+	}
+
+	var seriesUUID *string
+	var recurrenceAt *time.Time
+	if event.SeriesUUID != "" {
+		utc := event.RecurrenceAt.UTC()
+		seriesUUID, recurrenceAt = &event.SeriesUUID, &utc
+	}
+
+	_, err = db.ExecContext(ctx, `INSERT INTO events (`+eventColumns+`, series_end)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`, uuid.String(), event.Title, event.StartAt.UTC(), event.Duration, event.Description, event.User, notifyAt, event.RRule, seriesUUID, recurrenceAt, event.AllDay, int(event.Transparency), int(event.Status), event.ExternalUID, int64(event.NotifyBefore), end)
+	if err != nil {
+		return "", mapError(err)
+	}
+
+	created := *event
+	created.UUID = uuid.String()
+	created.ExDates = nil
+	if event.RRule != "" {
+		err = enqueueNextOccurrence(ctx, db, &created, time.Now(), time.Time{})
+		if err != nil {
+			return "", err
+		}
+		return uuid.String(), nil
+	}
+
+	// о начавшемся событии не напоминают, например при импорте прошлых событий
+	if !notifyAt.Add(event.NotifyBefore).After(time.Now()) {
+		return uuid.String(), nil
+	}
+
+	err = enqueueNotification(ctx, db, &created, notifyAt)
+	if err != nil {
+		return "", err
+	}
+
+	return uuid.String(), nil
+}
+
+// seriesEnd вернет конец последнего экземпляра серии в UTC, nil у разового события и бесконечной серии
+func seriesEnd(event *models.Event) (*time.Time, error) {
+	if event.RRule == "" {
+		return nil, nil
+	}
+	rule, err := models.ParseRRule(event.RRule)
+	if err != nil {
 		return nil, err
 	}
 
-	return events, nil
+	end := rule.End(event.StartAt, event.Duration)
+	if end.IsZero() {
+		return nil, nil
+	}
+	// первый экземпляр есть всегда, даже если UNTIL раньше него
+	if start := event.StartAt.Add(event.Duration); end.Before(start) {
+		end = start
+	}
+	end = end.UTC()
+	return &end, nil
+}
+
+// enqueueNotification запишет напоминание о событии в outbox. Вызывается в транзакции записи события,
+// поэтому напоминание не теряется и не появляется без события. При удалении события оно удалится каскадно
+func enqueueNotification(ctx context.Context, db sqlx.ExecerContext, event *models.Event, deliverAt time.Time) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `INSERT INTO notification_outbox (event_uuid, payload, deliver_at)
+	VALUES ($1, $2, $3)`, event.UUID, string(payload), deliverAt)
+	return err
+}
+
+// notifyTime вернет время напоминания о событии в UTC. Напоминание о событии на целый день
+// отсчитывается от местной полуночи пользователя, без профиля - от полуночи UTC
+func notifyTime(ctx context.Context, db sqlx.QueryerContext, event *models.Event) (time.Time, error) {
+	loc := time.UTC
+	if event.AllDay {
+		var err error
+		loc, err = userLocation(ctx, db, event.User)
+		if err != nil {
+			return time.Time{}, err
+		}
+	}
+	return event.NotifyAt(loc).UTC(), nil
+}
+
+// userLocation вернет часовой пояс пользователя, без профиля - UTC
+func userLocation(ctx context.Context, db sqlx.QueryerContext, user string) (*time.Location, error) {
+	var timeZone string
+	err := db.QueryRowxContext(ctx, `SELECT time_zone FROM users WHERE name=$1`, user).Scan(&timeZone)
+	if err == sql.ErrNoRows {
+		return time.UTC, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return time.LoadLocation(timeZone)
+}
+
+// enqueueNextOccurrence запишет в outbox напоминание о ближайшем экземпляре серии, начинающемся после after,
+// напоминание о котором не раньше notBefore. В outbox у серии одно напоминание, следующее
+// записывается при подтверждении отправки
+func enqueueNextOccurrence(ctx context.Context, db sqlx.ExtContext, series *models.Event, after, notBefore time.Time) error {
+	loc, err := userLocation(ctx, db, series.User)
+	if err != nil {
+		return err
+	}
+	occurrence, err := series.NextReminder(loc, after, notBefore)
+	if err != nil || occurrence == nil {
+		return err
+	}
+	return enqueueNotification(ctx, db, occurrence, occurrence.NotifyAt(loc).UTC())
+}
+
+// rescheduleSeries заменит напоминание об экземпляре серии после ее изменения. Как для разового события,
+// о прошедшем времени напоминать поздно, но просроченное неотправленное напоминание остается в силе
+func rescheduleSeries(ctx context.Context, db sqlx.ExtContext, uuid string) error {
+	series, err := getEvent(ctx, db, uuid)
+	if err == app.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if series.RRule == "" {
+		return nil
+	}
+
+	now := time.Now()
+	var overdue sql.NullTime
+	err = db.QueryRowxContext(ctx, `WITH pending AS (DELETE FROM notification_outbox WHERE event_uuid=$1 RETURNING deliver_at)
+	SELECT min(deliver_at) FROM pending`, uuid).Scan(&overdue)
+	if err != nil {
+		return err
+	}
+	notBefore := now
+	if overdue.Valid && overdue.Time.Before(now) {
+		notBefore = overdue.Time
+	}
+	return enqueueNextOccurrence(ctx, db, series, now, notBefore)
+}
+
+// loadExDates дополнит повторяющиеся события их отмененными экземплярами
+func loadExDates(ctx context.Context, db sqlx.QueryerContext, events []*models.Event) error {
+	series := make(map[string]*models.Event)
+	ids := make([]string, 0)
+	for _, e := range events {
+		if e.RRule != "" {
+			series[e.UUID] = e
+			ids = append(ids, e.UUID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := db.QueryxContext(ctx, `SELECT event_uuid, occurrence_at
+	FROM event_exdates
+	WHERE event_uuid = ANY($1)`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var occurrence time.Time
+		err = rows.Scan(&id, &occurrence)
+		if err != nil {
+			return err
+		}
+
+		series[id].ExDates = append(series[id].ExDates, occurrence)
+	}
+
+	return rows.Err()
 }
 
 func toEventModel(e *event) *models.Event {
+	var seriesUUID string
+	var recurrenceAt time.Time
+	if e.SeriesUUID != nil && e.RecurrenceAt != nil {
+		seriesUUID, recurrenceAt = *e.SeriesUUID, *e.RecurrenceAt
+	}
+
 	return &models.Event{
 		UUID:         e.UUID,
 		Title:        e.Title,
@@ -164,6 +878,13 @@ func toEventModel(e *event) *models.Event {
 		Duration:     e.Duration,
 		Description:  e.Description,
 		User:         e.User,
-		NotifyBefore: e.StartAt.Sub(e.NotifyAt),
+		NotifyBefore: time.Duration(e.NotifyBefore),
+		RRule:        e.RRule,
+		SeriesUUID:   seriesUUID,
+		RecurrenceAt: recurrenceAt,
+		AllDay:       e.AllDay,
+		Transparency: models.Transparency(e.Transparency),
+		Status:       models.Status(e.Status),
+		ExternalUID:  e.ExternalUID,
 	}
 }
@@ -0,0 +1,126 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/models"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDSNEnv переменная окружения со строкой подключения к базе с примененными миграциями.
+// Без нее тесты StoragePg пропускаются
+const testDSNEnv = "CALENDAR_TEST_PG"
+
+func newTestStorage(t testing.TB) *StoragePg {
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	pg, err := NewStoragePgDSN(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pg
+}
+
+func TestStoragePg_ListEventsUsesWindowIndex(t *testing.T) {
+	pg := newTestStorage(t)
+	defer pg.db.Close()
+
+	ctx := context.Background()
+	user := fmt.Sprintf("explain-%d", time.Now().UnixNano())
+	defer pg.db.ExecContext(ctx, `DELETE FROM events WHERE user_name=$1`, user)
+
+	// история до окна должна быть длиннее, чем выгодно читать целиком
+	start := time.Date(2010, time.January, 1, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 5000; i++ {
+		_, err := pg.CreateEvent(ctx, &models.Event{
+			Title:    "past meeting",
+			StartAt:  start.Add(time.Duration(i) * 2 * time.Hour),
+			Duration: time.Hour,
+			User:     user,
+		})
+		assert.NoError(t, err)
+	}
+	_, err := pg.db.ExecContext(ctx, `ANALYZE events`)
+	assert.NoError(t, err)
+
+	from := start.Add(4000 * 2 * time.Hour)
+	var plan []string
+	err = pg.db.SelectContext(ctx, &plan, `EXPLAIN `+listEventsQuery, user, from, from.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Contains(t, strings.Join(plan, "\n"), "events_timed_busy_range_idx")
+}
+
+func TestStoragePg_ListSeriesSkipsFinishedSeries(t *testing.T) {
+	pg := newTestStorage(t)
+	defer pg.db.Close()
+
+	ctx := context.Background()
+	user := fmt.Sprintf("series-%d", time.Now().UnixNano())
+	defer pg.db.ExecContext(ctx, `DELETE FROM events WHERE user_name=$1`, user)
+
+	start := time.Date(2010, time.January, 4, 9, 0, 0, 0, time.UTC)
+	series := map[string]string{
+		"count":  "FREQ=DAILY;COUNT=10",
+		"until":  "FREQ=WEEKLY;UNTIL=20110101T000000Z",
+		"split":  "FREQ=DAILY",
+		"weekly": "FREQ=WEEKLY",
+	}
+	ids := make(map[string]string)
+	for title, rrule := range series {
+		id, err := pg.CreateEvent(ctx, &models.Event{Title: title, StartAt: start, Duration: time.Hour, User: user, RRule: rrule})
+		assert.NoError(t, err)
+		ids[title] = id
+	}
+	_, err := pg.SplitSeries(ctx, ids["split"], start.AddDate(0, 0, 3), "FREQ=DAILY;COUNT=3", nil)
+	assert.NoError(t, err)
+
+	from := time.Date(2020, time.March, 2, 0, 0, 0, 0, time.UTC)
+	events, err := pg.ListSeriesAndAllDay(ctx, user, from, from.AddDate(0, 0, 7))
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, "weekly", events[0].Title)
+
+	events, err = pg.ListSeriesAndAllDay(ctx, user, start, start.AddDate(0, 0, 1))
+	assert.NoError(t, err)
+	assert.Len(t, events, 4)
+}
+
+func TestStoragePg_SeriesRemindsEveryOccurrence(t *testing.T) {
+	pg := newTestStorage(t)
+	defer pg.db.Close()
+
+	ctx := context.Background()
+	user := fmt.Sprintf("reminders-%d", time.Now().UnixNano())
+	defer pg.db.ExecContext(ctx, `DELETE FROM events WHERE user_name=$1`, user)
+
+	start := time.Now().Add(time.Minute).UTC().Truncate(time.Microsecond)
+	id, err := pg.CreateEvent(ctx, &models.Event{Title: "standup", StartAt: start, Duration: 15 * time.Minute, NotifyBefore: 5 * time.Minute, RRule: "FREQ=DAILY", User: user})
+	assert.NoError(t, err)
+
+	pending := func() (outboxID int64, deliverAt time.Time) {
+		err := pg.db.QueryRowxContext(ctx, `SELECT id, deliver_at FROM notification_outbox WHERE event_uuid=$1`, id).Scan(&outboxID, &deliverAt)
+		assert.NoError(t, err)
+		return outboxID, deliverAt
+	}
+
+	outboxID, deliverAt := pending()
+	assert.True(t, start.Add(-5*time.Minute).Equal(deliverAt))
+
+	// после отправки напоминания о первом дне ждет напоминание о втором
+	assert.NoError(t, pg.AckNotifications(ctx, []int64{outboxID}))
+	_, deliverAt = pending()
+	assert.True(t, start.AddDate(0, 0, 1).Add(-5*time.Minute).Equal(deliverAt))
+
+	// отмененный экземпляр пропускается
+	assert.NoError(t, pg.CancelOccurrence(ctx, id, start.AddDate(0, 0, 1)))
+	_, deliverAt = pending()
+	assert.True(t, start.AddDate(0, 0, 2).Add(-5*time.Minute).Equal(deliverAt))
+}
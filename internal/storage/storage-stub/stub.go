@@ -3,6 +3,7 @@ package stub
 import (
 	"time"
 
+	app "github.com/bobrovka/calendar/internal/calendar-app"
 	"github.com/bobrovka/calendar/internal/models"
 	"golang.org/x/net/context"
 )
@@ -10,13 +11,13 @@ import (
 // StorageStub is dummy storage
 type StorageStub struct{}
 
-// ListEvents stub for method
-func (s *StorageStub) ListEvents(_ context.Context, _ string, _, _ time.Time) ([]*models.Event, error) {
+// ListEvents stub for method, returns one event overlapping the window
+func (s *StorageStub) ListEvents(_ context.Context, _ string, from, _ time.Time) ([]*models.Event, error) {
 	return []*models.Event{
 		&models.Event{
 			UUID:         "1",
 			Title:        "title-1",
-			StartAt:      time.Now(),
+			StartAt:      from,
 			Duration:     2 * time.Hour,
 			Description:  "awesome meeting",
 			User:         "Kira",
@@ -25,6 +26,42 @@ func (s *StorageStub) ListEvents(_ context.Context, _ string, _, _ time.Time) ([
 	}, nil
 }
 
+// ListEventsPage stub for method, returns the event of ListEvents on the first page
+func (s *StorageStub) ListEventsPage(ctx context.Context, query *models.PageQuery) ([]*models.Event, error) {
+	if !query.After.IsZero() {
+		return nil, nil
+	}
+	return s.ListEvents(ctx, query.User, query.From, query.To)
+}
+
+// ListSeriesAndAllDay stub for method, the stub has no series
+func (s *StorageStub) ListSeriesAndAllDay(_ context.Context, _ string, _, _ time.Time) ([]*models.Event, error) {
+	return nil, nil
+}
+
+// GetEvent stub for method
+func (s *StorageStub) GetEvent(_ context.Context, id string) (*models.Event, error) {
+	return &models.Event{
+		UUID:         id,
+		Title:        "title-1",
+		StartAt:      time.Now(),
+		Duration:     2 * time.Hour,
+		Description:  "awesome meeting",
+		User:         "Kira",
+		NotifyBefore: 3 * time.Hour,
+	}, nil
+}
+
+// ListOverrides stub for method, the stub has no series
+func (s *StorageStub) ListOverrides(_ context.Context, _ string) ([]*models.Event, error) {
+	return nil, nil
+}
+
+// GetEventByExternalUID stub for method, nothing is imported into the stub
+func (s *StorageStub) GetEventByExternalUID(_ context.Context, _, _ string) (*models.Event, error) {
+	return nil, app.ErrNotFound
+}
+
 // CreateEvent stub for method
 func (s *StorageStub) CreateEvent(_ context.Context, _ *models.Event) (string, error) {
 	return "1", nil
@@ -39,3 +76,33 @@ func (s *StorageStub) UpdateEvent(_ context.Context, _ string, _ *models.Event)
 func (s *StorageStub) DeleteEvent(_ context.Context, _ string) error {
 	return nil
 }
+
+// CancelOccurrence stub for method
+func (s *StorageStub) CancelOccurrence(_ context.Context, _ string, _ time.Time) error {
+	return nil
+}
+
+// OverrideOccurrence stub for method
+func (s *StorageStub) OverrideOccurrence(_ context.Context, _ string, _ time.Time, _ *models.Event) (string, error) {
+	return "2", nil
+}
+
+// SplitSeries stub for method
+func (s *StorageStub) SplitSeries(_ context.Context, _ string, _ time.Time, _ string, _ *models.Event) (string, error) {
+	return "2", nil
+}
+
+// GetUser stub for method
+func (s *StorageStub) GetUser(_ context.Context, name string) (*models.User, error) {
+	return models.DefaultUser(name), nil
+}
+
+// SaveUser stub for method
+func (s *StorageStub) SaveUser(_ context.Context, _ *models.User) error {
+	return nil
+}
+
+// InTransaction stub for method
+func (s *StorageStub) InTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
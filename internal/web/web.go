@@ -0,0 +1,87 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bobrovka/calendar/internal/caldav"
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/ical"
+	"go.uber.org/zap"
+)
+
+// feedPrefix путь ленты календаря: /calendars/<user>.ics
+const feedPrefix = "/calendars/"
+
+// Handler HTTP-обработчик, отдающий календари пользователей клиентам по подписке и по CalDAV
+type Handler struct {
+	app    app.App
+	logger *zap.SugaredLogger
+	mux    *http.ServeMux
+}
+
+// NewHandler создает обработчик HTTP-запросов
+func NewHandler(app app.App, logger *zap.SugaredLogger) *Handler {
+	h := &Handler{
+		app:    app,
+		logger: logger,
+		mux:    http.NewServeMux(),
+	}
+	h.mux.HandleFunc(feedPrefix, h.feed)
+	h.mux.Handle(caldav.Prefix, caldav.NewHandler(app, logger))
+	return h
+}
+
+// ServeHTTP ...
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// feed отдаст события пользователя в формате iCalendar
+func (h *Handler) feed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, feedPrefix)
+	user := strings.TrimSuffix(name, ".ics")
+	if user == "" || user == name || strings.Contains(user, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	profile, err := h.app.GetProfile(r.Context(), user)
+	if err == app.ErrForbidden {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		h.logger.Errorw("error GetProfile", "methodName", "feed", "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	events, err := h.app.ExportEvents(r.Context(), user)
+	if err != nil {
+		h.logger.Errorw("error ExportEvents", "methodName", "feed", "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ical.ContentType)
+	err = ical.Encode(w, &ical.Calendar{
+		Name:     profile.Name,
+		Location: profile.Location,
+		Stamp:    time.Now(),
+		Events:   events,
+	})
+	if err != nil {
+		h.logger.Errorw("error ical encoding", "methodName", "feed", "err", err)
+		return
+	}
+
+	h.logger.Infow("Success feed", "user", user, "events", len(events))
+}
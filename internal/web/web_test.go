@@ -0,0 +1,86 @@
+package web
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	app "github.com/bobrovka/calendar/internal/calendar-app"
+	"github.com/bobrovka/calendar/internal/ical"
+	"github.com/bobrovka/calendar/internal/models"
+	memory "github.com/bobrovka/calendar/internal/storage/storage-memory"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestHandler_Feed(t *testing.T) {
+	calendar, err := app.NewCalendar(memory.NewStorageMemory(), nil)
+	assert.NoError(t, err)
+
+	tomorrow := time.Now().Truncate(time.Hour).Add(24 * time.Hour)
+	_, err = calendar.CreateNewEvent(context.Background(), &models.Event{
+		Title:    "retro",
+		StartAt:  tomorrow,
+		Duration: time.Hour,
+		User:     "Kira",
+	})
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(NewHandler(calendar, zap.NewNop().Sugar()))
+	defer server.Close()
+
+	type testCase struct {
+		method, path string
+		expStatus    int
+		expBody      string
+	}
+
+	testCases := make(map[string]testCase)
+
+	testCases["Feed of user"] = testCase{
+		method:    http.MethodGet,
+		path:      "/calendars/Kira.ics",
+		expStatus: http.StatusOK,
+		expBody:   "SUMMARY:retro",
+	}
+	testCases["Empty feed"] = testCase{
+		method:    http.MethodGet,
+		path:      "/calendars/Ivan.ics",
+		expStatus: http.StatusOK,
+		expBody:   "BEGIN:VCALENDAR",
+	}
+	testCases["No extension"] = testCase{
+		method:    http.MethodGet,
+		path:      "/calendars/Kira",
+		expStatus: http.StatusNotFound,
+	}
+	testCases["Wrong method"] = testCase{
+		method:    http.MethodPost,
+		path:      "/calendars/Kira.ics",
+		expStatus: http.StatusMethodNotAllowed,
+	}
+
+	for k, v := range testCases {
+		t.Run(k, func(t *testing.T) {
+			request, err := http.NewRequest(v.method, server.URL+v.path, nil)
+			assert.NoError(t, err)
+
+			response, err := http.DefaultClient.Do(request)
+			assert.NoError(t, err)
+			defer response.Body.Close()
+
+			assert.Equal(t, v.expStatus, response.StatusCode)
+			if v.expStatus != http.StatusOK {
+				return
+			}
+
+			body, err := ioutil.ReadAll(response.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, ical.ContentType, response.Header.Get("Content-Type"))
+			assert.Contains(t, string(body), v.expBody)
+		})
+	}
+}
@@ -0,0 +1,2 @@
+-- постраничная выдача разовых событий по ключу (start_at, uuid), uuid сравнивается побайтово
+CREATE INDEX ON events (user_name, start_at, uuid COLLATE "C") WHERE rrule = '' AND NOT all_day;
@@ -0,0 +1,27 @@
+-- напоминания к отправке в очередь: пишутся в одной транзакции с событием
+-- и удаляются только после того, как брокер принял сообщение
+CREATE TABLE IF NOT EXISTS notification_outbox(
+    id           bigserial PRIMARY KEY,
+    event_uuid   text      NOT NULL REFERENCES events (uuid) ON DELETE CASCADE,
+    payload      jsonb     NOT NULL,
+    deliver_at   timestamp NOT NULL,
+    -- до этого момента напоминание отправляет один из планировщиков
+    leased_until timestamp,
+    attempts     int       NOT NULL DEFAULT 0
+);
+
+CREATE INDEX ON notification_outbox (deliver_at);
+CREATE INDEX ON notification_outbox (event_uuid);
+
+-- еще не отправленные напоминания, events.notified больше не используется
+INSERT INTO notification_outbox (event_uuid, payload, deliver_at)
+SELECT uuid, json_build_object(
+        'UUID', uuid,
+        'Title', title,
+        'StartAt', to_char(start_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+        'Duration', duration,
+        'Description', descr,
+        'User', user_name),
+    notify_at
+FROM events
+WHERE notified IS NOT TRUE AND notify_at IS NOT NULL;
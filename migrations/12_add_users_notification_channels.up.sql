@@ -0,0 +1,4 @@
+-- куда пользователю слать напоминания; channels перечисляет каналы через запятую, пусто - только файл рассыльщика
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email text NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS webhook_url text NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS channels text NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- язык напоминаний, пусто - английский
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locale text NOT NULL DEFAULT '';
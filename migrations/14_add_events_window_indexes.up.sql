@@ -0,0 +1,5 @@
+-- выборка окна не проходит историю пользователя до окна: разовые события ищутся по пересечению busy_range,
+-- серии - по началу среди одних серий (индекс из 2_add_rrule).
+-- Индекс ограничения events_no_overlap сюда не подходит: в его условии есть transparency и status
+CREATE INDEX ON events USING gist (user_name, busy_range) WHERE rrule = '' AND all_day;
+CREATE INDEX events_timed_busy_range_idx ON events USING gist (user_name, busy_range) WHERE rrule = '' AND NOT all_day;
@@ -0,0 +1,14 @@
+-- конец последнего экземпляра серии, NULL у бесконечной серии. По нему выборка окна пропускает
+-- серии, закончившиеся до окна, вместо того чтобы разворачивать всю историю повторений
+ALTER TABLE events ADD COLUMN IF NOT EXISTS series_end timestamp;
+
+-- у серий с UNTIL конец известен и без разбора правила, серии с COUNT получат его при следующей записи
+UPDATE events
+SET series_end = GREATEST(start_at,
+    to_timestamp(substring(rrule from 'UNTIL=([0-9]{8}T[0-9]{6})Z'), 'YYYYMMDD"T"HH24MISS')::timestamp
+        + (duration / 1000) * interval '1 microsecond')
+WHERE rrule ~ 'UNTIL=[0-9]{8}T[0-9]{6}Z';
+
+-- серии, пересекающиеся с окном, ищутся по gist-индексу, как разовые события в 14_add_events_window_indexes.
+-- У бесконечной серии верхней границы нет
+CREATE INDEX events_series_range_idx ON events USING gist (user_name, tsrange(start_at, series_end)) WHERE rrule <> '';
@@ -0,0 +1,3 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS rrule text NOT NULL DEFAULT '';
+
+CREATE INDEX ON events (user_name, start_at) WHERE rrule <> '';
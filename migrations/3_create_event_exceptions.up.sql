@@ -0,0 +1,11 @@
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS series_uuid   text REFERENCES events (uuid) ON DELETE CASCADE,
+    ADD COLUMN IF NOT EXISTS recurrence_at timestamp;
+
+CREATE INDEX ON events (series_uuid);
+
+CREATE TABLE IF NOT EXISTS event_exdates(
+    event_uuid    text      NOT NULL REFERENCES events (uuid) ON DELETE CASCADE,
+    occurrence_at timestamp NOT NULL,
+    CONSTRAINT event_exdates_pkey PRIMARY KEY (event_uuid, occurrence_at)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS users(
+    name       text     NOT NULL,
+    time_zone  text     NOT NULL DEFAULT 'UTC',
+    week_start smallint NOT NULL DEFAULT 1, -- 0 воскресенье, 1 понедельник, ...
+    CONSTRAINT users_pkey PRIMARY KEY (name)
+);
@@ -0,0 +1,37 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- интервал занятости события, duration хранится в наносекундах
+ALTER TABLE events ADD COLUMN IF NOT EXISTS busy_range tsrange
+    GENERATED ALWAYS AS (tsrange(start_at, start_at + (duration / 1000) * interval '1 microsecond')) STORED;
+
+-- ограничение не создастся, если пересечения уже есть. Какое из событий перенести или удалить,
+-- решает владелец, поэтому миграция только перечисляет их; порядок действий описан в README
+DO $$
+DECLARE
+    conflicts text;
+BEGIN
+    SELECT string_agg(format('%s %s at %s', user_name, uuid, start_at), ', ')
+    INTO conflicts
+    FROM (
+        SELECT user_name, uuid, start_at
+        FROM (
+            SELECT user_name, uuid, start_at,
+                max(upper(busy_range)) OVER (PARTITION BY user_name ORDER BY start_at, uuid
+                    ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS busy_until
+            FROM events
+            WHERE rrule = '' AND NOT isempty(busy_range)
+        ) ordered
+        WHERE start_at < busy_until
+        LIMIT 20
+    ) overlapping;
+
+    IF conflicts IS NOT NULL THEN
+        RAISE EXCEPTION 'events overlap earlier events of the same user: %', conflicts
+            USING HINT = 'move or delete them, then force version 4 and migrate again';
+    END IF;
+END $$;
+
+-- разовые события одного пользователя не могут пересекаться даже при параллельной записи,
+-- экземпляры серий проверяет приложение в сериализуемой транзакции
+ALTER TABLE events ADD CONSTRAINT events_no_overlap
+    EXCLUDE USING gist (user_name WITH =, busy_range WITH &&) WHERE (rrule = '');
@@ -0,0 +1,5 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS end_at timestamp
+    GENERATED ALWAYS AS (start_at + (duration / 1000) * interval '1 microsecond') STORED;
+
+-- поиск событий, пересекающихся с окном, для проверки свободного времени
+CREATE INDEX ON events (user_name, start_at, end_at);
@@ -0,0 +1,16 @@
+-- событие на целый день хранит дату начала полуночью UTC и длительность в целых сутках
+ALTER TABLE events ADD COLUMN IF NOT EXISTS all_day boolean NOT NULL DEFAULT false;
+
+-- за сколько до начала напоминать, наносекунды. Для событий на целый день notify_at отсчитывается
+-- от местной полуночи пользователя, и из разницы с start_at это время уже не восстановить
+ALTER TABLE events ADD COLUMN IF NOT EXISTS notify_before bigint NOT NULL DEFAULT 0;
+UPDATE events SET notify_before = (extract(epoch FROM start_at - notify_at) * 1000000000)::bigint
+WHERE notify_at IS NOT NULL;
+
+-- занимают ли события на целый день время пользователя
+ALTER TABLE users ADD COLUMN IF NOT EXISTS all_day_busy boolean NOT NULL DEFAULT false;
+
+-- даты событий на целый день зависят от часового пояса пользователя, их пересечения проверяет приложение
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_no_overlap;
+ALTER TABLE events ADD CONSTRAINT events_no_overlap
+    EXCLUDE USING gist (user_name WITH =, busy_range WITH &&) WHERE (rrule = '' AND NOT all_day);
@@ -0,0 +1,10 @@
+-- 0 занимает время, 1 прозрачное
+ALTER TABLE events ADD COLUMN IF NOT EXISTS transparency smallint NOT NULL DEFAULT 0;
+-- 0 подтверждено, 1 под вопросом, 2 отменено
+ALTER TABLE events ADD COLUMN IF NOT EXISTS status smallint NOT NULL DEFAULT 0;
+
+-- прозрачные и отмененные события время не занимают
+ALTER TABLE events DROP CONSTRAINT IF EXISTS events_no_overlap;
+ALTER TABLE events ADD CONSTRAINT events_no_overlap
+    EXCLUDE USING gist (user_name WITH =, busy_range WITH &&)
+    WHERE (rrule = '' AND NOT all_day AND transparency = 0 AND status <> 2);
@@ -0,0 +1,4 @@
+-- UID события из импортированного iCalendar, по нему повторный импорт обновляет событие
+ALTER TABLE events ADD COLUMN IF NOT EXISTS external_uid text NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX ON events (user_name, external_uid) WHERE external_uid <> '';
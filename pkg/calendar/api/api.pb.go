@@ -27,6 +27,59 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+type Transparency int32
+
+const (
+	Transparency_OPAQUE      Transparency = 0
+	Transparency_TRANSPARENT Transparency = 1
+)
+
+var Transparency_name = map[int32]string{
+	0: "OPAQUE",
+	1: "TRANSPARENT",
+}
+
+var Transparency_value = map[string]int32{
+	"OPAQUE":      0,
+	"TRANSPARENT": 1,
+}
+
+func (x Transparency) String() string {
+	return proto.EnumName(Transparency_name, int32(x))
+}
+
+func (Transparency) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{0}
+}
+
+type Status int32
+
+const (
+	Status_CONFIRMED Status = 0
+	Status_TENTATIVE Status = 1
+	Status_CANCELLED Status = 2
+)
+
+var Status_name = map[int32]string{
+	0: "CONFIRMED",
+	1: "TENTATIVE",
+	2: "CANCELLED",
+}
+
+var Status_value = map[string]int32{
+	"CONFIRMED": 0,
+	"TENTATIVE": 1,
+	"CANCELLED": 2,
+}
+
+func (x Status) String() string {
+	return proto.EnumName(Status_name, int32(x))
+}
+
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{1}
+}
+
 type Period int32
 
 const (
@@ -52,20 +105,232 @@ func (x Period) String() string {
 }
 
 func (Period) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_1b40cafcd4234784, []int{0}
+	return fileDescriptor_1b40cafcd4234784, []int{2}
+}
+
+// SortOrder of ListEventsRange, events starting at the same time are ordered by uuid
+type SortOrder int32
+
+const (
+	SortOrder_START_ASC  SortOrder = 0
+	SortOrder_START_DESC SortOrder = 1
+)
+
+var SortOrder_name = map[int32]string{
+	0: "START_ASC",
+	1: "START_DESC",
+}
+
+var SortOrder_value = map[string]int32{
+	"START_ASC":  0,
+	"START_DESC": 1,
+}
+
+func (x SortOrder) String() string {
+	return proto.EnumName(SortOrder_name, int32(x))
+}
+
+func (SortOrder) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{3}
+}
+
+// Scope selects which part of a recurring series is changed
+type Scope int32
+
+const (
+	Scope_SERIES     Scope = 0
+	Scope_OCCURRENCE Scope = 1
+	Scope_FOLLOWING  Scope = 2
+)
+
+var Scope_name = map[int32]string{
+	0: "SERIES",
+	1: "OCCURRENCE",
+	2: "FOLLOWING",
+}
+
+var Scope_value = map[string]int32{
+	"SERIES":     0,
+	"OCCURRENCE": 1,
+	"FOLLOWING":  2,
+}
+
+func (x Scope) String() string {
+	return proto.EnumName(Scope_name, int32(x))
+}
+
+func (Scope) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{4}
+}
+
+// ISO 8601 day numbering, unspecified means Monday
+type Weekday int32
+
+const (
+	Weekday_WEEKDAY_UNSPECIFIED Weekday = 0
+	Weekday_MONDAY              Weekday = 1
+	Weekday_TUESDAY             Weekday = 2
+	Weekday_WEDNESDAY           Weekday = 3
+	Weekday_THURSDAY            Weekday = 4
+	Weekday_FRIDAY              Weekday = 5
+	Weekday_SATURDAY            Weekday = 6
+	Weekday_SUNDAY              Weekday = 7
+)
+
+var Weekday_name = map[int32]string{
+	0: "WEEKDAY_UNSPECIFIED",
+	1: "MONDAY",
+	2: "TUESDAY",
+	3: "WEDNESDAY",
+	4: "THURSDAY",
+	5: "FRIDAY",
+	6: "SATURDAY",
+	7: "SUNDAY",
+}
+
+var Weekday_value = map[string]int32{
+	"WEEKDAY_UNSPECIFIED": 0,
+	"MONDAY":              1,
+	"TUESDAY":             2,
+	"WEDNESDAY":           3,
+	"THURSDAY":            4,
+	"FRIDAY":              5,
+	"SATURDAY":            6,
+	"SUNDAY":              7,
+}
+
+func (x Weekday) String() string {
+	return proto.EnumName(Weekday_name, int32(x))
+}
+
+func (Weekday) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{5}
+}
+
+type NotificationChannel int32
+
+const (
+	NotificationChannel_FILE    NotificationChannel = 0
+	NotificationChannel_EMAIL   NotificationChannel = 1
+	NotificationChannel_WEBHOOK NotificationChannel = 2
+)
+
+var NotificationChannel_name = map[int32]string{
+	0: "FILE",
+	1: "EMAIL",
+	2: "WEBHOOK",
+}
+
+var NotificationChannel_value = map[string]int32{
+	"FILE":    0,
+	"EMAIL":   1,
+	"WEBHOOK": 2,
+}
+
+func (x NotificationChannel) String() string {
+	return proto.EnumName(NotificationChannel_name, int32(x))
+}
+
+func (NotificationChannel) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{6}
+}
+
+// ChangeType values are prefixed: enum values share the file scope with ImportStatus
+type ChangeType int32
+
+const (
+	// all occurrences in the window, always the first response
+	ChangeType_CHANGE_SNAPSHOT ChangeType = 0
+	ChangeType_CHANGE_CREATED  ChangeType = 1
+	ChangeType_CHANGE_UPDATED  ChangeType = 2
+	// the event is gone together with its moved occurrences (events with seriesUuid = uuid)
+	ChangeType_CHANGE_DELETED ChangeType = 3
+)
+
+var ChangeType_name = map[int32]string{
+	0: "CHANGE_SNAPSHOT",
+	1: "CHANGE_CREATED",
+	2: "CHANGE_UPDATED",
+	3: "CHANGE_DELETED",
+}
+
+var ChangeType_value = map[string]int32{
+	"CHANGE_SNAPSHOT": 0,
+	"CHANGE_CREATED":  1,
+	"CHANGE_UPDATED":  2,
+	"CHANGE_DELETED":  3,
+}
+
+func (x ChangeType) String() string {
+	return proto.EnumName(ChangeType_name, int32(x))
+}
+
+func (ChangeType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{7}
+}
+
+type ImportStatus int32
+
+const (
+	ImportStatus_CREATED ImportStatus = 0
+	ImportStatus_UPDATED ImportStatus = 1
+	// the time is busy, the event was skipped
+	ImportStatus_CONFLICT ImportStatus = 2
+	// the event could not be read, see error
+	ImportStatus_INVALID ImportStatus = 3
+)
+
+var ImportStatus_name = map[int32]string{
+	0: "CREATED",
+	1: "UPDATED",
+	2: "CONFLICT",
+	3: "INVALID",
+}
+
+var ImportStatus_value = map[string]int32{
+	"CREATED":  0,
+	"UPDATED":  1,
+	"CONFLICT": 2,
+	"INVALID":  3,
+}
+
+func (x ImportStatus) String() string {
+	return proto.EnumName(ImportStatus_name, int32(x))
+}
+
+func (ImportStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{8}
 }
 
 type Event struct {
-	Uuid                 string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
-	Title                string               `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	StartAt              *timestamp.Timestamp `protobuf:"bytes,3,opt,name=startAt,proto3" json:"startAt,omitempty"`
-	Duration             *duration.Duration   `protobuf:"bytes,4,opt,name=duration,proto3" json:"duration,omitempty"`
-	Description          string               `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
-	User                 string               `protobuf:"bytes,6,opt,name=user,proto3" json:"user,omitempty"`
-	NotifyBefore         *duration.Duration   `protobuf:"bytes,7,opt,name=notifyBefore,proto3" json:"notifyBefore,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+	Uuid         string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Title        string               `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	StartAt      *timestamp.Timestamp `protobuf:"bytes,3,opt,name=startAt,proto3" json:"startAt,omitempty"`
+	Duration     *duration.Duration   `protobuf:"bytes,4,opt,name=duration,proto3" json:"duration,omitempty"`
+	Description  string               `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	User         string               `protobuf:"bytes,6,opt,name=user,proto3" json:"user,omitempty"`
+	NotifyBefore *duration.Duration   `protobuf:"bytes,7,opt,name=notifyBefore,proto3" json:"notifyBefore,omitempty"`
+	// RFC 5545 recurrence rule, e.g. "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"; empty for one-off events
+	Rrule string `protobuf:"bytes,8,opt,name=rrule,proto3" json:"rrule,omitempty"`
+	// set on moved occurrences: the series they were detached from
+	SeriesUuid string `protobuf:"bytes,9,opt,name=seriesUuid,proto3" json:"seriesUuid,omitempty"`
+	// original start of a series occurrence; pass it back as UpdateRequest/DeleteRequest occurrence
+	RecurrenceAt *timestamp.Timestamp `protobuf:"bytes,10,opt,name=recurrenceAt,proto3" json:"recurrenceAt,omitempty"`
+	// startAt rendered in the user's time zone, RFC 3339; filled in ListEvents and ListEventsRange responses
+	StartAtLocal string `protobuf:"bytes,11,opt,name=startAtLocal,proto3" json:"startAtLocal,omitempty"`
+	// all-day event: startAt and duration are ignored, the event takes whole local days
+	// from startDate to endDate in the user's time zone
+	AllDay bool `protobuf:"varint,12,opt,name=allDay,proto3" json:"allDay,omitempty"`
+	// YYYY-MM-DD
+	StartDate string `protobuf:"bytes,13,opt,name=startDate,proto3" json:"startDate,omitempty"`
+	// YYYY-MM-DD, exclusive; empty means a single day
+	EndDate string `protobuf:"bytes,14,opt,name=endDate,proto3" json:"endDate,omitempty"`
+	// transparent and cancelled events are shown but do not block time
+	Transparency         Transparency `protobuf:"varint,15,opt,name=transparency,proto3,enum=Transparency" json:"transparency,omitempty"`
+	Status               Status       `protobuf:"varint,16,opt,name=status,proto3,enum=Status" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
 func (m *Event) Reset()         { *m = Event{} }
@@ -142,6 +407,210 @@ func (m *Event) GetNotifyBefore() *duration.Duration {
 	return nil
 }
 
+func (m *Event) GetRrule() string {
+	if m != nil {
+		return m.Rrule
+	}
+	return ""
+}
+
+func (m *Event) GetSeriesUuid() string {
+	if m != nil {
+		return m.SeriesUuid
+	}
+	return ""
+}
+
+func (m *Event) GetRecurrenceAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.RecurrenceAt
+	}
+	return nil
+}
+
+func (m *Event) GetStartAtLocal() string {
+	if m != nil {
+		return m.StartAtLocal
+	}
+	return ""
+}
+
+func (m *Event) GetAllDay() bool {
+	if m != nil {
+		return m.AllDay
+	}
+	return false
+}
+
+func (m *Event) GetStartDate() string {
+	if m != nil {
+		return m.StartDate
+	}
+	return ""
+}
+
+func (m *Event) GetEndDate() string {
+	if m != nil {
+		return m.EndDate
+	}
+	return ""
+}
+
+func (m *Event) GetTransparency() Transparency {
+	if m != nil {
+		return m.Transparency
+	}
+	return Transparency_OPAQUE
+}
+
+func (m *Event) GetStatus() Status {
+	if m != nil {
+		return m.Status
+	}
+	return Status_CONFIRMED
+}
+
+type Profile struct {
+	User string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	// IANA time zone name, e.g. "Europe/Moscow"
+	TimeZone  string  `protobuf:"bytes,2,opt,name=timeZone,proto3" json:"timeZone,omitempty"`
+	WeekStart Weekday `protobuf:"varint,3,opt,name=weekStart,proto3,enum=Weekday" json:"weekStart,omitempty"`
+	// whether all-day events block time for conflict checks
+	AllDayBusy bool `protobuf:"varint,4,opt,name=allDayBusy,proto3" json:"allDayBusy,omitempty"`
+	// address for EMAIL reminders
+	Email string `protobuf:"bytes,5,opt,name=email,proto3" json:"email,omitempty"`
+	// URL receiving WEBHOOK reminders as JSON POST requests
+	WebhookUrl string `protobuf:"bytes,6,opt,name=webhookUrl,proto3" json:"webhookUrl,omitempty"`
+	// channels reminders are sent to; empty means FILE only
+	Channels []NotificationChannel `protobuf:"varint,7,rep,packed,name=channels,proto3,enum=NotificationChannel" json:"channels,omitempty"`
+	// language of reminders: "en" (default) or "ru"
+	Locale               string   `protobuf:"bytes,8,opt,name=locale,proto3" json:"locale,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Profile) Reset()         { *m = Profile{} }
+func (m *Profile) String() string { return proto.CompactTextString(m) }
+func (*Profile) ProtoMessage()    {}
+func (*Profile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{1}
+}
+
+func (m *Profile) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Profile.Unmarshal(m, b)
+}
+func (m *Profile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Profile.Marshal(b, m, deterministic)
+}
+func (m *Profile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Profile.Merge(m, src)
+}
+func (m *Profile) XXX_Size() int {
+	return xxx_messageInfo_Profile.Size(m)
+}
+func (m *Profile) XXX_DiscardUnknown() {
+	xxx_messageInfo_Profile.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Profile proto.InternalMessageInfo
+
+func (m *Profile) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *Profile) GetTimeZone() string {
+	if m != nil {
+		return m.TimeZone
+	}
+	return ""
+}
+
+func (m *Profile) GetWeekStart() Weekday {
+	if m != nil {
+		return m.WeekStart
+	}
+	return Weekday_WEEKDAY_UNSPECIFIED
+}
+
+func (m *Profile) GetAllDayBusy() bool {
+	if m != nil {
+		return m.AllDayBusy
+	}
+	return false
+}
+
+func (m *Profile) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *Profile) GetWebhookUrl() string {
+	if m != nil {
+		return m.WebhookUrl
+	}
+	return ""
+}
+
+func (m *Profile) GetChannels() []NotificationChannel {
+	if m != nil {
+		return m.Channels
+	}
+	return nil
+}
+
+func (m *Profile) GetLocale() string {
+	if m != nil {
+		return m.Locale
+	}
+	return ""
+}
+
+type GetProfileRequest struct {
+	User                 string   `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetProfileRequest) Reset()         { *m = GetProfileRequest{} }
+func (m *GetProfileRequest) String() string { return proto.CompactTextString(m) }
+func (*GetProfileRequest) ProtoMessage()    {}
+func (*GetProfileRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{2}
+}
+
+func (m *GetProfileRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetProfileRequest.Unmarshal(m, b)
+}
+func (m *GetProfileRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetProfileRequest.Marshal(b, m, deterministic)
+}
+func (m *GetProfileRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetProfileRequest.Merge(m, src)
+}
+func (m *GetProfileRequest) XXX_Size() int {
+	return xxx_messageInfo_GetProfileRequest.Size(m)
+}
+func (m *GetProfileRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetProfileRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetProfileRequest proto.InternalMessageInfo
+
+func (m *GetProfileRequest) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+// ListRequest period is resolved to local calendar boundaries in the user's time zone
 type ListRequest struct {
 	Date                 *timestamp.Timestamp `protobuf:"bytes,1,opt,name=date,proto3" json:"date,omitempty"`
 	Period               Period               `protobuf:"varint,2,opt,name=period,proto3,enum=Period" json:"period,omitempty"`
@@ -155,7 +624,7 @@ func (m *ListRequest) Reset()         { *m = ListRequest{} }
 func (m *ListRequest) String() string { return proto.CompactTextString(m) }
 func (*ListRequest) ProtoMessage()    {}
 func (*ListRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b40cafcd4234784, []int{1}
+	return fileDescriptor_1b40cafcd4234784, []int{3}
 }
 
 func (m *ListRequest) XXX_Unmarshal(b []byte) error {
@@ -199,6 +668,7 @@ func (m *ListRequest) GetUser() string {
 
 type ListResponse struct {
 	Events               []*Event `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	TimeZone             string   `protobuf:"bytes,2,opt,name=timeZone,proto3" json:"timeZone,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -208,7 +678,7 @@ func (m *ListResponse) Reset()         { *m = ListResponse{} }
 func (m *ListResponse) String() string { return proto.CompactTextString(m) }
 func (*ListResponse) ProtoMessage()    {}
 func (*ListResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b40cafcd4234784, []int{2}
+	return fileDescriptor_1b40cafcd4234784, []int{4}
 }
 
 func (m *ListResponse) XXX_Unmarshal(b []byte) error {
@@ -236,43 +706,310 @@ func (m *ListResponse) GetEvents() []*Event {
 	return nil
 }
 
-type CreateRequest struct {
-	Event                *Event   `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *ListResponse) GetTimeZone() string {
+	if m != nil {
+		return m.TimeZone
+	}
+	return ""
 }
 
-func (m *CreateRequest) Reset()         { *m = CreateRequest{} }
-func (m *CreateRequest) String() string { return proto.CompactTextString(m) }
-func (*CreateRequest) ProtoMessage()    {}
-func (*CreateRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b40cafcd4234784, []int{3}
+// ListRangeRequest lists occurrences overlapping [from, to) page by page
+type ListRangeRequest struct {
+	User string               `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	From *timestamp.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To   *timestamp.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	// 100 when omitted, larger values are capped at 1000
+	PageSize int32 `protobuf:"varint,4,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	// nextPageToken of the previous page; it is only valid with the same user, from, to and order
+	PageToken            string    `protobuf:"bytes,5,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	Order                SortOrder `protobuf:"varint,6,opt,name=order,proto3,enum=SortOrder" json:"order,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ListRangeRequest) Reset()         { *m = ListRangeRequest{} }
+func (m *ListRangeRequest) String() string { return proto.CompactTextString(m) }
+func (*ListRangeRequest) ProtoMessage()    {}
+func (*ListRangeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{5}
 }
 
-func (m *CreateRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_CreateRequest.Unmarshal(m, b)
+func (m *ListRangeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRangeRequest.Unmarshal(m, b)
 }
-func (m *CreateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_CreateRequest.Marshal(b, m, deterministic)
+func (m *ListRangeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRangeRequest.Marshal(b, m, deterministic)
 }
-func (m *CreateRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_CreateRequest.Merge(m, src)
+func (m *ListRangeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRangeRequest.Merge(m, src)
 }
-func (m *CreateRequest) XXX_Size() int {
-	return xxx_messageInfo_CreateRequest.Size(m)
+func (m *ListRangeRequest) XXX_Size() int {
+	return xxx_messageInfo_ListRangeRequest.Size(m)
 }
-func (m *CreateRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_CreateRequest.DiscardUnknown(m)
+func (m *ListRangeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRangeRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_CreateRequest proto.InternalMessageInfo
+var xxx_messageInfo_ListRangeRequest proto.InternalMessageInfo
 
-func (m *CreateRequest) GetEvent() *Event {
+func (m *ListRangeRequest) GetUser() string {
 	if m != nil {
-		return m.Event
+		return m.User
 	}
-	return nil
+	return ""
+}
+
+func (m *ListRangeRequest) GetFrom() *timestamp.Timestamp {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *ListRangeRequest) GetTo() *timestamp.Timestamp {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *ListRangeRequest) GetPageSize() int32 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *ListRangeRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+func (m *ListRangeRequest) GetOrder() SortOrder {
+	if m != nil {
+		return m.Order
+	}
+	return SortOrder_START_ASC
+}
+
+type ListRangeResponse struct {
+	Events []*Event `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	// empty on the last page
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	TimeZone             string   `protobuf:"bytes,3,opt,name=timeZone,proto3" json:"timeZone,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListRangeResponse) Reset()         { *m = ListRangeResponse{} }
+func (m *ListRangeResponse) String() string { return proto.CompactTextString(m) }
+func (*ListRangeResponse) ProtoMessage()    {}
+func (*ListRangeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{6}
+}
+
+func (m *ListRangeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRangeResponse.Unmarshal(m, b)
+}
+func (m *ListRangeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRangeResponse.Marshal(b, m, deterministic)
+}
+func (m *ListRangeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRangeResponse.Merge(m, src)
+}
+func (m *ListRangeResponse) XXX_Size() int {
+	return xxx_messageInfo_ListRangeResponse.Size(m)
+}
+func (m *ListRangeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRangeResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRangeResponse proto.InternalMessageInfo
+
+func (m *ListRangeResponse) GetEvents() []*Event {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+func (m *ListRangeResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
+func (m *ListRangeResponse) GetTimeZone() string {
+	if m != nil {
+		return m.TimeZone
+	}
+	return ""
+}
+
+// WatchRequest watches occurrences overlapping [from, to)
+type WatchRequest struct {
+	User                 string               `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	From                 *timestamp.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To                   *timestamp.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *WatchRequest) Reset()         { *m = WatchRequest{} }
+func (m *WatchRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchRequest) ProtoMessage()    {}
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{7}
+}
+
+func (m *WatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchRequest.Unmarshal(m, b)
+}
+func (m *WatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchRequest.Merge(m, src)
+}
+func (m *WatchRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchRequest.Size(m)
+}
+func (m *WatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchRequest proto.InternalMessageInfo
+
+func (m *WatchRequest) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *WatchRequest) GetFrom() *timestamp.Timestamp {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *WatchRequest) GetTo() *timestamp.Timestamp {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+// WatchResponse a change replaces all occurrences of the event uuid the client has with events,
+// which are empty when the event is deleted or left the window
+type WatchResponse struct {
+	Type   ChangeType `protobuf:"varint,1,opt,name=type,proto3,enum=ChangeType" json:"type,omitempty"`
+	Uuid   string     `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Events []*Event   `protobuf:"bytes,3,rep,name=events,proto3" json:"events,omitempty"`
+	// set in the snapshot
+	TimeZone             string   `protobuf:"bytes,4,opt,name=timeZone,proto3" json:"timeZone,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchResponse) Reset()         { *m = WatchResponse{} }
+func (m *WatchResponse) String() string { return proto.CompactTextString(m) }
+func (*WatchResponse) ProtoMessage()    {}
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{8}
+}
+
+func (m *WatchResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchResponse.Unmarshal(m, b)
+}
+func (m *WatchResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchResponse.Marshal(b, m, deterministic)
+}
+func (m *WatchResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchResponse.Merge(m, src)
+}
+func (m *WatchResponse) XXX_Size() int {
+	return xxx_messageInfo_WatchResponse.Size(m)
+}
+func (m *WatchResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchResponse proto.InternalMessageInfo
+
+func (m *WatchResponse) GetType() ChangeType {
+	if m != nil {
+		return m.Type
+	}
+	return ChangeType_CHANGE_SNAPSHOT
+}
+
+func (m *WatchResponse) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *WatchResponse) GetEvents() []*Event {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+func (m *WatchResponse) GetTimeZone() string {
+	if m != nil {
+		return m.TimeZone
+	}
+	return ""
+}
+
+type CreateRequest struct {
+	Event                *Event   `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreateRequest) Reset()         { *m = CreateRequest{} }
+func (m *CreateRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateRequest) ProtoMessage()    {}
+func (*CreateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{9}
+}
+
+func (m *CreateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateRequest.Unmarshal(m, b)
+}
+func (m *CreateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateRequest.Marshal(b, m, deterministic)
+}
+func (m *CreateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateRequest.Merge(m, src)
+}
+func (m *CreateRequest) XXX_Size() int {
+	return xxx_messageInfo_CreateRequest.Size(m)
+}
+func (m *CreateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateRequest proto.InternalMessageInfo
+
+func (m *CreateRequest) GetEvent() *Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
 }
 
 type CreateResponse struct {
@@ -286,7 +1023,7 @@ func (m *CreateResponse) Reset()         { *m = CreateResponse{} }
 func (m *CreateResponse) String() string { return proto.CompactTextString(m) }
 func (*CreateResponse) ProtoMessage()    {}
 func (*CreateResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b40cafcd4234784, []int{4}
+	return fileDescriptor_1b40cafcd4234784, []int{10}
 }
 
 func (m *CreateResponse) XXX_Unmarshal(b []byte) error {
@@ -301,114 +1038,762 @@ func (m *CreateResponse) XXX_Merge(src proto.Message) {
 func (m *CreateResponse) XXX_Size() int {
 	return xxx_messageInfo_CreateResponse.Size(m)
 }
-func (m *CreateResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_CreateResponse.DiscardUnknown(m)
+func (m *CreateResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateResponse proto.InternalMessageInfo
+
+func (m *CreateResponse) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+type UpdateRequest struct {
+	Uuid                 string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Event                *Event               `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	Scope                Scope                `protobuf:"varint,3,opt,name=scope,proto3,enum=Scope" json:"scope,omitempty"`
+	Occurrence           *timestamp.Timestamp `protobuf:"bytes,4,opt,name=occurrence,proto3" json:"occurrence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *UpdateRequest) Reset()         { *m = UpdateRequest{} }
+func (m *UpdateRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateRequest) ProtoMessage()    {}
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{11}
+}
+
+func (m *UpdateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateRequest.Unmarshal(m, b)
+}
+func (m *UpdateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateRequest.Marshal(b, m, deterministic)
+}
+func (m *UpdateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateRequest.Merge(m, src)
+}
+func (m *UpdateRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateRequest.Size(m)
+}
+func (m *UpdateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateRequest proto.InternalMessageInfo
+
+func (m *UpdateRequest) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *UpdateRequest) GetEvent() *Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (m *UpdateRequest) GetScope() Scope {
+	if m != nil {
+		return m.Scope
+	}
+	return Scope_SERIES
+}
+
+func (m *UpdateRequest) GetOccurrence() *timestamp.Timestamp {
+	if m != nil {
+		return m.Occurrence
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	Uuid                 string               `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Scope                Scope                `protobuf:"varint,2,opt,name=scope,proto3,enum=Scope" json:"scope,omitempty"`
+	Occurrence           *timestamp.Timestamp `protobuf:"bytes,3,opt,name=occurrence,proto3" json:"occurrence,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *DeleteRequest) Reset()         { *m = DeleteRequest{} }
+func (m *DeleteRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteRequest) ProtoMessage()    {}
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{12}
+}
+
+func (m *DeleteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteRequest.Unmarshal(m, b)
+}
+func (m *DeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteRequest.Merge(m, src)
+}
+func (m *DeleteRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteRequest.Size(m)
+}
+func (m *DeleteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteRequest proto.InternalMessageInfo
+
+func (m *DeleteRequest) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *DeleteRequest) GetScope() Scope {
+	if m != nil {
+		return m.Scope
+	}
+	return Scope_SERIES
+}
+
+func (m *DeleteRequest) GetOccurrence() *timestamp.Timestamp {
+	if m != nil {
+		return m.Occurrence
+	}
+	return nil
+}
+
+type FreeBusyRequest struct {
+	Users                []string             `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	From                 *timestamp.Timestamp `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To                   *timestamp.Timestamp `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *FreeBusyRequest) Reset()         { *m = FreeBusyRequest{} }
+func (m *FreeBusyRequest) String() string { return proto.CompactTextString(m) }
+func (*FreeBusyRequest) ProtoMessage()    {}
+func (*FreeBusyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{13}
+}
+
+func (m *FreeBusyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FreeBusyRequest.Unmarshal(m, b)
+}
+func (m *FreeBusyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FreeBusyRequest.Marshal(b, m, deterministic)
+}
+func (m *FreeBusyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FreeBusyRequest.Merge(m, src)
+}
+func (m *FreeBusyRequest) XXX_Size() int {
+	return xxx_messageInfo_FreeBusyRequest.Size(m)
+}
+func (m *FreeBusyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FreeBusyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FreeBusyRequest proto.InternalMessageInfo
+
+func (m *FreeBusyRequest) GetUsers() []string {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
+func (m *FreeBusyRequest) GetFrom() *timestamp.Timestamp {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *FreeBusyRequest) GetTo() *timestamp.Timestamp {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+type Interval struct {
+	Start                *timestamp.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End                  *timestamp.Timestamp `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Interval) Reset()         { *m = Interval{} }
+func (m *Interval) String() string { return proto.CompactTextString(m) }
+func (*Interval) ProtoMessage()    {}
+func (*Interval) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{14}
+}
+
+func (m *Interval) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Interval.Unmarshal(m, b)
+}
+func (m *Interval) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Interval.Marshal(b, m, deterministic)
+}
+func (m *Interval) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Interval.Merge(m, src)
+}
+func (m *Interval) XXX_Size() int {
+	return xxx_messageInfo_Interval.Size(m)
+}
+func (m *Interval) XXX_DiscardUnknown() {
+	xxx_messageInfo_Interval.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Interval proto.InternalMessageInfo
+
+func (m *Interval) GetStart() *timestamp.Timestamp {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *Interval) GetEnd() *timestamp.Timestamp {
+	if m != nil {
+		return m.End
+	}
+	return nil
+}
+
+// UserBusy merged busy intervals of one user, event details are not exposed
+type UserBusy struct {
+	User                 string      `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Busy                 []*Interval `protobuf:"bytes,2,rep,name=busy,proto3" json:"busy,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *UserBusy) Reset()         { *m = UserBusy{} }
+func (m *UserBusy) String() string { return proto.CompactTextString(m) }
+func (*UserBusy) ProtoMessage()    {}
+func (*UserBusy) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{15}
+}
+
+func (m *UserBusy) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UserBusy.Unmarshal(m, b)
+}
+func (m *UserBusy) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UserBusy.Marshal(b, m, deterministic)
+}
+func (m *UserBusy) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UserBusy.Merge(m, src)
+}
+func (m *UserBusy) XXX_Size() int {
+	return xxx_messageInfo_UserBusy.Size(m)
+}
+func (m *UserBusy) XXX_DiscardUnknown() {
+	xxx_messageInfo_UserBusy.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UserBusy proto.InternalMessageInfo
+
+func (m *UserBusy) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *UserBusy) GetBusy() []*Interval {
+	if m != nil {
+		return m.Busy
+	}
+	return nil
+}
+
+type FreeBusyResponse struct {
+	Users                []*UserBusy `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *FreeBusyResponse) Reset()         { *m = FreeBusyResponse{} }
+func (m *FreeBusyResponse) String() string { return proto.CompactTextString(m) }
+func (*FreeBusyResponse) ProtoMessage()    {}
+func (*FreeBusyResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{16}
+}
+
+func (m *FreeBusyResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FreeBusyResponse.Unmarshal(m, b)
+}
+func (m *FreeBusyResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FreeBusyResponse.Marshal(b, m, deterministic)
+}
+func (m *FreeBusyResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FreeBusyResponse.Merge(m, src)
+}
+func (m *FreeBusyResponse) XXX_Size() int {
+	return xxx_messageInfo_FreeBusyResponse.Size(m)
+}
+func (m *FreeBusyResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FreeBusyResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FreeBusyResponse proto.InternalMessageInfo
+
+func (m *FreeBusyResponse) GetUsers() []*UserBusy {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
+// WorkingHours time of day in each user's own time zone, e.g. start 9h and end 18h
+type WorkingHours struct {
+	Start                *duration.Duration `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	End                  *duration.Duration `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *WorkingHours) Reset()         { *m = WorkingHours{} }
+func (m *WorkingHours) String() string { return proto.CompactTextString(m) }
+func (*WorkingHours) ProtoMessage()    {}
+func (*WorkingHours) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{17}
+}
+
+func (m *WorkingHours) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WorkingHours.Unmarshal(m, b)
+}
+func (m *WorkingHours) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WorkingHours.Marshal(b, m, deterministic)
+}
+func (m *WorkingHours) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WorkingHours.Merge(m, src)
+}
+func (m *WorkingHours) XXX_Size() int {
+	return xxx_messageInfo_WorkingHours.Size(m)
+}
+func (m *WorkingHours) XXX_DiscardUnknown() {
+	xxx_messageInfo_WorkingHours.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WorkingHours proto.InternalMessageInfo
+
+func (m *WorkingHours) GetStart() *duration.Duration {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *WorkingHours) GetEnd() *duration.Duration {
+	if m != nil {
+		return m.End
+	}
+	return nil
+}
+
+type FindSlotRequest struct {
+	Users    []string             `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	Duration *duration.Duration   `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	From     *timestamp.Timestamp `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To       *timestamp.Timestamp `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	// optional, slots may be at any time of day when omitted
+	WorkingHours *WorkingHours `protobuf:"bytes,5,opt,name=workingHours,proto3" json:"workingHours,omitempty"`
+	// how many slots to return, 5 when omitted
+	Limit                int32    `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FindSlotRequest) Reset()         { *m = FindSlotRequest{} }
+func (m *FindSlotRequest) String() string { return proto.CompactTextString(m) }
+func (*FindSlotRequest) ProtoMessage()    {}
+func (*FindSlotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{18}
+}
+
+func (m *FindSlotRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FindSlotRequest.Unmarshal(m, b)
+}
+func (m *FindSlotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FindSlotRequest.Marshal(b, m, deterministic)
+}
+func (m *FindSlotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FindSlotRequest.Merge(m, src)
+}
+func (m *FindSlotRequest) XXX_Size() int {
+	return xxx_messageInfo_FindSlotRequest.Size(m)
+}
+func (m *FindSlotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FindSlotRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FindSlotRequest proto.InternalMessageInfo
+
+func (m *FindSlotRequest) GetUsers() []string {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
+func (m *FindSlotRequest) GetDuration() *duration.Duration {
+	if m != nil {
+		return m.Duration
+	}
+	return nil
+}
+
+func (m *FindSlotRequest) GetFrom() *timestamp.Timestamp {
+	if m != nil {
+		return m.From
+	}
+	return nil
+}
+
+func (m *FindSlotRequest) GetTo() *timestamp.Timestamp {
+	if m != nil {
+		return m.To
+	}
+	return nil
+}
+
+func (m *FindSlotRequest) GetWorkingHours() *WorkingHours {
+	if m != nil {
+		return m.WorkingHours
+	}
+	return nil
+}
+
+func (m *FindSlotRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+// FindSlotResponse non-overlapping slots free for every user, earliest first
+type FindSlotResponse struct {
+	Slots                []*Interval `protobuf:"bytes,1,rep,name=slots,proto3" json:"slots,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *FindSlotResponse) Reset()         { *m = FindSlotResponse{} }
+func (m *FindSlotResponse) String() string { return proto.CompactTextString(m) }
+func (*FindSlotResponse) ProtoMessage()    {}
+func (*FindSlotResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{19}
+}
+
+func (m *FindSlotResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FindSlotResponse.Unmarshal(m, b)
+}
+func (m *FindSlotResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FindSlotResponse.Marshal(b, m, deterministic)
+}
+func (m *FindSlotResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FindSlotResponse.Merge(m, src)
+}
+func (m *FindSlotResponse) XXX_Size() int {
+	return xxx_messageInfo_FindSlotResponse.Size(m)
+}
+func (m *FindSlotResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FindSlotResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FindSlotResponse proto.InternalMessageInfo
+
+func (m *FindSlotResponse) GetSlots() []*Interval {
+	if m != nil {
+		return m.Slots
+	}
+	return nil
+}
+
+type ExportRequest struct {
+	User                 string   `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportRequest) Reset()         { *m = ExportRequest{} }
+func (m *ExportRequest) String() string { return proto.CompactTextString(m) }
+func (*ExportRequest) ProtoMessage()    {}
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{20}
+}
+
+func (m *ExportRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportRequest.Unmarshal(m, b)
+}
+func (m *ExportRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportRequest.Marshal(b, m, deterministic)
+}
+func (m *ExportRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportRequest.Merge(m, src)
+}
+func (m *ExportRequest) XXX_Size() int {
+	return xxx_messageInfo_ExportRequest.Size(m)
+}
+func (m *ExportRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportRequest proto.InternalMessageInfo
+
+func (m *ExportRequest) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+// ExportResponse calendar is an RFC 5545 VCALENDAR
+type ExportResponse struct {
+	Calendar             string   `protobuf:"bytes,1,opt,name=calendar,proto3" json:"calendar,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExportResponse) Reset()         { *m = ExportResponse{} }
+func (m *ExportResponse) String() string { return proto.CompactTextString(m) }
+func (*ExportResponse) ProtoMessage()    {}
+func (*ExportResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{21}
+}
+
+func (m *ExportResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExportResponse.Unmarshal(m, b)
+}
+func (m *ExportResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExportResponse.Marshal(b, m, deterministic)
+}
+func (m *ExportResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExportResponse.Merge(m, src)
+}
+func (m *ExportResponse) XXX_Size() int {
+	return xxx_messageInfo_ExportResponse.Size(m)
+}
+func (m *ExportResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExportResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExportResponse proto.InternalMessageInfo
+
+func (m *ExportResponse) GetCalendar() string {
+	if m != nil {
+		return m.Calendar
+	}
+	return ""
+}
+
+// ImportRequest calendar is an RFC 5545 VCALENDAR; events already imported
+// with the same UID are updated instead of duplicated
+type ImportRequest struct {
+	User                 string   `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Calendar             string   `protobuf:"bytes,2,opt,name=calendar,proto3" json:"calendar,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ImportRequest) Reset()         { *m = ImportRequest{} }
+func (m *ImportRequest) String() string { return proto.CompactTextString(m) }
+func (*ImportRequest) ProtoMessage()    {}
+func (*ImportRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{22}
+}
+
+func (m *ImportRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportRequest.Unmarshal(m, b)
+}
+func (m *ImportRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportRequest.Marshal(b, m, deterministic)
+}
+func (m *ImportRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportRequest.Merge(m, src)
+}
+func (m *ImportRequest) XXX_Size() int {
+	return xxx_messageInfo_ImportRequest.Size(m)
+}
+func (m *ImportRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_CreateResponse proto.InternalMessageInfo
+var xxx_messageInfo_ImportRequest proto.InternalMessageInfo
 
-func (m *CreateResponse) GetUuid() string {
+func (m *ImportRequest) GetUser() string {
 	if m != nil {
-		return m.Uuid
+		return m.User
 	}
 	return ""
 }
 
-type UpdateRequest struct {
-	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
-	Event                *Event   `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *ImportRequest) GetCalendar() string {
+	if m != nil {
+		return m.Calendar
+	}
+	return ""
 }
 
-func (m *UpdateRequest) Reset()         { *m = UpdateRequest{} }
-func (m *UpdateRequest) String() string { return proto.CompactTextString(m) }
-func (*UpdateRequest) ProtoMessage()    {}
-func (*UpdateRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b40cafcd4234784, []int{5}
+type ImportResult struct {
+	// iCalendar UID of the VEVENT, may be empty for invalid events
+	Uid                  string       `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	Uuid                 string       `protobuf:"bytes,2,opt,name=uuid,proto3" json:"uuid,omitempty"`
+	Status               ImportStatus `protobuf:"varint,3,opt,name=status,proto3,enum=ImportStatus" json:"status,omitempty"`
+	Error                string       `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
 }
 
-func (m *UpdateRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_UpdateRequest.Unmarshal(m, b)
+func (m *ImportResult) Reset()         { *m = ImportResult{} }
+func (m *ImportResult) String() string { return proto.CompactTextString(m) }
+func (*ImportResult) ProtoMessage()    {}
+func (*ImportResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{23}
 }
-func (m *UpdateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_UpdateRequest.Marshal(b, m, deterministic)
+
+func (m *ImportResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportResult.Unmarshal(m, b)
 }
-func (m *UpdateRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_UpdateRequest.Merge(m, src)
+func (m *ImportResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportResult.Marshal(b, m, deterministic)
 }
-func (m *UpdateRequest) XXX_Size() int {
-	return xxx_messageInfo_UpdateRequest.Size(m)
+func (m *ImportResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportResult.Merge(m, src)
 }
-func (m *UpdateRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_UpdateRequest.DiscardUnknown(m)
+func (m *ImportResult) XXX_Size() int {
+	return xxx_messageInfo_ImportResult.Size(m)
+}
+func (m *ImportResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportResult.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_UpdateRequest proto.InternalMessageInfo
+var xxx_messageInfo_ImportResult proto.InternalMessageInfo
 
-func (m *UpdateRequest) GetUuid() string {
+func (m *ImportResult) GetUid() string {
+	if m != nil {
+		return m.Uid
+	}
+	return ""
+}
+
+func (m *ImportResult) GetUuid() string {
 	if m != nil {
 		return m.Uuid
 	}
 	return ""
 }
 
-func (m *UpdateRequest) GetEvent() *Event {
+func (m *ImportResult) GetStatus() ImportStatus {
 	if m != nil {
-		return m.Event
+		return m.Status
 	}
-	return nil
+	return ImportStatus_CREATED
 }
 
-type DeleteRequest struct {
-	Uuid                 string   `protobuf:"bytes,1,opt,name=uuid,proto3" json:"uuid,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *ImportResult) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
 }
 
-func (m *DeleteRequest) Reset()         { *m = DeleteRequest{} }
-func (m *DeleteRequest) String() string { return proto.CompactTextString(m) }
-func (*DeleteRequest) ProtoMessage()    {}
-func (*DeleteRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b40cafcd4234784, []int{6}
+// ImportResponse results in the order of VEVENTs in the file
+type ImportResponse struct {
+	Results              []*ImportResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
 }
 
-func (m *DeleteRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DeleteRequest.Unmarshal(m, b)
+func (m *ImportResponse) Reset()         { *m = ImportResponse{} }
+func (m *ImportResponse) String() string { return proto.CompactTextString(m) }
+func (*ImportResponse) ProtoMessage()    {}
+func (*ImportResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b40cafcd4234784, []int{24}
 }
-func (m *DeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DeleteRequest.Marshal(b, m, deterministic)
+
+func (m *ImportResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ImportResponse.Unmarshal(m, b)
 }
-func (m *DeleteRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DeleteRequest.Merge(m, src)
+func (m *ImportResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ImportResponse.Marshal(b, m, deterministic)
 }
-func (m *DeleteRequest) XXX_Size() int {
-	return xxx_messageInfo_DeleteRequest.Size(m)
+func (m *ImportResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ImportResponse.Merge(m, src)
 }
-func (m *DeleteRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_DeleteRequest.DiscardUnknown(m)
+func (m *ImportResponse) XXX_Size() int {
+	return xxx_messageInfo_ImportResponse.Size(m)
+}
+func (m *ImportResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ImportResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_DeleteRequest proto.InternalMessageInfo
+var xxx_messageInfo_ImportResponse proto.InternalMessageInfo
 
-func (m *DeleteRequest) GetUuid() string {
+func (m *ImportResponse) GetResults() []*ImportResult {
 	if m != nil {
-		return m.Uuid
+		return m.Results
 	}
-	return ""
+	return nil
 }
 
 func init() {
+	proto.RegisterEnum("Transparency", Transparency_name, Transparency_value)
+	proto.RegisterEnum("Status", Status_name, Status_value)
 	proto.RegisterEnum("Period", Period_name, Period_value)
+	proto.RegisterEnum("SortOrder", SortOrder_name, SortOrder_value)
+	proto.RegisterEnum("Scope", Scope_name, Scope_value)
+	proto.RegisterEnum("Weekday", Weekday_name, Weekday_value)
+	proto.RegisterEnum("NotificationChannel", NotificationChannel_name, NotificationChannel_value)
+	proto.RegisterEnum("ChangeType", ChangeType_name, ChangeType_value)
+	proto.RegisterEnum("ImportStatus", ImportStatus_name, ImportStatus_value)
 	proto.RegisterType((*Event)(nil), "Event")
+	proto.RegisterType((*Profile)(nil), "Profile")
+	proto.RegisterType((*GetProfileRequest)(nil), "GetProfileRequest")
 	proto.RegisterType((*ListRequest)(nil), "ListRequest")
 	proto.RegisterType((*ListResponse)(nil), "ListResponse")
+	proto.RegisterType((*ListRangeRequest)(nil), "ListRangeRequest")
+	proto.RegisterType((*ListRangeResponse)(nil), "ListRangeResponse")
+	proto.RegisterType((*WatchRequest)(nil), "WatchRequest")
+	proto.RegisterType((*WatchResponse)(nil), "WatchResponse")
 	proto.RegisterType((*CreateRequest)(nil), "CreateRequest")
 	proto.RegisterType((*CreateResponse)(nil), "CreateResponse")
 	proto.RegisterType((*UpdateRequest)(nil), "UpdateRequest")
 	proto.RegisterType((*DeleteRequest)(nil), "DeleteRequest")
+	proto.RegisterType((*FreeBusyRequest)(nil), "FreeBusyRequest")
+	proto.RegisterType((*Interval)(nil), "Interval")
+	proto.RegisterType((*UserBusy)(nil), "UserBusy")
+	proto.RegisterType((*FreeBusyResponse)(nil), "FreeBusyResponse")
+	proto.RegisterType((*WorkingHours)(nil), "WorkingHours")
+	proto.RegisterType((*FindSlotRequest)(nil), "FindSlotRequest")
+	proto.RegisterType((*FindSlotResponse)(nil), "FindSlotResponse")
+	proto.RegisterType((*ExportRequest)(nil), "ExportRequest")
+	proto.RegisterType((*ExportResponse)(nil), "ExportResponse")
+	proto.RegisterType((*ImportRequest)(nil), "ImportRequest")
+	proto.RegisterType((*ImportResult)(nil), "ImportResult")
+	proto.RegisterType((*ImportResponse)(nil), "ImportResponse")
 }
 
 func init() {
@@ -416,36 +1801,112 @@ func init() {
 }
 
 var fileDescriptor_1b40cafcd4234784 = []byte{
-	// 464 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x53, 0x6d, 0x8b, 0xd3, 0x40,
-	0x10, 0x36, 0x6d, 0x93, 0xde, 0x4d, 0x9a, 0x5a, 0x16, 0x91, 0x18, 0xe5, 0xae, 0x44, 0x91, 0x2a,
-	0xb8, 0x85, 0xea, 0x7d, 0xf4, 0x43, 0xb5, 0x05, 0xc1, 0x57, 0xc2, 0x89, 0xf8, 0x31, 0x67, 0xa6,
-	0xc7, 0x42, 0xdb, 0x5d, 0x77, 0x37, 0xc2, 0xfd, 0x0e, 0x7f, 0x99, 0xff, 0x48, 0xba, 0x2f, 0xb5,
-	0xe9, 0xd5, 0xbb, 0x6f, 0x99, 0x99, 0x67, 0xe6, 0x79, 0xe6, 0x99, 0x0d, 0x24, 0xa5, 0x60, 0xe3,
-	0x52, 0x30, 0x2a, 0x24, 0xd7, 0x3c, 0x7b, 0x78, 0xc9, 0xf9, 0xe5, 0x12, 0xc7, 0x26, 0xba, 0xa8,
-	0x17, 0x63, 0x5c, 0x09, 0x7d, 0xe5, 0x8a, 0x27, 0xfb, 0xc5, 0xaa, 0x96, 0xa5, 0x66, 0x7c, 0xed,
-	0xea, 0xa7, 0xfb, 0x75, 0xcd, 0x56, 0xa8, 0x74, 0xb9, 0x12, 0x16, 0x90, 0xff, 0x6e, 0x41, 0x38,
-	0xff, 0x85, 0x6b, 0x4d, 0x08, 0x74, 0xea, 0x9a, 0x55, 0x69, 0x30, 0x0c, 0x46, 0xc7, 0x85, 0xf9,
-	0x26, 0xf7, 0x20, 0xd4, 0x4c, 0x2f, 0x31, 0x6d, 0x99, 0xa4, 0x0d, 0xc8, 0x2b, 0xe8, 0x2a, 0x5d,
-	0x4a, 0x3d, 0xd5, 0x69, 0x7b, 0x18, 0x8c, 0xe2, 0x49, 0x46, 0x2d, 0x0d, 0xf5, 0x34, 0xf4, 0xdc,
-	0xd3, 0x14, 0x1e, 0x4a, 0xce, 0xe0, 0xc8, 0x8b, 0x4b, 0x3b, 0xa6, 0xed, 0xc1, 0xb5, 0xb6, 0x99,
-	0x03, 0x14, 0x5b, 0x28, 0x19, 0x42, 0x5c, 0xa1, 0xfa, 0x21, 0x99, 0x30, 0x9d, 0xa1, 0x11, 0xb2,
-	0x9b, 0x32, 0xc2, 0x15, 0xca, 0x34, 0x72, 0xc2, 0x15, 0x4a, 0xf2, 0x1a, 0x7a, 0x6b, 0xae, 0xd9,
-	0xe2, 0xea, 0x0d, 0x2e, 0xb8, 0xc4, 0xb4, 0x7b, 0x1b, 0x61, 0x03, 0x9e, 0x4b, 0x88, 0x3f, 0x30,
-	0xa5, 0x0b, 0xfc, 0x59, 0xa3, 0xd2, 0x84, 0x42, 0xa7, 0x2a, 0x35, 0x1a, 0x6b, 0x6e, 0xde, 0xd6,
-	0xe0, 0xc8, 0x29, 0x44, 0x02, 0x25, 0xe3, 0x95, 0xf1, 0xad, 0x3f, 0xe9, 0xd2, 0x2f, 0x26, 0x2c,
-	0x5c, 0x7a, 0x2b, 0xb9, 0xfd, 0x4f, 0x72, 0x4e, 0xa1, 0x67, 0x39, 0x95, 0xe0, 0x6b, 0x85, 0xe4,
-	0x04, 0x22, 0xdc, 0x1c, 0x46, 0xa5, 0xc1, 0xb0, 0x3d, 0x8a, 0x27, 0x11, 0x35, 0x77, 0x2a, 0x5c,
-	0x36, 0x7f, 0x01, 0xc9, 0x5b, 0x89, 0xa5, 0x46, 0xaf, 0xf2, 0x11, 0x84, 0xa6, 0xe4, 0x64, 0x7a,
-	0xbc, 0x4d, 0xe6, 0x4f, 0xa0, 0xef, 0xe1, 0x8e, 0xe0, 0xc0, 0xc1, 0xf3, 0x29, 0x24, 0x5f, 0x45,
-	0xb5, 0x33, 0xf4, 0xd0, 0xab, 0xd8, 0x12, 0xb5, 0x0e, 0x11, 0x3d, 0x86, 0x64, 0x86, 0x4b, 0xbc,
-	0x71, 0xc4, 0xf3, 0xa7, 0x10, 0x59, 0x4b, 0x48, 0x17, 0xda, 0xb3, 0xe9, 0xf7, 0xc1, 0x1d, 0x72,
-	0x04, 0x9d, 0x6f, 0xf3, 0xf9, 0xfb, 0x41, 0x40, 0x8e, 0x21, 0xfc, 0xf8, 0xf9, 0xd3, 0xf9, 0xbb,
-	0x41, 0x6b, 0xf2, 0x27, 0x80, 0xc8, 0x4c, 0x57, 0xe4, 0x19, 0xc0, 0xc6, 0x1f, 0x17, 0xf5, 0xe8,
-	0xce, 0x81, 0xb2, 0x84, 0x36, 0xac, 0xa3, 0x10, 0xdb, 0x5d, 0xed, 0xcb, 0xee, 0xd3, 0x86, 0x51,
-	0xd9, 0x5d, 0xba, 0xe7, 0xc4, 0x19, 0xc4, 0x76, 0x6b, 0x8f, 0x6f, 0x78, 0x90, 0xdd, 0xbf, 0x76,
-	0xf0, 0xf9, 0xe6, 0x17, 0xdc, 0xb4, 0xd9, 0x4d, 0x7d, 0x5b, 0x63, 0xef, 0xff, 0xb5, 0x5d, 0x44,
-	0x26, 0x7e, 0xf9, 0x37, 0x00, 0x00, 0xff, 0xff, 0x38, 0x90, 0xc3, 0x54, 0xe8, 0x03, 0x00, 0x00,
+	// 1679 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x57, 0xd9, 0x6e, 0xe3, 0xd6,
+	0x19, 0x36, 0xa9, 0xfd, 0xd7, 0x46, 0x9f, 0x19, 0xa4, 0xac, 0x9a, 0x66, 0x0c, 0x36, 0x4d, 0x5c,
+	0x65, 0x4a, 0x67, 0x94, 0x0e, 0xba, 0x00, 0x69, 0xc1, 0x91, 0xe8, 0x31, 0x1b, 0x8d, 0xe4, 0x92,
+	0x54, 0x8c, 0x14, 0x28, 0x06, 0xb4, 0x74, 0xec, 0x61, 0x4d, 0x91, 0x0a, 0x79, 0x94, 0x89, 0x0a,
+	0x34, 0x68, 0x91, 0xc7, 0xe8, 0x53, 0xf5, 0xae, 0x57, 0x7d, 0x96, 0xe2, 0x2c, 0xa4, 0x48, 0xd9,
+	0xb1, 0x9c, 0x8b, 0x22, 0x77, 0xfa, 0xf7, 0x95, 0xff, 0xf9, 0x04, 0x6d, 0x6f, 0xe5, 0x9f, 0x78,
+	0x2b, 0x5f, 0x5f, 0xc5, 0x11, 0x89, 0x7a, 0x3f, 0xb9, 0x8e, 0xa2, 0xeb, 0x00, 0x9f, 0x30, 0xea,
+	0x72, 0x7d, 0x75, 0x82, 0x97, 0x2b, 0xb2, 0x11, 0xc2, 0xf7, 0x76, 0x85, 0x8b, 0x75, 0xec, 0x11,
+	0x3f, 0x0a, 0x85, 0xfc, 0xc9, 0xae, 0x9c, 0xf8, 0x4b, 0x9c, 0x10, 0x6f, 0xb9, 0xe2, 0x0a, 0xda,
+	0xbf, 0xcb, 0x50, 0x31, 0xbf, 0xc2, 0x21, 0x41, 0x08, 0xca, 0xeb, 0xb5, 0xbf, 0x50, 0xa5, 0x23,
+	0xe9, 0xb8, 0x61, 0xb3, 0xdf, 0xe8, 0x31, 0x54, 0x88, 0x4f, 0x02, 0xac, 0xca, 0x8c, 0xc9, 0x09,
+	0xf4, 0x2b, 0xa8, 0x25, 0xc4, 0x8b, 0x89, 0x41, 0xd4, 0xd2, 0x91, 0x74, 0xdc, 0x1c, 0xf4, 0x74,
+	0x1e, 0x46, 0x4f, 0xc3, 0xe8, 0x6e, 0x1a, 0xc6, 0x4e, 0x55, 0xd1, 0x73, 0xa8, 0xa7, 0xc9, 0xa9,
+	0x65, 0x66, 0xf6, 0xe3, 0x5b, 0x66, 0x23, 0xa1, 0x60, 0x67, 0xaa, 0xe8, 0x08, 0x9a, 0x0b, 0x9c,
+	0xcc, 0x63, 0x7f, 0xc5, 0x2c, 0x2b, 0x2c, 0x91, 0x3c, 0x8b, 0x25, 0x9e, 0xe0, 0x58, 0xad, 0x8a,
+	0xc4, 0x13, 0x1c, 0xa3, 0x4f, 0xa1, 0x15, 0x46, 0xc4, 0xbf, 0xda, 0xbc, 0xc0, 0x57, 0x51, 0x8c,
+	0xd5, 0xda, 0xbe, 0x80, 0x05, 0x75, 0x5a, 0x77, 0x1c, 0xaf, 0x03, 0xac, 0xd6, 0x79, 0xdd, 0x8c,
+	0x40, 0xef, 0x01, 0x24, 0x38, 0xf6, 0x71, 0x32, 0xa3, 0x7d, 0x6a, 0x30, 0x51, 0x8e, 0x83, 0x7e,
+	0x0f, 0xad, 0x18, 0xcf, 0xd7, 0x71, 0x8c, 0xc3, 0x39, 0x36, 0x88, 0x0a, 0x7b, 0x9b, 0x53, 0xd0,
+	0x47, 0x1a, 0xb4, 0x44, 0xb3, 0xc6, 0xd1, 0xdc, 0x0b, 0xd4, 0x26, 0x8b, 0x50, 0xe0, 0xa1, 0x77,
+	0xa0, 0xea, 0x05, 0xc1, 0xc8, 0xdb, 0xa8, 0xad, 0x23, 0xe9, 0xb8, 0x6e, 0x0b, 0x0a, 0xbd, 0x0b,
+	0x0d, 0xa6, 0x37, 0xf2, 0x08, 0x56, 0xdb, 0xcc, 0x70, 0xcb, 0x40, 0x2a, 0xd4, 0x70, 0xb8, 0x60,
+	0xb2, 0x0e, 0x93, 0xa5, 0x24, 0x7a, 0x06, 0x2d, 0x12, 0x7b, 0x61, 0xb2, 0xf2, 0x68, 0x16, 0x1b,
+	0xb5, 0x7b, 0x24, 0x1d, 0x77, 0x06, 0x6d, 0xdd, 0xcd, 0x31, 0xed, 0x82, 0x0a, 0x7a, 0x02, 0xd5,
+	0x84, 0x78, 0x64, 0x9d, 0xa8, 0x0a, 0x53, 0xae, 0xe9, 0x0e, 0x23, 0x6d, 0xc1, 0xd6, 0xfe, 0x29,
+	0x43, 0xed, 0x3c, 0x8e, 0xae, 0xfc, 0x00, 0x67, 0xc3, 0x91, 0x72, 0xc3, 0xe9, 0x41, 0x9d, 0xae,
+	0xe1, 0x9f, 0xa3, 0x30, 0x5d, 0xac, 0x8c, 0x46, 0x1f, 0x40, 0xe3, 0x2d, 0xc6, 0x37, 0x0e, 0x4d,
+	0x9d, 0x6d, 0x57, 0x67, 0x50, 0xd7, 0x2f, 0x30, 0xbe, 0x59, 0x78, 0x1b, 0x7b, 0x2b, 0xa2, 0xb3,
+	0xe0, 0x95, 0xbf, 0x58, 0x27, 0x1b, 0xb6, 0x4f, 0x75, 0x3b, 0xc7, 0xa1, 0x13, 0xc4, 0x4b, 0xcf,
+	0x0f, 0xc4, 0xc2, 0x70, 0x82, 0x5a, 0xbd, 0xc5, 0x97, 0x6f, 0xa2, 0xe8, 0x66, 0x16, 0x07, 0x62,
+	0x61, 0x72, 0x1c, 0xf4, 0x31, 0xd4, 0xe7, 0x6f, 0xbc, 0x30, 0xc4, 0x41, 0xa2, 0xd6, 0x8e, 0x4a,
+	0xc7, 0x9d, 0xc1, 0x63, 0x7d, 0x42, 0x17, 0xc3, 0x9f, 0xb3, 0x35, 0x19, 0x72, 0xa1, 0x9d, 0x69,
+	0xd1, 0x79, 0x04, 0x74, 0x30, 0xe9, 0xaa, 0x08, 0x4a, 0xfb, 0x10, 0x0e, 0x5f, 0x62, 0x22, 0xba,
+	0x60, 0xe3, 0x2f, 0xd7, 0x38, 0x21, 0x77, 0x35, 0x43, 0x8b, 0xa1, 0x39, 0xf6, 0x13, 0x92, 0xaa,
+	0xe8, 0x50, 0x5e, 0xd0, 0x31, 0x49, 0x7b, 0x77, 0x87, 0xe9, 0xd1, 0x61, 0xac, 0x70, 0xec, 0x47,
+	0x0b, 0x55, 0x16, 0xc3, 0x38, 0x67, 0xa4, 0x2d, 0xd8, 0x59, 0xcc, 0x52, 0x2e, 0xe6, 0x1f, 0xa1,
+	0xc5, 0x63, 0x26, 0xab, 0x28, 0x4c, 0xe8, 0x62, 0x57, 0x31, 0xbd, 0x01, 0x89, 0x2a, 0x1d, 0x95,
+	0x8e, 0x9b, 0x83, 0xaa, 0xce, 0x4e, 0x82, 0x2d, 0xb8, 0xf7, 0x0d, 0x4c, 0xfb, 0xaf, 0x04, 0x0a,
+	0x73, 0xe6, 0x85, 0xd7, 0xf7, 0x15, 0x4a, 0x2b, 0xbb, 0x8a, 0xa3, 0xa5, 0x2a, 0xef, 0xaf, 0x8c,
+	0xea, 0xa1, 0x3e, 0xc8, 0x24, 0x7a, 0xc0, 0x81, 0x91, 0x49, 0x44, 0x13, 0x5c, 0x79, 0xd7, 0xd8,
+	0xf1, 0xff, 0x86, 0xd9, 0x2e, 0x54, 0xec, 0x8c, 0xa6, 0x5f, 0x06, 0xfd, 0xed, 0x46, 0x37, 0x38,
+	0x3d, 0x1f, 0x5b, 0x06, 0x3a, 0x82, 0x4a, 0x14, 0x2f, 0xc4, 0xf5, 0xe8, 0x0c, 0x40, 0x77, 0xa2,
+	0x98, 0x4c, 0x29, 0xc7, 0xe6, 0x02, 0x6d, 0x0d, 0x87, 0xb9, 0xfa, 0x1e, 0xd8, 0xb1, 0xf7, 0xa1,
+	0x1d, 0xe2, 0xaf, 0xc9, 0x79, 0x16, 0x98, 0xb7, 0xad, 0xc8, 0x2c, 0xf4, 0xb5, 0xb4, 0xd3, 0xd7,
+	0x6f, 0xa0, 0x75, 0xe1, 0x91, 0xf9, 0x9b, 0x1f, 0xa8, 0xa5, 0xda, 0x3f, 0x24, 0x68, 0x8b, 0x04,
+	0x44, 0xcd, 0x4f, 0xa0, 0x4c, 0x36, 0x2b, 0xbe, 0x9a, 0x9d, 0x41, 0x53, 0xa7, 0x1f, 0xc3, 0x35,
+	0x76, 0x37, 0x2b, 0x6c, 0x33, 0x41, 0xf6, 0x82, 0xc8, 0xb9, 0x17, 0x64, 0xdb, 0xa8, 0xd2, 0xde,
+	0xd5, 0x2a, 0xef, 0xb4, 0xe0, 0x97, 0xd0, 0x1e, 0xc6, 0xd8, 0x23, 0xd9, 0x5a, 0xbd, 0x0b, 0x15,
+	0x66, 0x26, 0xbe, 0x8e, 0xd4, 0x17, 0x67, 0x6a, 0xef, 0x43, 0x27, 0x55, 0x17, 0x19, 0xdf, 0xf1,
+	0xa4, 0x69, 0xff, 0x92, 0xa0, 0x3d, 0x5b, 0x2d, 0x72, 0x5e, 0xef, 0xd0, 0xda, 0x46, 0x92, 0xef,
+	0x88, 0x44, 0xa5, 0xc9, 0x3c, 0x5a, 0x61, 0x71, 0xa0, 0xaa, 0xba, 0x43, 0x29, 0x9b, 0x33, 0xd1,
+	0xef, 0x00, 0xa2, 0x79, 0x7a, 0xd6, 0xd5, 0xf2, 0xde, 0x6e, 0xe7, 0xb4, 0xb5, 0xbf, 0x43, 0x7b,
+	0x84, 0x03, 0xbc, 0x37, 0x39, 0x1e, 0x5e, 0xde, 0x1f, 0xbe, 0xf4, 0xbd, 0xc2, 0x7f, 0x2b, 0x41,
+	0xf7, 0x34, 0xc6, 0x98, 0x9e, 0xd0, 0x34, 0x83, 0xc7, 0x50, 0xa1, 0xcb, 0xc6, 0x37, 0xbd, 0x61,
+	0x73, 0xe2, 0xff, 0xba, 0x7a, 0x7f, 0x85, 0xba, 0x15, 0x12, 0x1c, 0x7f, 0xe5, 0xd1, 0x8b, 0x5c,
+	0x61, 0xcf, 0xd8, 0x03, 0x0e, 0x22, 0x57, 0x44, 0x4f, 0xa1, 0x84, 0xc3, 0xc5, 0x03, 0x12, 0xa3,
+	0x6a, 0xda, 0xa7, 0x50, 0x9f, 0x25, 0x38, 0x66, 0x6f, 0xc6, 0x5d, 0x9f, 0xd8, 0x4f, 0xa1, 0x7c,
+	0x49, 0x5f, 0x18, 0x99, 0x6d, 0x6f, 0x43, 0x4f, 0x13, 0xb3, 0x19, 0x5b, 0xfb, 0x04, 0x94, 0x6d,
+	0xbf, 0xb2, 0xef, 0x24, 0xd7, 0x30, 0x6a, 0x93, 0x06, 0x10, 0xbd, 0xd3, 0x02, 0x68, 0x5d, 0x44,
+	0xf1, 0x8d, 0x1f, 0x5e, 0x9f, 0x45, 0xeb, 0x38, 0x41, 0x27, 0xc5, 0x1a, 0xef, 0x41, 0x29, 0xa2,
+	0xc4, 0x8f, 0xf2, 0x25, 0xde, 0xa3, 0xce, 0x2a, 0xfc, 0x56, 0x86, 0xee, 0xa9, 0x1f, 0x2e, 0x9c,
+	0x20, 0x22, 0xf7, 0xcf, 0x34, 0x8f, 0xd0, 0xe4, 0x87, 0x23, 0xb4, 0x74, 0x15, 0x4a, 0xdf, 0x6b,
+	0x15, 0xca, 0x0f, 0x3a, 0xec, 0xcf, 0xa0, 0xf5, 0x36, 0xd7, 0x2a, 0x76, 0xbf, 0x9b, 0x83, 0xb6,
+	0x9e, 0xef, 0x9f, 0x5d, 0x50, 0xa1, 0xb5, 0x05, 0xfe, 0xd2, 0x27, 0xec, 0xa2, 0x57, 0x6c, 0x4e,
+	0xb0, 0x41, 0x65, 0x4d, 0xd8, 0x0e, 0x2a, 0x09, 0x22, 0xb2, 0x1d, 0x54, 0x36, 0x5c, 0xce, 0xd7,
+	0x7e, 0x06, 0x6d, 0xf3, 0xeb, 0x55, 0x14, 0x93, 0xfb, 0x1e, 0xf0, 0xa7, 0xd0, 0x49, 0x95, 0x84,
+	0xdf, 0x1e, 0xd4, 0x29, 0x06, 0x08, 0x17, 0x5e, 0xaa, 0x99, 0xd1, 0xda, 0x1f, 0xa0, 0x6d, 0x2d,
+	0xf7, 0xb8, 0x2c, 0x38, 0x90, 0x77, 0x1c, 0x7c, 0x09, 0xad, 0xd4, 0x41, 0xb2, 0x0e, 0x08, 0x52,
+	0xa0, 0xb4, 0xbd, 0x0f, 0xf4, 0xe7, 0x9d, 0x67, 0xf8, 0xe7, 0x19, 0x66, 0x2b, 0x09, 0x80, 0xc7,
+	0x9d, 0x14, 0x91, 0x1b, 0x43, 0x4d, 0x71, 0x1c, 0xc5, 0xe2, 0x14, 0x73, 0x42, 0xfb, 0x2d, 0x74,
+	0xac, 0x65, 0xa1, 0xc2, 0x0f, 0xa1, 0x16, 0xb3, 0xf0, 0x69, 0xef, 0xda, 0x7a, 0x3e, 0x29, 0x3b,
+	0x95, 0xf6, 0x3f, 0x82, 0x56, 0x1e, 0x49, 0x22, 0x80, 0xea, 0xf4, 0xdc, 0xf8, 0xd3, 0xcc, 0x54,
+	0x0e, 0x50, 0x17, 0x9a, 0xae, 0x6d, 0x4c, 0x9c, 0x73, 0xc3, 0x36, 0x27, 0xae, 0x22, 0xf5, 0x9f,
+	0x43, 0x95, 0xe7, 0x83, 0xda, 0xd0, 0x18, 0x4e, 0x27, 0xa7, 0x96, 0xfd, 0xca, 0x1c, 0x29, 0x07,
+	0x94, 0x74, 0xcd, 0x89, 0x6b, 0xb8, 0xd6, 0xe7, 0xa6, 0x22, 0x31, 0xa9, 0x31, 0x19, 0x9a, 0xe3,
+	0xb1, 0x39, 0x52, 0xe4, 0xfe, 0x07, 0x50, 0xe5, 0x98, 0x07, 0xd5, 0xa0, 0x34, 0x32, 0xbe, 0x50,
+	0x0e, 0x50, 0x1d, 0xca, 0x17, 0xa6, 0xf9, 0x99, 0x22, 0xa1, 0x06, 0x54, 0x5e, 0x4d, 0x27, 0xee,
+	0x99, 0x22, 0xf7, 0xfb, 0xd0, 0xc8, 0x1e, 0x77, 0xea, 0xc3, 0x71, 0x0d, 0xdb, 0x7d, 0x6d, 0x38,
+	0x43, 0xe5, 0x00, 0x75, 0x00, 0x38, 0x39, 0x32, 0x9d, 0xa1, 0x22, 0xf5, 0x07, 0x50, 0x61, 0x47,
+	0x95, 0x26, 0xec, 0x98, 0xb6, 0x65, 0x3a, 0x5c, 0x69, 0x3a, 0x1c, 0xce, 0x6c, 0xdb, 0x9c, 0x0c,
+	0x45, 0x1e, 0xa7, 0xd3, 0xf1, 0x78, 0x7a, 0x61, 0x4d, 0x5e, 0x2a, 0x72, 0xff, 0x1b, 0xa8, 0x09,
+	0xa0, 0x8a, 0x7e, 0x04, 0x8f, 0x68, 0xfc, 0x91, 0xf1, 0xc5, 0xeb, 0xd9, 0xc4, 0x39, 0x37, 0x87,
+	0xd6, 0xa9, 0xc5, 0x2a, 0x01, 0xa8, 0xbe, 0x9a, 0x4e, 0x68, 0x92, 0x12, 0x6a, 0x42, 0xcd, 0x9d,
+	0x99, 0x0e, 0x25, 0x64, 0xea, 0xeb, 0xc2, 0x1c, 0x4d, 0x38, 0x59, 0x42, 0x2d, 0xa8, 0xbb, 0x67,
+	0x33, 0x9b, 0x51, 0x65, 0x6a, 0x75, 0x6a, 0x5b, 0xf4, 0x77, 0x85, 0x4a, 0x1c, 0xc3, 0x9d, 0xd9,
+	0x94, 0xaa, 0xb2, 0xf4, 0x66, 0xcc, 0x5f, 0xad, 0xff, 0x6b, 0x78, 0x74, 0x07, 0x56, 0xa5, 0xbd,
+	0x38, 0xb5, 0xc6, 0xb4, 0xe1, 0x0d, 0xa8, 0x98, 0xaf, 0x0c, 0x6b, 0xcc, 0x63, 0x5f, 0x98, 0x2f,
+	0xce, 0xa6, 0xd3, 0xcf, 0x14, 0xb9, 0xff, 0x17, 0x80, 0xed, 0x5b, 0x8e, 0x1e, 0x41, 0x77, 0x78,
+	0x66, 0x4c, 0x5e, 0x9a, 0xaf, 0x9d, 0x89, 0x71, 0xee, 0x9c, 0x4d, 0x5d, 0xe5, 0x00, 0x21, 0xe8,
+	0x08, 0xe6, 0xd0, 0x36, 0x0d, 0xd7, 0x1c, 0x29, 0x52, 0x8e, 0x37, 0x3b, 0x1f, 0x31, 0x9e, 0x9c,
+	0xe3, 0x8d, 0xcc, 0xb1, 0x49, 0x79, 0xa5, 0xfe, 0x30, 0xdd, 0x58, 0x31, 0xdc, 0x26, 0xd4, 0x52,
+	0x27, 0x07, 0x94, 0x48, 0xad, 0x25, 0x5a, 0x1b, 0x1d, 0xfb, 0xd8, 0x1a, 0xba, 0x8a, 0x4c, 0x45,
+	0xd6, 0xe4, 0x73, 0x63, 0x6c, 0x8d, 0x94, 0xd2, 0xe0, 0x3f, 0x65, 0xa8, 0x9a, 0x1c, 0x32, 0xfc,
+	0x02, 0x80, 0x02, 0x32, 0x41, 0xb5, 0xf4, 0x1c, 0x7c, 0xee, 0xb5, 0xf5, 0x02, 0xb0, 0xfd, 0x0d,
+	0x74, 0xb7, 0xaa, 0x0c, 0xc1, 0xa1, 0x43, 0x7d, 0x17, 0xad, 0xf6, 0x90, 0x7e, 0x1b, 0xe0, 0xe9,
+	0xd0, 0x64, 0xe8, 0x47, 0x44, 0x69, 0xeb, 0x79, 0x30, 0xd6, 0xeb, 0xe8, 0x05, 0x68, 0xf4, 0xb1,
+	0x44, 0xf5, 0x39, 0xf8, 0x60, 0x06, 0xa8, 0xa3, 0x17, 0x90, 0x4b, 0xaf, 0xab, 0xef, 0x40, 0x93,
+	0xe7, 0xd0, 0xe4, 0x28, 0x24, 0xd5, 0x2f, 0x60, 0x92, 0xde, 0x3b, 0xb7, 0xee, 0xa2, 0x49, 0xff,
+	0xf5, 0x53, 0x33, 0x8e, 0x0f, 0x52, 0xb3, 0x02, 0x5a, 0xf8, 0x4e, 0xb3, 0x3e, 0xc0, 0xf6, 0xdf,
+	0x08, 0x42, 0xfa, 0xad, 0xbf, 0x26, 0xbd, 0xba, 0x9e, 0x4a, 0x9f, 0xa5, 0xf8, 0x28, 0x65, 0x64,
+	0xa2, 0xef, 0x74, 0x7f, 0x02, 0xf5, 0xf4, 0x15, 0x44, 0x8a, 0xbe, 0x03, 0x20, 0x7a, 0x87, 0xfa,
+	0xad, 0x27, 0x92, 0x1a, 0x88, 0x6b, 0x4c, 0x0d, 0x8a, 0xaf, 0x53, 0xef, 0x30, 0xc7, 0x11, 0x06,
+	0x4f, 0xa1, 0xc1, 0x8f, 0xac, 0x35, 0x74, 0x50, 0x47, 0x2f, 0x5c, 0xe5, 0x5e, 0x57, 0xdf, 0x39,
+	0xc0, 0x4f, 0xa1, 0x61, 0x2d, 0xb7, 0xda, 0x85, 0x83, 0xdb, 0xeb, 0xea, 0xc5, 0x63, 0x76, 0x59,
+	0x65, 0xd5, 0x7c, 0xf2, 0xbf, 0x01, 0x00, 0x4e, 0x80, 0x60, 0xab, 0x78, 0x11, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -461,9 +1922,17 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type EventsClient interface {
 	ListEvents(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	ListEventsRange(ctx context.Context, in *ListRangeRequest, opts ...grpc.CallOption) (*ListRangeResponse, error)
+	WatchEvents(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Events_WatchEventsClient, error)
 	CreateEvent(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error)
 	UpdateEvent(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	DeleteEvent(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	GetProfile(ctx context.Context, in *GetProfileRequest, opts ...grpc.CallOption) (*Profile, error)
+	UpdateProfile(ctx context.Context, in *Profile, opts ...grpc.CallOption) (*empty.Empty, error)
+	FreeBusy(ctx context.Context, in *FreeBusyRequest, opts ...grpc.CallOption) (*FreeBusyResponse, error)
+	FindSlot(ctx context.Context, in *FindSlotRequest, opts ...grpc.CallOption) (*FindSlotResponse, error)
+	ExportICS(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error)
+	ImportICS(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*ImportResponse, error)
 }
 
 type eventsClient struct {
@@ -483,6 +1952,47 @@ func (c *eventsClient) ListEvents(ctx context.Context, in *ListRequest, opts ...
 	return out, nil
 }
 
+func (c *eventsClient) ListEventsRange(ctx context.Context, in *ListRangeRequest, opts ...grpc.CallOption) (*ListRangeResponse, error) {
+	out := new(ListRangeResponse)
+	err := c.cc.Invoke(ctx, "/Events/ListEventsRange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventsClient) WatchEvents(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (Events_WatchEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Events_serviceDesc.Streams[0], "/Events/WatchEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventsWatchEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Events_WatchEventsClient interface {
+	Recv() (*WatchResponse, error)
+	grpc.ClientStream
+}
+
+type eventsWatchEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventsWatchEventsClient) Recv() (*WatchResponse, error) {
+	m := new(WatchResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *eventsClient) CreateEvent(ctx context.Context, in *CreateRequest, opts ...grpc.CallOption) (*CreateResponse, error) {
 	out := new(CreateResponse)
 	err := c.cc.Invoke(ctx, "/Events/CreateEvent", in, out, opts...)
@@ -510,12 +2020,74 @@ func (c *eventsClient) DeleteEvent(ctx context.Context, in *DeleteRequest, opts
 	return out, nil
 }
 
+func (c *eventsClient) GetProfile(ctx context.Context, in *GetProfileRequest, opts ...grpc.CallOption) (*Profile, error) {
+	out := new(Profile)
+	err := c.cc.Invoke(ctx, "/Events/GetProfile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventsClient) UpdateProfile(ctx context.Context, in *Profile, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/Events/UpdateProfile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventsClient) FreeBusy(ctx context.Context, in *FreeBusyRequest, opts ...grpc.CallOption) (*FreeBusyResponse, error) {
+	out := new(FreeBusyResponse)
+	err := c.cc.Invoke(ctx, "/Events/FreeBusy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventsClient) FindSlot(ctx context.Context, in *FindSlotRequest, opts ...grpc.CallOption) (*FindSlotResponse, error) {
+	out := new(FindSlotResponse)
+	err := c.cc.Invoke(ctx, "/Events/FindSlot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventsClient) ExportICS(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error) {
+	out := new(ExportResponse)
+	err := c.cc.Invoke(ctx, "/Events/ExportICS", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *eventsClient) ImportICS(ctx context.Context, in *ImportRequest, opts ...grpc.CallOption) (*ImportResponse, error) {
+	out := new(ImportResponse)
+	err := c.cc.Invoke(ctx, "/Events/ImportICS", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EventsServer is the server API for Events service.
 type EventsServer interface {
 	ListEvents(context.Context, *ListRequest) (*ListResponse, error)
+	ListEventsRange(context.Context, *ListRangeRequest) (*ListRangeResponse, error)
+	WatchEvents(*WatchRequest, Events_WatchEventsServer) error
 	CreateEvent(context.Context, *CreateRequest) (*CreateResponse, error)
 	UpdateEvent(context.Context, *UpdateRequest) (*empty.Empty, error)
 	DeleteEvent(context.Context, *DeleteRequest) (*empty.Empty, error)
+	GetProfile(context.Context, *GetProfileRequest) (*Profile, error)
+	UpdateProfile(context.Context, *Profile) (*empty.Empty, error)
+	FreeBusy(context.Context, *FreeBusyRequest) (*FreeBusyResponse, error)
+	FindSlot(context.Context, *FindSlotRequest) (*FindSlotResponse, error)
+	ExportICS(context.Context, *ExportRequest) (*ExportResponse, error)
+	ImportICS(context.Context, *ImportRequest) (*ImportResponse, error)
 }
 
 // UnimplementedEventsServer can be embedded to have forward compatible implementations.
@@ -525,6 +2097,12 @@ type UnimplementedEventsServer struct {
 func (*UnimplementedEventsServer) ListEvents(ctx context.Context, req *ListRequest) (*ListResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListEvents not implemented")
 }
+func (*UnimplementedEventsServer) ListEventsRange(ctx context.Context, req *ListRangeRequest) (*ListRangeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEventsRange not implemented")
+}
+func (*UnimplementedEventsServer) WatchEvents(req *WatchRequest, srv Events_WatchEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchEvents not implemented")
+}
 func (*UnimplementedEventsServer) CreateEvent(ctx context.Context, req *CreateRequest) (*CreateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateEvent not implemented")
 }
@@ -534,6 +2112,24 @@ func (*UnimplementedEventsServer) UpdateEvent(ctx context.Context, req *UpdateRe
 func (*UnimplementedEventsServer) DeleteEvent(ctx context.Context, req *DeleteRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteEvent not implemented")
 }
+func (*UnimplementedEventsServer) GetProfile(ctx context.Context, req *GetProfileRequest) (*Profile, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProfile not implemented")
+}
+func (*UnimplementedEventsServer) UpdateProfile(ctx context.Context, req *Profile) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateProfile not implemented")
+}
+func (*UnimplementedEventsServer) FreeBusy(ctx context.Context, req *FreeBusyRequest) (*FreeBusyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FreeBusy not implemented")
+}
+func (*UnimplementedEventsServer) FindSlot(ctx context.Context, req *FindSlotRequest) (*FindSlotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindSlot not implemented")
+}
+func (*UnimplementedEventsServer) ExportICS(ctx context.Context, req *ExportRequest) (*ExportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportICS not implemented")
+}
+func (*UnimplementedEventsServer) ImportICS(ctx context.Context, req *ImportRequest) (*ImportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ImportICS not implemented")
+}
 
 func RegisterEventsServer(s *grpc.Server, srv EventsServer) {
 	s.RegisterService(&_Events_serviceDesc, srv)
@@ -557,6 +2153,45 @@ func _Events_ListEvents_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Events_ListEventsRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsServer).ListEventsRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Events/ListEventsRange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsServer).ListEventsRange(ctx, req.(*ListRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Events_WatchEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventsServer).WatchEvents(m, &eventsWatchEventsServer{stream})
+}
+
+type Events_WatchEventsServer interface {
+	Send(*WatchResponse) error
+	grpc.ServerStream
+}
+
+type eventsWatchEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventsWatchEventsServer) Send(m *WatchResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Events_CreateEvent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateRequest)
 	if err := dec(in); err != nil {
@@ -611,6 +2246,114 @@ func _Events_DeleteEvent_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Events_GetProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsServer).GetProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Events/GetProfile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsServer).GetProfile(ctx, req.(*GetProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Events_UpdateProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Profile)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsServer).UpdateProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Events/UpdateProfile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsServer).UpdateProfile(ctx, req.(*Profile))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Events_FreeBusy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FreeBusyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsServer).FreeBusy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Events/FreeBusy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsServer).FreeBusy(ctx, req.(*FreeBusyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Events_FindSlot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindSlotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsServer).FindSlot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Events/FindSlot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsServer).FindSlot(ctx, req.(*FindSlotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Events_ExportICS_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsServer).ExportICS(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Events/ExportICS",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsServer).ExportICS(ctx, req.(*ExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Events_ImportICS_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EventsServer).ImportICS(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/Events/ImportICS",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EventsServer).ImportICS(ctx, req.(*ImportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Events_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "Events",
 	HandlerType: (*EventsServer)(nil),
@@ -619,6 +2362,10 @@ var _Events_serviceDesc = grpc.ServiceDesc{
 			MethodName: "ListEvents",
 			Handler:    _Events_ListEvents_Handler,
 		},
+		{
+			MethodName: "ListEventsRange",
+			Handler:    _Events_ListEventsRange_Handler,
+		},
 		{
 			MethodName: "CreateEvent",
 			Handler:    _Events_CreateEvent_Handler,
@@ -631,7 +2378,37 @@ var _Events_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteEvent",
 			Handler:    _Events_DeleteEvent_Handler,
 		},
+		{
+			MethodName: "GetProfile",
+			Handler:    _Events_GetProfile_Handler,
+		},
+		{
+			MethodName: "UpdateProfile",
+			Handler:    _Events_UpdateProfile_Handler,
+		},
+		{
+			MethodName: "FreeBusy",
+			Handler:    _Events_FreeBusy_Handler,
+		},
+		{
+			MethodName: "FindSlot",
+			Handler:    _Events_FindSlot_Handler,
+		},
+		{
+			MethodName: "ExportICS",
+			Handler:    _Events_ExportICS_Handler,
+		},
+		{
+			MethodName: "ImportICS",
+			Handler:    _Events_ImportICS_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchEvents",
+			Handler:       _Events_WatchEvents_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/api.proto",
 }